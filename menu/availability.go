@@ -0,0 +1,134 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Date is a bound of an item's season: a calendar date, or a
+// month and day that recur every year when year is 0.
+type Date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// ParseDate reads a YYYY-MM-DD date or an MM-DD yearly date.
+func ParseDate(raw string) (Date, error) {
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return Date{t.Year(), t.Month(), t.Day()}, nil
+	}
+	// 2000 is a leap year, so 02-29 is accepted.
+	t, err := time.Parse("2006-01-02", "2000-"+raw)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD, or MM-DD for every year", raw)
+	}
+	return Date{0, t.Month(), t.Day()}, nil
+}
+
+// Compare returns a negative number when d falls before date, zero on the
+// same day and a positive number after it. A yearly date is compared within
+// the year of date.
+func (d Date) Compare(date time.Time) int {
+	year := d.year
+	if year == 0 {
+		year = date.Year()
+	}
+	a := [3]int{year, int(d.month), d.day}
+	b := [3]int{date.Year(), int(date.Month()), date.Day()}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return 0
+}
+
+// Yearly reports whether d recurs every year.
+func (d Date) Yearly() bool {
+	return d.year == 0
+}
+
+// isAvailableOn reports whether the item is in season on date. Both bounds
+// are inclusive; a yearly window whose start comes after its end, such as
+// 11-01 to 02-28, wraps around the new year. Bounds that cannot be parsed
+// are ignored; CheckAvailability reports them.
+func (item Item) isAvailableOn(date time.Time) bool {
+	from, fromErr := ParseDate(item.AvailableFrom)
+	until, untilErr := ParseDate(item.AvailableUntil)
+	hasFrom := item.AvailableFrom != "" && fromErr == nil
+	hasUntil := item.AvailableUntil != "" && untilErr == nil
+	if hasFrom && hasUntil && from.year == 0 && until.year == 0 &&
+		(from.month > until.month || from.month == until.month && from.day > until.day) {
+		return from.Compare(date) <= 0 || until.Compare(date) >= 0
+	}
+	return (!hasFrom || from.Compare(date) <= 0) && (!hasUntil || until.Compare(date) >= 0)
+}
+
+// isServedOnDay reports whether the item may be served on the named weekday.
+func (item Item) isServedOnDay(dayName string) bool {
+	return len(item.ServedOn) == 0 || ContainsFold(item.ServedOn, dayName)
+}
+
+// IsRestricted reports whether the item is only served on some days: part
+// of the year or some days of the week.
+func (item Item) IsRestricted() bool {
+	return item.AvailableFrom != "" || item.AvailableUntil != "" || len(item.ServedOn) > 0
+}
+
+// CheckAvailability reports what is wrong with the item's season or
+// weekdays, and the field at fault.
+func CheckAvailability(item Item) (string, error) {
+	for _, day := range item.ServedOn {
+		if !IsDayName(day) {
+			return "served_on", fmt.Errorf("unknown day %q", day)
+		}
+	}
+	var from, until Date
+	var err error
+	if item.AvailableFrom != "" {
+		if from, err = ParseDate(item.AvailableFrom); err != nil {
+			return "available_from", err
+		}
+	}
+	if item.AvailableUntil != "" {
+		if until, err = ParseDate(item.AvailableUntil); err != nil {
+			return "available_until", err
+		}
+	}
+	// Yearly windows may wrap around the new year; dated ones may not.
+	if from.year != 0 && until.year != 0 &&
+		from.Compare(time.Date(until.year, until.month, until.day, 0, 0, 0, 0, time.UTC)) > 0 {
+		return "available_until", fmt.Errorf("%s is before available_from %s", item.AvailableUntil, item.AvailableFrom)
+	}
+	return "", nil
+}
+
+// Unavailable returns the names of the restricted items that may not
+// be served on date, the named weekday, or nil when every item may be served.
+func Unavailable(restricted []Item, date time.Time, dayName string) map[string]bool {
+	var names map[string]bool
+	for _, item := range restricted {
+		if !item.isAvailableOn(date) || !item.isServedOnDay(dayName) {
+			if names == nil {
+				names = make(map[string]bool)
+			}
+			names[item.ItemName] = true
+		}
+	}
+	return names
+}
+
+// DayNames labels the days of a plan; plans longer than a week wrap around.
+var DayNames = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// IsDayName reports whether name is a weekday name, ignoring case.
+func IsDayName(name string) bool {
+	for _, day := range DayNames {
+		if strings.EqualFold(day, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package menu
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvListSeparator separates the values of list fields within a single CSV cell.
+const csvListSeparator = ";"
+
+// requiredCSVColumns must be present in the header of an imported CSV menu.
+var requiredCSVColumns = []string{"item_name", "category", "calories", "popularity_score"}
+
+// menuItemCSVFields maps JSON field names of Item to their struct field index,
+// so CSV columns use the same names as the JSON menu.
+var menuItemCSVFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Item{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// ParseCSV reads menu items from CSV. The first row is a header naming
+// Item JSON fields (e.g. item_name, category, calories); unknown columns
+// are ignored so spreadsheet exports with extra columns can be imported as is.
+func ParseCSV(r io.Reader) ([]Item, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV menu is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make([]int, len(header)) // struct field index per column, or -1
+	present := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[i] = -1
+		if field, ok := menuItemCSVFields[name]; ok {
+			columns[i] = field
+			present[name] = true
+		}
+	}
+	for _, name := range requiredCSVColumns {
+		if !present[name] {
+			return nil, fmt.Errorf("CSV menu is missing required column %q", name)
+		}
+	}
+
+	var items []Item
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row: %w", err)
+		}
+		var item Item
+		v := reflect.ValueOf(&item).Elem()
+		for i, cell := range record {
+			if columns[i] < 0 {
+				continue
+			}
+			if err := setCSVField(v.Field(columns[i]), strings.TrimSpace(cell)); err != nil {
+				return nil, fmt.Errorf("line %d, column %q: %w", line, header[i], err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// setCSVField parses a CSV cell into a Item field according to its type.
+func setCSVField(field reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int:
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", cell)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", cell)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", cell)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			// Lists of structured values, such as portions, are written as JSON.
+			if err := json.Unmarshal([]byte(cell), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("invalid JSON value %q: %w", cell, err)
+			}
+			return nil
+		}
+		var values []string
+		for _, part := range strings.Split(cell, csvListSeparator) {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		// Structured fields cannot be expressed in a single cell; accept JSON.
+		if err := json.Unmarshal([]byte(cell), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid JSON value %q: %w", cell, err)
+		}
+	}
+	return nil
+}
+
+// IsCSVContentType reports whether a Content-Type header denotes CSV.
+func IsCSVContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/csv" || mediaType == "application/csv"
+}
+
+// Parse reads menu items from r, as CSV when contentType denotes CSV and as
+// a JSON array otherwise.
+func Parse(r io.Reader, contentType string) ([]Item, error) {
+	if IsCSVContentType(contentType) {
+		return ParseCSV(r)
+	}
+	var items []Item
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid JSON menu: %w", err)
+	}
+	return items, nil
+}
+
+// loadMenuFromCSV reads the master menu from a CSV file.
+func loadMenuFromCSV(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read menu file %s: %w", path, err)
+	}
+	defer f.Close()
+	items, err := ParseCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV from %s: %w", path, err)
+	}
+	return items, nil
+}
+
+// LoadFile reads the master menu, choosing the format by file extension.
+func LoadFile(path string) ([]Item, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return loadMenuFromCSV(path)
+	}
+	return loadMenuFromJSON(path)
+}
@@ -0,0 +1,37 @@
+package menu
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NameKey folds an item name for duplicate detection, ignoring case, runs
+// of whitespace and diacritics, so "Crème  Brûlée" and "creme brulee" share
+// a key.
+func NameKey(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Deduplicate returns items without each item whose name has the NameKey of
+// an earlier one, which is kept, and the items it left out.
+func Deduplicate(items []Item) (kept, dropped []Item) {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		key := NameKey(item.ItemName)
+		if seen[key] {
+			dropped = append(dropped, item)
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, item)
+	}
+	return kept, dropped
+}
@@ -0,0 +1,61 @@
+package menu
+
+import (
+	"fmt"
+	"math"
+)
+
+// Energy units calories can be given and reported in. Items, options and
+// plans hold kilocalories; other units are converted on the way in and out.
+const (
+	UnitKcal = "kcal"
+	UnitKJ   = "kJ"
+)
+
+// kJPerKcal is the thermochemical calorie in kilojoules.
+const kJPerKcal = 4.184
+
+// ParseEnergyUnit returns the unit named by raw, which must be spelled as
+// UnitKcal or UnitKJ; empty means kilocalories.
+func ParseEnergyUnit(raw string) (string, error) {
+	switch raw {
+	case "", UnitKcal:
+		return UnitKcal, nil
+	case UnitKJ:
+		return UnitKJ, nil
+	default:
+		return "", fmt.Errorf("unknown energy unit %q (expected %s or %s)", raw, UnitKcal, UnitKJ)
+	}
+}
+
+// EnergyFactor returns how many of unit make a kilocalorie.
+func EnergyFactor(unit string) float64 {
+	if unit == UnitKJ {
+		return kJPerKcal
+	}
+	return 1
+}
+
+// ToKcal converts an energy value given in unit to whole kilocalories.
+func ToKcal(value int, unit string) int {
+	return int(math.Round(float64(value) / EnergyFactor(unit)))
+}
+
+// FromKcal converts whole kilocalories to unit, rounding to a whole number.
+func FromKcal(kcal int, unit string) int {
+	return int(math.Round(float64(kcal) * EnergyFactor(unit)))
+}
+
+// ItemsToKcal converts the calories of items and their portions, given in
+// unit, to kilocalories in place.
+func ItemsToKcal(items []Item, unit string) {
+	if EnergyFactor(unit) == 1 {
+		return
+	}
+	for i := range items {
+		items[i].Calories = ToKcal(items[i].Calories, unit)
+		for j := range items[i].Portions {
+			items[i].Portions[j].Calories = ToKcal(items[i].Portions[j].Calories, unit)
+		}
+	}
+}
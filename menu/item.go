@@ -0,0 +1,109 @@
+// Package menu describes the items of a cafeteria menu and loads, checks
+// and filters them.
+package menu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Item represents a single item in the master menu.
+type Item struct {
+	ItemName string `json:"item_name"`
+	Category string `json:"category"`
+	// Categories lists further categories the item may fill besides
+	// Category, such as a smoothie that is a drink but may also be served
+	// as a side. A combo never serves the same item twice.
+	Categories      []string `json:"categories,omitempty"`
+	Calories        int      `json:"calories"`
+	TasteProfile    string   `json:"taste_profile"`
+	PopularityScore float64  `json:"popularity_score"`
+	ProteinGrams    float64  `json:"protein_g,omitempty"`
+	SodiumMg        float64  `json:"sodium_mg,omitempty"`
+	SugarGrams      float64  `json:"sugar_g,omitempty"`
+	CarbsGrams      float64  `json:"carbs_g,omitempty"`
+	FatGrams        float64  `json:"fat_g,omitempty"`
+	Price           float64  `json:"price,omitempty"`
+	// AvailableFrom and AvailableUntil bound the season the item is served
+	// in, both inclusive. Each is a date (YYYY-MM-DD) or a month and day
+	// (MM-DD) recurring every year; empty means no bound.
+	AvailableFrom  string `json:"available_from,omitempty"`
+	AvailableUntil string `json:"available_until,omitempty"`
+	// ServedOn lists the weekdays the item may be served on, such as
+	// "Friday"; an item without days may be served on any day.
+	ServedOn []string `json:"served_on,omitempty"`
+	// Stock is the number of servings on hand; each combo serving the item
+	// uses one, and the generator schedules no more than there are. Nil
+	// means the item is not stocked in limited quantities.
+	Stock *int `json:"stock,omitempty"`
+	// Meals lists the meal slots the item may be served at, such as
+	// "breakfast"; an item without meals may be served at any meal.
+	Meals []string `json:"meals,omitempty"`
+	// DietaryTags lists properties such as "vegetarian", "vegan" or "gluten-free".
+	DietaryTags []string `json:"dietary_tags,omitempty"`
+	// Allergens lists allergens the item contains, such as "dairy" or "gluten".
+	Allergens []string `json:"allergens,omitempty"`
+	// Portions lists size variants the generator may serve instead of the
+	// regular portion described by the fields above.
+	Portions []Portion `json:"portions,omitempty"`
+	// Portion is the size chosen for a combo; empty for the regular portion.
+	Portion string `json:"-"`
+}
+
+// loadMenuFromJSON reads the master menu from a JSON file.
+func loadMenuFromJSON(path string) ([]Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read menu file %s: %w", path, err)
+	}
+	var items []Item
+	err = json.Unmarshal(data, &items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from %s: %w", path, err)
+	}
+	return items, nil
+}
+
+// AllCategories returns Category followed by the further Categories of the
+// item, each once.
+func (item Item) AllCategories() []string {
+	all := []string{item.Category}
+	for _, category := range item.Categories {
+		if !slices.Contains(all, category) {
+			all = append(all, category)
+		}
+	}
+	return all
+}
+
+// InCategory reports whether the item may fill category.
+func (item Item) InCategory(category string) bool {
+	return item.Category == category || slices.Contains(item.Categories, category)
+}
+
+// Categorize groups menu items by their categories; an item with several
+// is listed under each.
+func Categorize(items []Item) map[string][]Item {
+	categorized := make(map[string][]Item)
+	for _, item := range items {
+		for _, category := range item.AllCategories() {
+			categorized[category] = append(categorized[category], item)
+		}
+	}
+	return categorized
+}
+
+// ComboMetrics calculates total calories and average popularity.
+func ComboMetrics(items ...Item) (int, float64) {
+	if len(items) == 0 {
+		return 0, 0
+	}
+	totalCalories, totalPopularity := 0, 0.0
+	for _, item := range items {
+		totalCalories += item.Calories
+		totalPopularity += item.PopularityScore
+	}
+	return totalCalories, totalPopularity / float64(len(items))
+}
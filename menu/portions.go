@@ -0,0 +1,65 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// regularPortion names an item's own calories and price, which are used
+// unless the generator picks one of its other portions.
+const regularPortion = "regular"
+
+// Portion is a size variant of a menu item, such as "small" or "large", with
+// its own calories and price. Macros, sodium and sugar scale with calories.
+type Portion struct {
+	Size     string `json:"size"`
+	Calories int    `json:"calories"`
+	// Price of the portion; when zero the item's price is scaled with calories.
+	Price float64 `json:"price,omitempty"`
+}
+
+// WithPortion returns item served in portion p.
+func WithPortion(item Item, p Portion) Item {
+	ratio := 1.0
+	if item.Calories > 0 {
+		ratio = float64(p.Calories) / float64(item.Calories)
+	}
+	sized := item
+	sized.Portion = p.Size
+	sized.Calories = p.Calories
+	sized.Price = p.Price
+	if sized.Price == 0 {
+		sized.Price = RoundPrice(item.Price * ratio)
+	}
+	sized.ProteinGrams *= ratio
+	sized.CarbsGrams *= ratio
+	sized.FatGrams *= ratio
+	sized.SodiumMg *= ratio
+	sized.SugarGrams *= ratio
+	return sized
+}
+
+// validatePortions returns a problem message for each unusable portion of item.
+func validatePortions(item Item) []string {
+	var problems []string
+	seen := make(map[string]bool, len(item.Portions))
+	for i, p := range item.Portions {
+		size := strings.ToLower(strings.TrimSpace(p.Size))
+		switch {
+		case size == "":
+			problems = append(problems, fmt.Sprintf("portion %d has no size", i+1))
+		case size == regularPortion:
+			problems = append(problems, fmt.Sprintf("portion %q repeats the item's own calories and price", p.Size))
+		case seen[size]:
+			problems = append(problems, fmt.Sprintf("duplicate portion %q", p.Size))
+		}
+		seen[size] = true
+		if p.Calories <= 0 {
+			problems = append(problems, fmt.Sprintf("portion %q must have positive calories", p.Size))
+		}
+		if p.Price < 0 {
+			problems = append(problems, fmt.Sprintf("portion %q price must not be negative", p.Size))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,17 @@
+package menu
+
+import "math"
+
+// Price returns the combined price of items.
+func Price(items ...Item) float64 {
+	total := 0.0
+	for _, item := range items {
+		total += item.Price
+	}
+	return total
+}
+
+// RoundPrice rounds a price to two decimal places.
+func RoundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,34 @@
+package menu
+
+import "strings"
+
+// ContainsFold reports whether values contains value, ignoring case.
+func ContainsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllTags reports whether item carries every tag in tags, ignoring case.
+func HasAllTags(item Item, tags []string) bool {
+	for _, tag := range tags {
+		if !ContainsFold(item.DietaryTags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchingAllergens returns the allergens of item that appear in excluded.
+func MatchingAllergens(item Item, excluded []string) []string {
+	var matches []string
+	for _, allergen := range item.Allergens {
+		if ContainsFold(excluded, allergen) {
+			matches = append(matches, allergen)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,193 @@
+package menu
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// knownCategories are the categories the generator combines into combos.
+var knownCategories = []string{"main", "side", "drink", "dessert", "snack"}
+
+// requiredCategories are the categories of the standard main+side+drink
+// combo, which a menu must offer; desserts and snacks only fill templates
+// that ask for them.
+var requiredCategories = knownCategories[:3]
+
+// Severities of menu diagnostics.
+const (
+	// severityError marks a problem that makes generation produce nonsense
+	// or nothing at all.
+	severityError = "error"
+	// severityWarning marks a value that is probably a mistake.
+	severityWarning = "warning"
+)
+
+// calorieOutlierFactor is how far above or below the median of its category
+// an item's calories must lie to be reported as an outlier.
+const calorieOutlierFactor = 3
+
+// Diagnostic is one problem found in a menu.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	// Item is the 1-based position of the item in the menu; problems with
+	// the menu as a whole have none.
+	Item     int    `json:"item,omitempty"`
+	ItemName string `json:"item_name,omitempty"`
+	// Field names the item field at fault, if any.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the diagnostic as a single line naming the item.
+func (d Diagnostic) String() string {
+	switch {
+	case d.Item == 0:
+		return d.Message
+	case d.ItemName == "":
+		return fmt.Sprintf("item %d: %s", d.Item, d.Message)
+	default:
+		return fmt.Sprintf("item %d (%q): %s", d.Item, d.ItemName, d.Message)
+	}
+}
+
+// Report is the result of checking a menu.
+type Report struct {
+	// Valid is false when the menu has errors; warnings do not count.
+	Valid    bool         `json:"valid"`
+	Items    int          `json:"items"`
+	Errors   []Diagnostic `json:"errors"`
+	Warnings []Diagnostic `json:"warnings"`
+}
+
+// Diagnose checks a menu for errors, which make generation produce
+// nonsense or nothing at all, and for warnings about suspicious values.
+func Diagnose(items []Item) Report {
+	report := Report{Items: len(items), Errors: []Diagnostic{}, Warnings: []Diagnostic{}}
+	add := func(severity string, i int, field, format string, args ...any) {
+		d := Diagnostic{Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)}
+		if i >= 0 {
+			d.Item, d.ItemName = i+1, items[i].ItemName
+		}
+		if severity == severityError {
+			report.Errors = append(report.Errors, d)
+		} else {
+			report.Warnings = append(report.Warnings, d)
+		}
+	}
+	seen := make(map[string]bool, len(items))
+	keys := make(map[string]int, len(items))
+	counts := make(map[string]int)
+	calories := make(map[string][]int)
+	for i, item := range items {
+		key := NameKey(item.ItemName)
+		if first, ok := keys[key]; ok && key != "" {
+			if seen[item.ItemName] {
+				add(severityError, i, "item_name", "duplicate item_name")
+			} else {
+				add(severityWarning, i, "item_name", "item_name is a near-duplicate of item %d (%q), differing only in case, spacing or accents", first+1, items[first].ItemName)
+			}
+		} else {
+			keys[key] = i
+		}
+		seen[item.ItemName] = true
+		for _, category := range item.AllCategories() {
+			counts[category]++
+		}
+		if item.Calories > 0 {
+			calories[item.Category] = append(calories[item.Category], item.Calories)
+		}
+		checkItem(item, func(severity, field, format string, args ...any) {
+			add(severity, i, field, format, args...)
+		})
+	}
+
+	for _, category := range requiredCategories {
+		if counts[category] == 0 {
+			add(severityError, -1, "", "menu has no %s items", category)
+		}
+	}
+
+	medians := make(map[string]int, len(calories))
+	for category, values := range calories {
+		sort.Ints(values)
+		medians[category] = values[len(values)/2]
+	}
+	for i, item := range items {
+		median := medians[item.Category]
+		if item.Calories <= 0 || median == 0 {
+			continue
+		}
+		if item.Calories > median*calorieOutlierFactor || item.Calories*calorieOutlierFactor < median {
+			add(severityWarning, i, "calories", "calories %d is far from the %s median of %d", item.Calories, item.Category, median)
+		}
+	}
+	sort.SliceStable(report.Warnings, func(a, b int) bool { return report.Warnings[a].Item < report.Warnings[b].Item })
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// CheckItem returns the errors of a single item, one per field at fault:
+// those Diagnose reports without comparing the item to the rest of the menu.
+func CheckItem(item Item) []Diagnostic {
+	var problems []Diagnostic
+	checkItem(item, func(severity, field, format string, args ...any) {
+		if severity == severityError {
+			problems = append(problems, Diagnostic{Severity: severity, ItemName: item.ItemName, Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+	})
+	return problems
+}
+
+// checkItem reports the problems of item on its own through add.
+func checkItem(item Item, add func(severity, field, format string, args ...any)) {
+	if strings.TrimSpace(item.ItemName) == "" {
+		add(severityError, "item_name", "item_name is empty")
+	}
+	if item.Category == "" {
+		add(severityError, "category", "category is empty")
+	} else if !slices.Contains(knownCategories, item.Category) {
+		add(severityError, "category", "unknown category %q (expected one of %s)", item.Category, strings.Join(knownCategories, ", "))
+	}
+	for _, category := range item.Categories {
+		if !slices.Contains(knownCategories, category) {
+			add(severityError, "categories", "unknown category %q (expected one of %s)", category, strings.Join(knownCategories, ", "))
+		}
+	}
+	switch {
+	case item.Calories < 0:
+		add(severityError, "calories", "calories must not be negative")
+	case item.Calories == 0:
+		add(severityWarning, "calories", "calories is missing or zero")
+	}
+	if item.PopularityScore < 0 || item.PopularityScore > 1 {
+		add(severityError, "popularity_score", "popularity_score %g is outside [0, 1]", item.PopularityScore)
+	}
+	if strings.TrimSpace(item.TasteProfile) == "" {
+		add(severityWarning, "taste_profile", "taste_profile is empty")
+	}
+	for _, problem := range validatePortions(item) {
+		add(severityError, "portions", "%s", problem)
+	}
+	if field, err := CheckAvailability(item); err != nil {
+		add(severityError, field, "%v", err)
+	}
+	switch {
+	case item.Stock == nil:
+	case *item.Stock < 0:
+		add(severityError, "stock", "stock must not be negative")
+	case *item.Stock == 0:
+		add(severityWarning, "stock", "item is out of stock and will not be served")
+	}
+}
+
+// Validate returns one message per error in the menu; see Diagnose.
+func Validate(items []Item) []string {
+	var problems []string
+	for _, d := range Diagnose(items).Errors {
+		problems = append(problems, d.String())
+	}
+	return problems
+}
@@ -0,0 +1,101 @@
+package planner
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"slices"
+
+	"task/menu"
+)
+
+const (
+	// annealSteps is how many combo swaps the anneal strategy proposes.
+	annealSteps = 400
+	// annealStartTemperature and annealEndTemperature bound the temperature
+	// of the search, in objective units: early on a swap costing about
+	// annealStartTemperature is still accepted a third of the time, at the
+	// end hardly any worse swap is.
+	annealStartTemperature = 0.02
+	annealEndTemperature   = 0.0005
+)
+
+// discardLogger swallows the log output of proposed swaps.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// planObjective is what the anneal strategy maximizes: the average score of
+// the plan's combos, which weighs popularity, diversity and cost by
+// opts.ScoreWeights, plus the variety of items across the plan weighted by
+// the diversity weight, so the plan as a whole does not keep serving the
+// same best items.
+func planObjective(plan MenuPlan, weights ScoreWeights) float64 {
+	total, combos := 0.0, 0
+	for _, day := range plan.MenuPlan {
+		for _, combo := range day.Combos {
+			total += combo.Score
+			combos++
+		}
+	}
+	if combos == 0 {
+		return 0
+	}
+	variety := weights.Diversity / (weights.Popularity + weights.Diversity + weights.Cost)
+	return total/float64(combos) + variety*plan.Diversity.ItemVariety
+}
+
+// anneal improves plan by simulated annealing: it repeatedly swaps a random
+// combo for another valid one with SwapCombo, always keeping a better plan
+// and a worse one with a probability that shrinks as the search cools. The
+// best plan found replaces plan. It stops early, keeping the best plan so
+// far, when ctx is done.
+func anneal(ctx context.Context, plan *MenuPlan, opts GenerationOptions, masterMenu []menu.Item, index *ComboIndex, rng *rand.Rand) {
+	var slots [][2]int
+	for d, day := range plan.MenuPlan {
+		for c := range day.Combos {
+			slots = append(slots, [2]int{d, c})
+		}
+	}
+	if len(slots) == 0 {
+		return
+	}
+	logger := opts.log()
+	// Proposals are searched for quietly: a swap finding no alternative is
+	// part of the search, not an infeasible slot.
+	opts.Debug, opts.Span, opts.Logger = nil, nil, discardLogger
+	opts.proposing = true
+
+	current, best := *plan, *plan
+	currentScore := planObjective(current, opts.ScoreWeights)
+	bestScore := currentScore
+	accepted := 0
+	for step := 0; step < annealSteps && ctx.Err() == nil; step++ {
+		progress := float64(step) / annealSteps
+		temperature := annealStartTemperature * math.Pow(annealEndTemperature/annealStartTemperature, progress)
+
+		slot := slots[rng.Intn(len(slots))]
+		candidate := current
+		candidate.MenuPlan = slices.Clone(current.MenuPlan)
+		candidate.MenuPlan[slot[0]].Combos = slices.Clone(current.MenuPlan[slot[0]].Combos)
+		if err := SwapCombo(ctx, &candidate, opts, slot[0], slot[1], masterMenu, index, rng.Int63()); err != nil {
+			continue
+		}
+		score := planObjective(candidate, opts.ScoreWeights)
+		if delta := score - currentScore; delta < 0 && rng.Float64() >= math.Exp(delta/temperature) {
+			continue
+		}
+		current, currentScore = candidate, score
+		accepted++
+		if currentScore > bestScore {
+			best, bestScore = current, currentScore
+		}
+	}
+	for d := range best.MenuPlan {
+		if len(best.MenuPlan[d].Meals) == 0 {
+			rankCombos(best.MenuPlan[d].Combos)
+		}
+	}
+	logger.Debug("plan annealed", "steps", annealSteps, "accepted", accepted, "objective", math.Round(bestScore*1000)/1000)
+	*plan = best
+}
@@ -0,0 +1,187 @@
+package planner
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSearchChecks bounds the candidate checks of the backtrack
+	// strategy's search of one meal; a meal needing more is filled as far
+	// as the enumerate strategy would fill it.
+	maxSearchChecks = 1_000_000
+	// maxDayBacktracks bounds how often the backtrack strategy goes back a
+	// day in one plan. The search is best-effort: past the bound the plan
+	// is filled without going back, and the warnings of its short days say
+	// so, since a full plan may still exist.
+	maxDayBacktracks = 64
+)
+
+// Values of BacktrackStats.GaveUp.
+const (
+	backtrackExhausted = "exhausted"
+	backtrackLimit     = "limit"
+)
+
+// searchOrder returns candidates in a random order biased by
+// preferenceWeight, so the search of the backtrack strategy tries the
+// candidates the enumerate strategy would likely pick first.
+func searchOrder(rng *rand.Rand, candidates []comboCandidate, opts GenerationOptions) []comboCandidate {
+	keys := make([]float64, len(candidates))
+	for i, c := range candidates {
+		weight := 1.0
+		for _, item := range c.Items {
+			weight *= preferenceWeight(item, opts)
+		}
+		// An exponential variate divided by the weight: sorting by it
+		// draws a weighted sample without replacement.
+		keys[i] = math.Inf(1)
+		if weight > 0 {
+			keys[i] = rng.ExpFloat64() / weight
+		}
+	}
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] < keys[order[j]] })
+	ordered := make([]comboCandidate, len(candidates))
+	for i, o := range order {
+		ordered[i] = candidates[o]
+	}
+	return ordered
+}
+
+// slotSearch is a depth-first search for one candidate per slot of a meal,
+// undoing earlier choices when they leave a later slot without a candidate.
+type slotSearch struct {
+	candidates []comboCandidate
+	// allowed reports whether a candidate may fill slot given the choices
+	// so far; take and undo record and forget a choice.
+	allowed    func(slot int, c comboCandidate) bool
+	take, undo func(c comboCandidate)
+	// complete, when set, can turn down a full set of choices.
+	complete func(chosen []comboCandidate) bool
+	checks   int
+}
+
+// solve returns a candidate for each of slots slots, or nil when there is
+// none or the search ran out of checks. Every choice is undone again by the
+// time it returns. Slots are interchangeable, so the candidates are chosen
+// in search order to try each set only once.
+func (s *slotSearch) solve(slots int) []comboCandidate {
+	chosen := make([]comboCandidate, 0, slots)
+	var search func(from int) bool
+	search = func(from int) bool {
+		if len(chosen) == slots {
+			return s.complete == nil || s.complete(chosen)
+		}
+		for i := from; i < len(s.candidates); i++ {
+			if s.checks++; s.checks > maxSearchChecks {
+				return false
+			}
+			c := s.candidates[i]
+			if !s.allowed(len(chosen), c) {
+				continue
+			}
+			s.take(c)
+			chosen = append(chosen, c)
+			found := search(i + 1)
+			s.undo(c)
+			if found {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+		}
+		return false
+	}
+	if !search(0) {
+		return nil
+	}
+	return chosen
+}
+
+// signatures returns the comboSignatures of the day's combos.
+func (day DailyMenu) signatures() []string {
+	signatures := make([]string, len(day.Combos))
+	for i, combo := range day.Combos {
+		signatures[i] = combo.signature()
+	}
+	return signatures
+}
+
+// daySolutionKey identifies the combos served on a day by their signatures.
+func daySolutionKey(signatures []string) string {
+	sorted := append([]string(nil), signatures...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "|")
+}
+
+// generatorCheckpoint is what a planGenerator has used up to a day of the
+// plan, so the backtrack strategy can go back to it.
+type generatorCheckpoint struct {
+	comboSignatures map[string]int
+	itemUses        map[string]int
+	day1UsedItems   map[string]bool
+	comboCounter    int
+	warnings        int
+	debugSlots      int
+	// remainingBudget and remainingCalories are what is left of the plan's
+	// price and calorie budgets.
+	remainingBudget   float64
+	remainingCalories int
+}
+
+// checkpoint records the state of g before the next day is generated.
+func (g *planGenerator) checkpoint(remainingBudget float64, remainingCalories int) generatorCheckpoint {
+	cp := generatorCheckpoint{
+		comboSignatures:   maps.Clone(g.comboSignatures),
+		itemUses:          maps.Clone(g.itemUses),
+		day1UsedItems:     maps.Clone(g.day1UsedItems),
+		comboCounter:      g.comboCounter,
+		warnings:          len(g.warnings),
+		remainingBudget:   remainingBudget,
+		remainingCalories: remainingCalories,
+	}
+	if g.opts.Debug != nil {
+		cp.debugSlots = len(g.opts.Debug.Slots)
+	}
+	return cp
+}
+
+// restore returns g to the state of cp.
+func (g *planGenerator) restore(cp generatorCheckpoint) {
+	g.comboSignatures = maps.Clone(cp.comboSignatures)
+	g.itemUses = maps.Clone(cp.itemUses)
+	g.day1UsedItems = maps.Clone(cp.day1UsedItems)
+	g.comboCounter = cp.comboCounter
+	g.warnings = g.warnings[:cp.warnings]
+	if g.opts.Debug != nil {
+		g.opts.Debug.Slots = g.opts.Debug.Slots[:cp.debugSlots]
+	}
+}
+
+// shortByEarlierDays reports whether warnings, those of a single day, say
+// the day is short for reasons an earlier day can change, as opposed to
+// its menu having too few items or no valid combos at all.
+func shortByEarlierDays(warnings []PlanWarning) bool {
+	for _, w := range warnings {
+		if w.Reason != shortMissingItems && w.Reason != shortNoCandidates {
+			return true
+		}
+	}
+	return false
+}
+
+// noteBacktrackLimit adds to warnings, those of a plan filled without going
+// back once the backtrack strategy went back maxDayBacktracks times, that a
+// full plan may still exist.
+func noteBacktrackLimit(warnings []PlanWarning) {
+	for i := range warnings {
+		warnings[i].Message += fmt.Sprintf("; the backtrack search gave up after %d backtracks, so a full plan may still exist", maxDayBacktracks)
+	}
+}
@@ -0,0 +1,89 @@
+package planner
+
+import (
+	"runtime"
+	"sync"
+)
+
+// candidateKey identifies a set of candidate combos within a plan: only the
+// calorie window and template differ between its days and meals.
+type candidateKey struct {
+	minCalories, maxCalories int
+	template                 string
+}
+
+func candidateKeyOf(opts GenerationOptions) candidateKey {
+	return candidateKey{opts.MinCalories, opts.MaxCalories, opts.Template.orDefault().String()}
+}
+
+// candidatesFor returns the valid combos for opts, which must be the plan's
+// options with a day's or meal's calorie window and template. Each set is
+// computed once per plan.
+func (g *planGenerator) candidatesFor(opts GenerationOptions) []comboCandidate {
+	key := candidateKeyOf(opts)
+	if candidates, ok := g.candidateCache[key]; ok {
+		return candidates
+	}
+	candidates := templateCandidates(g.index, g.categorizedMenu, opts)
+	g.candidateCache[key] = candidates
+	return candidates
+}
+
+// prefetchCandidates computes the candidate sets the first days of the plan
+// need concurrently, a worker per CPU. Days and meals with their own calorie
+// window or template each need a set of their own, and computing them is
+// independent work; choosing combos is not, since every day depends on the
+// days before it and on the plan's random sequence, so days are still
+// generated in turn.
+func (g *planGenerator) prefetchCandidates(days int) {
+	if g.index == nil {
+		return
+	}
+	if len(g.opts.CalorieSchedule) > 0 {
+		days = min(days, len(g.opts.CalorieSchedule))
+	} else {
+		days = min(days, 1)
+	}
+	var pending []GenerationOptions
+	queued := make(map[candidateKey]bool)
+	queue := func(opts GenerationOptions) {
+		key := candidateKeyOf(opts)
+		if _, ok := g.candidateCache[key]; !ok && !queued[key] {
+			queued[key] = true
+			pending = append(pending, opts)
+		}
+	}
+	for dayIndex := 0; dayIndex < days; dayIndex++ {
+		dayOpts := g.opts.forDay(dayIndex)
+		queue(dayOpts)
+		for _, meal := range g.opts.MealSlots {
+			if meal.MaxCalories > 0 || len(meal.Template) > 0 {
+				queue(dayOpts.forMeal(meal))
+			}
+		}
+	}
+	if len(pending) < 2 {
+		return
+	}
+
+	results := make([][]comboCandidate, len(pending))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(pending)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = templateCandidates(g.index, g.categorizedMenu, pending[i])
+			}
+		}()
+	}
+	for i := range pending {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for i, opts := range pending {
+		g.candidateCache[candidateKeyOf(opts)] = results[i]
+	}
+}
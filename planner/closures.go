@@ -0,0 +1,72 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	"task/menu"
+)
+
+// Closure marks a date the cafeteria is closed or serves fewer combos than
+// usual, such as a holiday.
+type Closure struct {
+	// Date is a date (YYYY-MM-DD), or a month and day (MM-DD) recurring
+	// every year such as 12-25.
+	Date string `json:"date" yaml:"date"`
+	// Combos caps the combos of each meal on the date, or of the whole day
+	// without meal slots; 0 closes the cafeteria for the day.
+	Combos int `json:"combos,omitempty" yaml:"combos"`
+	// Reason explains the change, such as "Christmas", to diners.
+	Reason string `json:"reason,omitempty" yaml:"reason"`
+}
+
+// validateClosures checks that every closure names a valid date once.
+func validateClosures(closures []Closure) error {
+	seen := make(map[string]bool, len(closures))
+	for i, c := range closures {
+		if _, err := menu.ParseDate(c.Date); err != nil {
+			return fmt.Errorf("closures[%d]: %w", i, err)
+		}
+		if seen[c.Date] {
+			return fmt.Errorf("closures[%d]: date %s is listed twice", i, c.Date)
+		}
+		seen[c.Date] = true
+		if c.Combos < 0 {
+			return fmt.Errorf("closures[%d]: combos must not be negative, got %d", i, c.Combos)
+		}
+	}
+	return nil
+}
+
+// closureOn returns the closure falling on date, or nil when the day has
+// full service. A closure of that exact date wins over a yearly one.
+func (opts GenerationOptions) closureOn(date time.Time) *Closure {
+	var yearly *Closure
+	for i, c := range opts.Closures {
+		d, err := menu.ParseDate(c.Date)
+		if err != nil || d.Compare(date) != 0 {
+			continue
+		}
+		if !d.Yearly() {
+			return &opts.Closures[i]
+		}
+		if yearly == nil {
+			yearly = &opts.Closures[i]
+		}
+	}
+	return yearly
+}
+
+// meals returns the meals of a day under the closure, each capped at
+// c.Combos combos.
+func (c *Closure) meals(meals []MealSlot) []MealSlot {
+	if c == nil {
+		return meals
+	}
+	capped := make([]MealSlot, len(meals))
+	for i, meal := range meals {
+		meal.Combos = min(meal.Combos, c.Combos)
+		capped[i] = meal
+	}
+	return capped
+}
@@ -0,0 +1,139 @@
+package planner
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"task/menu"
+)
+
+// indexedCombo is one main/side/drink triple in a ComboIndex. Items are
+// referenced by their position in the index's category slices.
+type indexedCombo struct {
+	main, side, drink int32
+	calories          int
+	// spread is the difference between the highest and lowest popularity score.
+	spread float64
+}
+
+// ComboIndex holds every main/side/drink triple of a menu, other than those
+// serving an item of several categories twice, sorted by total
+// calories, so the combos valid for a request's calorie window and popularity
+// tolerance can be found without re-validating every triple. It is immutable
+// once built and safe for concurrent use.
+type ComboIndex struct {
+	mains, sides, drinks []menu.Item
+	combos               []indexedCombo
+	// portions is set when any item declares portion sizes, which can move
+	// a combo's calories away from the indexed total.
+	portions bool
+}
+
+// NewComboIndex builds the index for a menu.
+func NewComboIndex(items []menu.Item) *ComboIndex {
+	categorized := menu.Categorize(items)
+	idx := &ComboIndex{
+		mains:  categorized["main"],
+		sides:  categorized["side"],
+		drinks: categorized["drink"],
+	}
+	idx.portions = hasPortions(items)
+	idx.combos = make([]indexedCombo, 0, len(idx.mains)*len(idx.sides)*len(idx.drinks))
+	for m, mainItem := range idx.mains {
+		for s, sideItem := range idx.sides {
+			if sideItem.ItemName == mainItem.ItemName {
+				continue
+			}
+			for d, drinkItem := range idx.drinks {
+				if drinkItem.ItemName == mainItem.ItemName || drinkItem.ItemName == sideItem.ItemName {
+					continue
+				}
+				calories := mainItem.Calories + sideItem.Calories + drinkItem.Calories
+				low := min(mainItem.PopularityScore, sideItem.PopularityScore, drinkItem.PopularityScore)
+				high := max(mainItem.PopularityScore, sideItem.PopularityScore, drinkItem.PopularityScore)
+				idx.combos = append(idx.combos, indexedCombo{
+					main:     int32(m),
+					side:     int32(s),
+					drink:    int32(d),
+					calories: calories,
+					spread:   high - low,
+				})
+			}
+		}
+	}
+	// A stable sort keeps menu order within equal calories, so results are deterministic.
+	sort.SliceStable(idx.combos, func(i, j int) bool {
+		return idx.combos[i].calories < idx.combos[j].calories
+	})
+	return idx
+}
+
+// parallelComboThreshold is the number of indexed combos above which
+// validCombos splits checking them over several workers.
+const parallelComboThreshold = 20000
+
+// validCombos returns the combos within the calorie window, popularity
+// tolerance and per-combo macro and price limits of opts, ordered by the
+// calories of their regular portions. Combos whose regular portions miss the
+// limits are included with resized portions when that makes them fit.
+// Large indexes are checked by a worker per CPU, each taking a contiguous
+// share of the combos, so the order is the same as when checked in turn.
+func (idx *ComboIndex) validCombos(opts GenerationOptions) []comboCandidate {
+	combos := idx.combos
+	if !idx.portions {
+		start := sort.Search(len(idx.combos), func(i int) bool {
+			return idx.combos[i].calories >= opts.MinCalories
+		})
+		end := sort.Search(len(idx.combos), func(i int) bool {
+			return idx.combos[i].calories > opts.MaxCalories
+		})
+		combos = idx.combos[start:max(start, end)]
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if len(combos) < parallelComboThreshold || workers < 2 {
+		return idx.checkCombos(combos, opts)
+	}
+	share := (len(combos) + workers - 1) / workers
+	results := make([][]comboCandidate, workers)
+	var wg sync.WaitGroup
+	for w := range results {
+		lo, hi := min(w*share, len(combos)), min((w+1)*share, len(combos))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[w] = idx.checkCombos(combos[lo:hi], opts)
+		}()
+	}
+	wg.Wait()
+	var candidates []comboCandidate
+	for _, result := range results {
+		candidates = append(candidates, result...)
+	}
+	return candidates
+}
+
+// checkCombos returns the candidates among combos that pass the popularity
+// tolerance and per-combo limits of opts; see validCombos.
+func (idx *ComboIndex) checkCombos(combos []indexedCombo, opts GenerationOptions) []comboCandidate {
+	var candidates []comboCandidate
+	for _, c := range combos {
+		if c.spread > opts.PopularityTolerance {
+			continue
+		}
+		items := []menu.Item{idx.mains[c.main], idx.sides[c.side], idx.drinks[c.drink]}
+		if idx.portions {
+			var ok bool
+			if items, ok = fitPortions(items, opts); !ok {
+				continue
+			}
+		} else if !comboWithinLimits(items, opts) {
+			continue
+		}
+		candidates = append(candidates, comboCandidate{
+			Items:     items,
+			Signature: comboSignature(items...),
+		})
+	}
+	return candidates
+}
@@ -0,0 +1,135 @@
+package planner
+
+// rejectReason is why a candidate combo is turned down while filling a slot.
+type rejectReason int
+
+const (
+	notRejected rejectReason = iota
+	rejectCalories
+	rejectPopularity
+	rejectComboLimits
+	rejectDuplicateItems
+	rejectUniqueness
+	rejectRepetition
+	rejectDayLimits
+	rejectItemUses
+	rejectOutOfStock
+	rejectExcluded
+	rejectRule
+	numRejectReasons
+)
+
+// rejectReasonNames are the names reasons are reported under.
+var rejectReasonNames = [numRejectReasons]string{
+	rejectCalories:       "calories",
+	rejectPopularity:     "popularity_spread",
+	rejectComboLimits:    "combo_limits",
+	rejectDuplicateItems: "duplicate_items",
+	rejectUniqueness:     "uniqueness",
+	rejectRepetition:     "repetition",
+	rejectDayLimits:      "day_limits",
+	rejectItemUses:       "item_uses",
+	rejectOutOfStock:     "out_of_stock",
+	rejectExcluded:       "excluded",
+	rejectRule:           "custom_rule",
+}
+
+// rejectReasonDescriptions complete "rejected for ..." in warnings.
+var rejectReasonDescriptions = [numRejectReasons]string{
+	rejectCalories:       "falling outside the calorie window",
+	rejectPopularity:     "exceeding the popularity tolerance",
+	rejectComboLimits:    "exceeding the per-combo macro or price limits",
+	rejectDuplicateItems: "repeating an item",
+	rejectUniqueness:     "reusing an item already served that day",
+	rejectRepetition:     "repeating a combo within the repeat window",
+	rejectDayLimits:      "exceeding the day's macro, calorie or price budget",
+	rejectItemUses:       "exceeding an item use limit",
+	rejectOutOfStock:     "using an item that is out of stock",
+	rejectExcluded:       "being excluded",
+	rejectRule:           "breaking a custom constraint rule",
+}
+
+func (r rejectReason) String() string {
+	return rejectReasonNames[r]
+}
+
+// rejectionTally counts the combos rejected while filling a slot, by reason.
+type rejectionTally [numRejectReasons]int
+
+// counts returns the non-zero counts by reason name.
+func (t rejectionTally) counts() map[string]int {
+	counts := map[string]int{}
+	for r, n := range t {
+		if n > 0 {
+			counts[rejectReason(r).String()] = n
+		}
+	}
+	return counts
+}
+
+// main returns the most common reason, or notRejected when nothing was rejected.
+func (t rejectionTally) main() rejectReason {
+	main := notRejected
+	for r := notRejected + 1; r < numRejectReasons; r++ {
+		if t[r] > t[main] {
+			main = r
+		}
+	}
+	return main
+}
+
+// GenerationDebug holds the search statistics of a plan generated with
+// debug=true, so constraints can be tuned against what actually rejected
+// the candidates.
+type GenerationDebug struct {
+	Slots []SlotStats `json:"slots"`
+	// Backtrack describes the search of the backtrack strategy; nil for
+	// the other strategies.
+	Backtrack *BacktrackStats `json:"backtrack,omitempty"`
+}
+
+// BacktrackStats describes how far the backtrack strategy went back.
+type BacktrackStats struct {
+	// Backtracks counts the times the search went back a day.
+	Backtracks int `json:"backtracks"`
+	// GaveUp is set when the search found no full plan and the plan was
+	// filled again without going back: "exhausted" when every choice was
+	// tried, "limit" when it went back as often as it may first.
+	GaveUp string `json:"gave_up,omitempty"`
+}
+
+// SlotStats describes the search for the combo of one slot of a day.
+type SlotStats struct {
+	// Day is the 1-based day index.
+	Day     int    `json:"day"`
+	DayName string `json:"day_name"`
+	Meal    string `json:"meal,omitempty"`
+	// Slot is the 1-based position of the combo within its meal.
+	Slot   int  `json:"slot"`
+	Filled bool `json:"filled"`
+	// Candidates is the number of precomputed valid combos the enumerate
+	// strategy chose from; the sample strategy has none.
+	Candidates int `json:"candidates,omitempty"`
+	// Rejected counts the combos turned down, by reason: calories,
+	// popularity_spread, combo_limits (per-combo macros and price),
+	// duplicate_items (a sampled combo repeating an item), uniqueness (an item
+	// already served that day, or on day 1), repetition (the repeat window),
+	// day_limits (day macros and calorie and price budgets), item_uses and
+	// excluded.
+	Rejected map[string]int `json:"rejected"`
+}
+
+// addSlot records the search for a slot; the caller fills in its day name
+// and meal. It does nothing when debug statistics are not collected.
+func (d *GenerationDebug) addSlot(dayIndex, slot, candidates int, filled bool, tally rejectionTally) {
+	if d == nil {
+		return
+	}
+	d.Slots = append(d.Slots, SlotStats{
+		Day:        dayIndex + 1,
+		Slot:       slot + 1,
+		Filled:     filled,
+		Candidates: candidates,
+		Rejected:   tally.counts(),
+	})
+}
@@ -0,0 +1,83 @@
+package planner
+
+import (
+	"math"
+
+	"task/menu"
+)
+
+// DiversityStats measures how varied a set of combos is.
+type DiversityStats struct {
+	// DistinctItems is the number of different items served.
+	DistinctItems int `json:"distinct_items"`
+	// TotalSlots is the number of item slots filled, one per combo component.
+	TotalSlots int `json:"total_slots"`
+	// ItemVariety is DistinctItems / TotalSlots; 1 means no item repeats.
+	ItemVariety float64 `json:"item_variety"`
+	// TasteEntropy is the Shannon entropy, in bits, of the taste profiles served.
+	TasteEntropy float64 `json:"taste_entropy"`
+}
+
+// comboDiversity computes the diversity of combos, looking up taste profiles in catalog.
+func comboDiversity(combos []Combo, catalog map[string]menu.Item) DiversityStats {
+	counter := newDiversityCounter(catalog)
+	counter.add(combos)
+	return counter.stats()
+}
+
+// diversityCounter tallies the items and taste profiles served, so the
+// diversity of combos can be added up without keeping them.
+type diversityCounter struct {
+	catalog  map[string]menu.Item
+	items    map[string]bool
+	profiles map[string]int
+	slots    int
+}
+
+// newDiversityCounter returns a counter looking up taste profiles in catalog.
+func newDiversityCounter(catalog map[string]menu.Item) *diversityCounter {
+	return &diversityCounter{catalog: catalog, items: make(map[string]bool), profiles: make(map[string]int)}
+}
+
+// add counts the components of combos.
+func (c *diversityCounter) add(combos []Combo) {
+	for _, combo := range combos {
+		for _, component := range combo.Components {
+			c.items[component.ItemName] = true
+			c.profiles[c.catalog[component.ItemName].TasteProfile]++
+			c.slots++
+		}
+	}
+}
+
+// stats returns the diversity of the combos counted so far.
+func (c *diversityCounter) stats() DiversityStats {
+	if c.slots == 0 {
+		return DiversityStats{}
+	}
+	entropy := 0.0
+	for _, count := range c.profiles {
+		p := float64(count) / float64(c.slots)
+		entropy -= p * math.Log2(p)
+	}
+	return DiversityStats{
+		DistinctItems: len(c.items),
+		TotalSlots:    c.slots,
+		ItemVariety:   math.Round(float64(len(c.items))/float64(c.slots)*1000) / 1000,
+		TasteEntropy:  math.Round(entropy*1000) / 1000,
+	}
+}
+
+// addPlanDiversity fills in the diversity of every day and of the plan as a whole.
+func addPlanDiversity(plan *MenuPlan, items []menu.Item) {
+	catalog := make(map[string]menu.Item, len(items))
+	for _, item := range items {
+		catalog[item.ItemName] = item
+	}
+	var all []Combo
+	for d := range plan.MenuPlan {
+		plan.MenuPlan[d].Diversity = comboDiversity(plan.MenuPlan[d].Combos, catalog)
+		all = append(all, plan.MenuPlan[d].Combos...)
+	}
+	plan.Diversity = comboDiversity(all, catalog)
+}
@@ -0,0 +1,108 @@
+package planner
+
+import (
+	"math"
+	"slices"
+
+	"task/menu"
+)
+
+// ReportedEnergy returns plan with its calories converted from kcal to the
+// unit its options ask for and EnergyUnit set: the combos, meals, days and
+// totals, the nutrition summary and the echoed calorie options. Plans are
+// generated and stored in kcal and converted only when they are written.
+func ReportedEnergy(plan MenuPlan) MenuPlan {
+	if plan.Options == nil || plan.EnergyUnit != "" || menu.EnergyFactor(plan.Options.Units) == 1 {
+		return plan
+	}
+	unit := plan.Options.Units
+	plan.EnergyUnit = unit
+	plan.CalorieWindow = plan.CalorieWindow.inUnit(unit)
+	plan.TotalCalories = menu.FromKcal(plan.TotalCalories, unit)
+	days := make([]DailyMenu, len(plan.MenuPlan))
+	for i, day := range plan.MenuPlan {
+		days[i] = ReportedDayEnergy(day, unit)
+	}
+	plan.MenuPlan = days
+	plan.Nutrition = plan.Nutrition.inUnit(unit)
+
+	opts := *plan.Options
+	opts.MinCalories = menu.FromKcal(opts.MinCalories, unit)
+	opts.MaxCalories = menu.FromKcal(opts.MaxCalories, unit)
+	opts.MaxTotalCalories = menu.FromKcal(opts.MaxTotalCalories, unit)
+	opts.CalorieSchedule = slices.Clone(opts.CalorieSchedule)
+	for i, window := range opts.CalorieSchedule {
+		opts.CalorieSchedule[i] = window.inUnit(unit)
+	}
+	opts.MealSlots = slices.Clone(opts.MealSlots)
+	for i, slot := range opts.MealSlots {
+		opts.MealSlots[i].MinCalories = menu.FromKcal(slot.MinCalories, unit)
+		opts.MealSlots[i].MaxCalories = menu.FromKcal(slot.MaxCalories, unit)
+	}
+	plan.Options = &opts
+	return plan
+}
+
+// ReportedDayEnergy returns day with its calories converted from kcal to unit.
+func ReportedDayEnergy(day DailyMenu, unit string) DailyMenu {
+	if menu.EnergyFactor(unit) == 1 {
+		return day
+	}
+	day.TotalCalories = menu.FromKcal(day.TotalCalories, unit)
+	day.Combos = slices.Clone(day.Combos)
+	for i, combo := range day.Combos {
+		day.Combos[i].CalorieCount = menu.FromKcal(combo.CalorieCount, unit)
+		if check := combo.NutritionCheck; check != nil {
+			converted := *check
+			converted.VerifiedCalories = menu.FromKcal(check.VerifiedCalories, unit)
+			day.Combos[i].NutritionCheck = &converted
+		}
+	}
+	day.Meals = slices.Clone(day.Meals)
+	for i, meal := range day.Meals {
+		day.Meals[i].MinCalories = menu.FromKcal(meal.MinCalories, unit)
+		day.Meals[i].MaxCalories = menu.FromKcal(meal.MaxCalories, unit)
+	}
+	return day
+}
+
+// ScheduleToKcal converts calorie windows given in unit to kcal in place.
+func ScheduleToKcal(windows []CalorieWindow, unit string) {
+	for i, window := range windows {
+		windows[i] = CalorieWindow{MinCalories: menu.ToKcal(window.MinCalories, unit), MaxCalories: menu.ToKcal(window.MaxCalories, unit)}
+	}
+}
+
+// MealSlotsToKcal converts the calorie bounds of slots given in unit to kcal
+// in place.
+func MealSlotsToKcal(slots []MealSlot, unit string) {
+	for i, slot := range slots {
+		slots[i].MinCalories = menu.ToKcal(slot.MinCalories, unit)
+		slots[i].MaxCalories = menu.ToKcal(slot.MaxCalories, unit)
+	}
+}
+
+// inUnit returns the window converted from kcal to unit.
+func (w CalorieWindow) inUnit(unit string) CalorieWindow {
+	return CalorieWindow{MinCalories: menu.FromKcal(w.MinCalories, unit), MaxCalories: menu.FromKcal(w.MaxCalories, unit)}
+}
+
+// inUnit returns the summary with its calories converted from kcal to unit.
+func (s NutritionSummary) inUnit(unit string) NutritionSummary {
+	factor := menu.EnergyFactor(unit)
+	convert := func(t NutritionTotals) NutritionTotals {
+		t.Calories = math.Round(t.Calories*factor*10) / 10
+		return t
+	}
+	s.Days = slices.Clone(s.Days)
+	for i, day := range s.Days {
+		s.Days[i].Calories = math.Round(day.Calories*factor*10) / 10
+	}
+	s.Total = convert(s.Total)
+	s.DailyAverage = convert(s.DailyAverage)
+	s.WeeklyAverages = slices.Clone(s.WeeklyAverages)
+	for i, week := range s.WeeklyAverages {
+		s.WeeklyAverages[i] = convert(week)
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package planner
+
+import (
+	"math/rand"
+
+	"task/menu"
+)
+
+// comboCandidate is the items of a combo, in template order, together with its signature.
+type comboCandidate struct {
+	Items     []menu.Item
+	Signature string
+}
+
+// pickCandidate selects a random candidate among those accepted by allowed.
+// Each candidate is weighted by the product of its items' preference weights,
+// matching the odds of sampling the items independently. It reports false
+// when no candidate is allowed.
+func pickCandidate(rng *rand.Rand, candidates []comboCandidate, opts GenerationOptions, allowed func(comboCandidate) bool) (comboCandidate, bool) {
+	var eligible []comboCandidate
+	var weights []float64
+	totalWeight := 0.0
+	for _, c := range candidates {
+		if !allowed(c) {
+			continue
+		}
+		weight := 1.0
+		for _, item := range c.Items {
+			weight *= preferenceWeight(item, opts)
+		}
+		eligible = append(eligible, c)
+		weights = append(weights, weight)
+		totalWeight += weight
+	}
+	if len(eligible) == 0 {
+		return comboCandidate{}, false
+	}
+	if totalWeight <= 0 {
+		return eligible[rng.Intn(len(eligible))], true
+	}
+
+	target := rng.Float64() * totalWeight
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return eligible[i], true
+		}
+	}
+	return eligible[len(eligible)-1], true
+}
+
+// bestCandidate returns the allowed candidate with the highest objective
+// value, preferring the earliest one among equals so the result is
+// deterministic. It reports false when no candidate is allowed.
+func bestCandidate(candidates []comboCandidate, objective func(comboCandidate) float64, allowed func(comboCandidate) bool) (comboCandidate, bool) {
+	var best comboCandidate
+	bestValue, found := 0.0, false
+	for _, c := range candidates {
+		if !allowed(c) {
+			continue
+		}
+		if value := objective(c); !found || value > bestValue {
+			best, bestValue, found = c, value, true
+		}
+	}
+	return best, found
+}
+
+// optimizationObjective returns the value an optimize mode maximizes.
+func optimizationObjective(mode string) func(comboCandidate) float64 {
+	switch mode {
+	case OptimizePopularity:
+		return func(c comboCandidate) float64 {
+			_, avgPopularity := menu.ComboMetrics(c.Items...)
+			return avgPopularity
+		}
+	case OptimizeCost:
+		return func(c comboCandidate) float64 { return -menu.Price(c.Items...) }
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"task/menu"
+)
+
+// FeasibilityReport tells whether a plan can be generated in full from a
+// menu before generating it. The checks are necessary conditions: a plan
+// that fails one is certain to have short days, while one that passes them
+// all can still come out short when item use limits or day macro targets
+// bite.
+type FeasibilityReport struct {
+	Feasible bool             `json:"feasible"`
+	Days     []DayFeasibility `json:"days"`
+	// Problems explains each reason days would have fewer combos than asked for.
+	Problems []string `json:"problems"`
+}
+
+// DayFeasibility is the feasibility of one day of the plan.
+type DayFeasibility struct {
+	Day      string            `json:"day"`
+	Feasible bool              `json:"feasible"`
+	Meals    []MealFeasibility `json:"meals"`
+}
+
+// MealFeasibility counts the combos available to one meal of a day. Without
+// meal slots the day is a single unnamed meal.
+type MealFeasibility struct {
+	Meal        string `json:"meal,omitempty"`
+	Template    string `json:"template"`
+	MinCalories int    `json:"min_calories"`
+	MaxCalories int    `json:"max_calories"`
+	// CombosNeeded is the number of combos the meal is to be served.
+	CombosNeeded int `json:"combos_needed"`
+	// ValidCombos counts the combos that pass the calorie window, popularity
+	// tolerance, per-combo limits and the day's item filters.
+	ValidCombos int `json:"valid_combos"`
+	// MaxCombos is the most combos the meal can get without serving an item
+	// twice in a day.
+	MaxCombos int `json:"max_combos"`
+
+	// signatures are the valid combos, for the repeat window check, and
+	// cheapest holds the lowest calories and price CombosNeeded of them add up to.
+	signatures      []string
+	cheapestCalorie int
+	cheapestPrice   float64
+}
+
+// AnalyzeFeasibility checks whether every day of the plan opts describes can
+// get all its combos from masterMenu. index may be a prebuilt ComboIndex of
+// masterMenu; when nil it is built on demand.
+func AnalyzeFeasibility(ctx context.Context, masterMenu []menu.Item, opts GenerationOptions, index *ComboIndex) FeasibilityReport {
+	// Both strategies draw from the same valid combos; enumerating them
+	// makes the counts exact.
+	opts.Strategy = StrategyEnumerate
+	g := newPlanGenerator(ctx, masterMenu, opts, index, nil)
+	g.prefetchCandidates(opts.Days)
+	report := FeasibilityReport{Feasible: true, Days: []DayFeasibility{}, Problems: []string{}}
+	problem := func(format string, args ...any) {
+		report.Feasible = false
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+
+	meals := opts.meals()
+	// mealSignatures collects the valid combos of each meal over all days.
+	mealSignatures := make([]map[string]bool, len(meals))
+	for i := range mealSignatures {
+		mealSignatures[i] = make(map[string]bool)
+	}
+	leastCalories, leastPrice := 0, 0.0
+	for dayIndex := 0; dayIndex < opts.Days; dayIndex++ {
+		day := g.day(dayIndex, 0, 0)
+		dayReport := DayFeasibility{Day: day.name, Feasible: true}
+		for i, slot := range day.meals {
+			meal := analyzeMeal(g, day, slot)
+			label := day.name
+			if slot.Name != "" {
+				label = fmt.Sprintf("%s %s", day.name, slot.Name)
+			}
+			switch {
+			case meal.CombosNeeded == 0:
+				// The day falls on a closure.
+			case meal.ValidCombos == 0:
+				problem("no valid combos exist for %s; need %d", label, meal.CombosNeeded)
+				dayReport.Feasible = false
+			case meal.ValidCombos < meal.CombosNeeded:
+				problem("only %d valid %s exist for %s; need %d", meal.ValidCombos, plural(meal.ValidCombos, "combo"), label, meal.CombosNeeded)
+				dayReport.Feasible = false
+			case meal.MaxCombos < meal.CombosNeeded:
+				problem("%d valid combos exist for %s, but without repeating an item they fill only %d of %d", meal.ValidCombos, label, meal.MaxCombos, meal.CombosNeeded)
+				dayReport.Feasible = false
+			}
+			for _, signature := range meal.signatures {
+				mealSignatures[i][signature] = true
+			}
+			leastCalories += meal.cheapestCalorie
+			leastPrice += meal.cheapestPrice
+			dayReport.Meals = append(dayReport.Meals, meal)
+		}
+		report.Days = append(report.Days, dayReport)
+	}
+	if !report.Feasible {
+		// The plan-wide checks below assume every day can be filled.
+		return report
+	}
+
+	// No combo can come back within the repeat window, so every window of
+	// consecutive days needs that many different combos.
+	if window := min(opts.RepeatWindow, opts.Days); window > 1 {
+		for i, slot := range meals {
+			needed := slot.Combos * window
+			if available := len(mealSignatures[i]); available < needed {
+				label := "each day"
+				if slot.Name != "" {
+					label = slot.Name
+				}
+				problem("only %d different valid combos exist for %s; a repeat window of %d days needs %d", available, label, opts.RepeatWindow, needed)
+			}
+		}
+	}
+	if opts.MaxTotalCalories > 0 && leastCalories > opts.MaxTotalCalories {
+		problem("the lowest-calorie combos add up to %d calories; max_total_calories is %d", leastCalories, opts.MaxTotalCalories)
+	}
+	if opts.MaxTotalPrice > 0 && leastPrice > opts.MaxTotalPrice {
+		problem("the cheapest combos cost %.2f in total; max_total_price is %.2f", leastPrice, opts.MaxTotalPrice)
+	}
+	return report
+}
+
+// analyzeMeal counts the combos available to a meal of a day.
+func analyzeMeal(g *planGenerator, day dayState, slot MealSlot) MealFeasibility {
+	mealMenu, candidates, mealOpts := g.meal(day, slot)
+	if mealOpts.Template.hasOptional() {
+		// Only the required roles must be filled for the meal to be served.
+		slot.Template = mealOpts.Template.required()
+		mealMenu, candidates, mealOpts = g.meal(day, slot)
+	}
+	template := mealOpts.Template.orDefault()
+	meal := MealFeasibility{
+		Meal:         slot.Name,
+		Template:     template.String(),
+		MinCalories:  mealOpts.MinCalories,
+		MaxCalories:  mealOpts.MaxCalories,
+		CombosNeeded: slot.Combos,
+	}
+	if !hasTemplateItems(mealMenu, template) {
+		return meal
+	}
+	meal.ValidCombos = len(candidates)
+
+	// Items cannot repeat within a day, so each category caps the combos at
+	// the number of its items that appear in valid combos, divided by how
+	// often the template uses it.
+	perCombo := make(map[string]int)
+	for _, category := range template {
+		perCombo[category]++
+	}
+	usable := make(map[string]map[string]bool)
+	calories := make([]int, len(candidates))
+	prices := make([]float64, len(candidates))
+	for i, c := range candidates {
+		for pos, item := range c.Items {
+			if usable[template[pos]] == nil {
+				usable[template[pos]] = make(map[string]bool)
+			}
+			usable[template[pos]][item.ItemName] = true
+		}
+		calories[i], _ = menu.ComboMetrics(c.Items...)
+		prices[i] = menu.Price(c.Items...)
+		meal.signatures = append(meal.signatures, c.Signature)
+	}
+	meal.MaxCombos = len(candidates)
+	for category, count := range perCombo {
+		meal.MaxCombos = min(meal.MaxCombos, len(usable[category])/count)
+	}
+
+	sort.Ints(calories)
+	sort.Float64s(prices)
+	for i := 0; i < slot.Combos && i < len(candidates); i++ {
+		meal.cheapestCalorie += calories[i]
+		meal.cheapestPrice += prices[i]
+	}
+	return meal
+}
+
+// plural returns noun with an "s" unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
@@ -0,0 +1,95 @@
+package planner
+
+import (
+	"slices"
+	"strings"
+
+	"task/menu"
+)
+
+// ExcludedItem reports a menu item that was left out of a plan and why.
+type ExcludedItem struct {
+	ItemName  string   `json:"item_name"`
+	Allergens []string `json:"allergens"`
+}
+
+// excludedItems lists the items that contain any of the excluded allergens.
+func excludedItems(items []menu.Item, excluded []string) []ExcludedItem {
+	var result []ExcludedItem
+	for _, item := range items {
+		if matches := menu.MatchingAllergens(item, excluded); len(matches) > 0 {
+			result = append(result, ExcludedItem{ItemName: item.ItemName, Allergens: matches})
+		}
+	}
+	return result
+}
+
+// itemFilter returns the predicate items must satisfy to be served on the
+// named day, or nil when every item is allowed.
+func (opts GenerationOptions) itemFilter(dayName string) func(menu.Item) bool {
+	tags := append(append([]string(nil), opts.DietaryTags...), opts.dayDietaryTags(dayName)...)
+	allergens := opts.ExcludeAllergens
+	if len(tags) == 0 && len(allergens) == 0 && len(opts.ExcludeItems) == 0 {
+		return nil
+	}
+	return func(item menu.Item) bool {
+		return menu.HasAllTags(item, tags) && len(menu.MatchingAllergens(item, allergens)) == 0 &&
+			!menu.ContainsFold(opts.ExcludeItems, item.ItemName)
+	}
+}
+
+// dayDietaryTags returns the extra tags required on the named day.
+func (opts GenerationOptions) dayDietaryTags(dayName string) []string {
+	for day, tags := range opts.DayDietaryTags {
+		if strings.EqualFold(day, dayName) {
+			return tags
+		}
+	}
+	return nil
+}
+
+// dayTemplate returns the combo template of the named day, or nil when the
+// day uses Template.
+func (opts GenerationOptions) dayTemplate(dayName string) ComboTemplate {
+	for day, template := range opts.DayTemplates {
+		if strings.EqualFold(day, dayName) {
+			return template
+		}
+	}
+	return nil
+}
+
+// filterCategorizedMenu returns the categorized menu restricted to items accepted by keep.
+func filterCategorizedMenu(categorized map[string][]menu.Item, keep func(menu.Item) bool) map[string][]menu.Item {
+	filtered := make(map[string][]menu.Item, len(categorized))
+	for category, items := range categorized {
+		for _, item := range items {
+			if keep(item) {
+				filtered[category] = append(filtered[category], item)
+			}
+		}
+	}
+	return filtered
+}
+
+// filterCandidates returns the candidates whose items are all accepted by keep.
+func filterCandidates(candidates []comboCandidate, keep func(menu.Item) bool) []comboCandidate {
+	var filtered []comboCandidate
+	for _, c := range candidates {
+		if !slices.ContainsFunc(c.Items, func(item menu.Item) bool { return !keep(item) }) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// SplitList splits a comma-separated query value, dropping empty entries.
+func SplitList(raw string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
@@ -0,0 +1,1155 @@
+// Package planner generates menu plans: days of combos, such as a main, a
+// side and a drink, drawn from a menu under calorie, popularity, dietary,
+// budget and variety constraints. It can be embedded in other programs
+// without running the server:
+//
+//	items, err := menu.LoadFile("master_menu.json")
+//	if err != nil {
+//		return err
+//	}
+//	opts := planner.DefaultOptions()
+//	opts.Days = 5
+//	if err := opts.Validate(); err != nil {
+//		return err
+//	}
+//	plan, err := planner.Generate(ctx, items, opts)
+package planner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"task/menu"
+)
+
+// Combo represents a single meal combination in the desired output format.
+type Combo struct {
+	ComboID string `json:"combo_id"`
+	Main    string `json:"main,omitempty"`
+	Side    string `json:"side,omitempty"`
+	Drink   string `json:"drink,omitempty"`
+	// Components lists every item of the combo in template order; Main, Side
+	// and Drink repeat the first item of those categories.
+	Components    []ComboComponent `json:"components"`
+	CalorieCount  int              `json:"calorie_count"`
+	PopularityAvg float64          `json:"popularity_score"`
+	Reasoning     string           `json:"reasoning"`
+	HealthGrade   string           `json:"health_grade"`
+	Macros        Macros           `json:"macros"`
+	Price         float64          `json:"price"`
+	// Meal names the meal slot the combo is served at, when meal slots are used.
+	Meal string `json:"meal,omitempty"`
+	// Score ranks the combo against the others of its day; see ScoreWeights.
+	Score float64 `json:"score"`
+	// NutritionCheck is only set when verification against the nutrition service was requested.
+	NutritionCheck *NutritionCheck `json:"nutrition_check,omitempty"`
+}
+
+// DailyMenu represents the combos for a single day.
+type DailyMenu struct {
+	// Day is the weekday of Date; plans stored before days were dated
+	// name days from Monday on instead.
+	Day string `json:"day"`
+	// Date is the calendar date (YYYY-MM-DD) the day is served on.
+	Date   string  `json:"date,omitempty"`
+	Combos []Combo `json:"combos"`
+	// Closure is set when the day falls on a closure date, to say why it
+	// has no or fewer combos.
+	Closure *Closure `json:"closure,omitempty"`
+	// Macros totals the macros of the day's combos.
+	Macros Macros `json:"macros"`
+	// TotalPrice is the combined price of the day's combos.
+	TotalPrice float64 `json:"total_price"`
+	// TotalCalories is the combined calories of the day's combos.
+	TotalCalories int `json:"total_calories"`
+	// Diversity measures how varied the day's combos are.
+	Diversity DiversityStats `json:"diversity"`
+	// Meals groups the day's combos by meal slot, when meal slots are used.
+	Meals []MealMenu `json:"meals,omitempty"`
+	// Seed is the random seed of a day regenerated after the plan was created.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// MealMenu lists the combos served at one meal of a day.
+type MealMenu struct {
+	Name        string   `json:"name"`
+	MinCalories int      `json:"min_calories"`
+	MaxCalories int      `json:"max_calories"`
+	ComboIDs    []string `json:"combo_ids"`
+}
+
+// MenuPlan represents the entire 3-day (now 7-day) menu plan for JSON output.
+type MenuPlan struct {
+	PlanID string `json:"plan_id,omitempty"`
+	// CreatedAt is when the plan was generated and stored; unset for plans
+	// that are not stored, such as those of the generate command.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// Seed is the random seed the plan was generated with; passing it back
+	// with the same inputs reproduces the plan.
+	Seed int64 `json:"seed"`
+	// MenuVersion is the version of the master menu the plan was last
+	// generated or regenerated from; zero when the menu came with the request.
+	MenuVersion int `json:"menu_version,omitempty"`
+	// EnergyUnit is the unit the plan's calories are reported in when it is
+	// not kcal; see ReportedEnergy.
+	EnergyUnit string `json:"energy_unit,omitempty"`
+	// CalorieWindow is the per-combo calorie window the plan was generated with.
+	CalorieWindow CalorieWindow `json:"calorie_window"`
+	// Options are the generation settings of the plan, kept so single days
+	// can later be regenerated under the same constraints.
+	Options  *GenerationOptions `json:"options,omitempty"`
+	MenuPlan []DailyMenu        `json:"menu_plan"`
+	// TotalPrice is the combined price of every combo in the plan.
+	TotalPrice float64 `json:"total_price"`
+	// TotalCalories is the combined calories of every combo in the plan.
+	TotalCalories int `json:"total_calories"`
+	// Diversity measures how varied the plan is across all days.
+	Diversity DiversityStats `json:"diversity"`
+	// Nutrition sums up the calories and macros of each day and on average.
+	Nutrition NutritionSummary `json:"nutrition"`
+	// ExcludedItems lists the menu items left out because of excluded allergens.
+	ExcludedItems []ExcludedItem `json:"excluded_items,omitempty"`
+	// Warnings describe the meals that have fewer combos than were asked
+	// for, and why.
+	Warnings []PlanWarning `json:"warnings,omitempty"`
+	// Debug holds search statistics when they were asked for with
+	// debug=true. It is not stored with the plan.
+	Debug *GenerationDebug `json:"debug,omitempty"`
+}
+
+// isValidCombo checks if a combo meets the calorie, popularity, macro and price criteria of opts.
+func isValidCombo(items []menu.Item, opts GenerationOptions) bool {
+	return comboRejection(items, opts) == notRejected
+}
+
+// comboRejection returns the first of the criteria of isValidCombo the combo
+// misses, or notRejected when it meets them all.
+func comboRejection(items []menu.Item, opts GenerationOptions) rejectReason {
+	if reason := checkRules(comboRules, items, DayContext{Options: opts}); reason != notRejected {
+		return reason
+	}
+
+	if !comboWithinLimits(items, opts) {
+		return rejectComboLimits
+	}
+	return notRejected
+}
+
+// comboWithinLimits checks the per-combo macro and price limits of opts.
+func comboWithinLimits(items []menu.Item, opts GenerationOptions) bool {
+	if opts.MaxComboPrice > 0 && menu.Price(items...) > opts.MaxComboPrice {
+		return false
+	}
+	return opts.ComboMacros.allows(itemMacros(items...))
+}
+
+// generateReasoning creates a descriptive reasoning string for a combo,
+// written in the language of loc.
+// Taste profiles the caller weighted above 1 are called out as preference matches.
+func generateReasoning(items []menu.Item, totalCalories int, avgPopularity float64, tastePreferences map[string]float64, loc Localizer) string {
+	tasteProfiles := make(map[string]bool)
+	for _, item := range items {
+		tasteProfiles[item.TasteProfile] = true
+	}
+
+	tasteDesc := ""
+	if len(tasteProfiles) == 1 {
+		for k := range tasteProfiles {
+			tasteDesc = loc.format("taste.single", loc.text(k))
+		}
+	} else if tasteProfiles["spicy"] {
+		tasteDesc = loc.format("taste.mixed", loc.text("spicy"))
+	} else if tasteProfiles["sweet"] {
+		tasteDesc = loc.format("taste.mixed", loc.text("sweet"))
+	} else if tasteProfiles["savory"] {
+		tasteDesc = loc.format("taste.mixed", loc.text("savory"))
+	} else if tasteProfiles["fresh"] {
+		tasteDesc = loc.format("taste.mixed", loc.text("fresh"))
+	} else {
+		tasteDesc = loc.text("taste.mixed.any")
+	}
+
+	reasoning := loc.format("reasoning", tasteDesc, avgPopularity, totalCalories)
+
+	var matched []string
+	for _, item := range items {
+		if weight, ok := tastePreference(item.TasteProfile, tastePreferences); ok && weight > 1 && !slices.Contains(matched, loc.text(item.TasteProfile)) {
+			matched = append(matched, loc.text(item.TasteProfile))
+		}
+	}
+	if len(matched) > 0 {
+		reasoning += loc.format("reasoning.preference", loc.join(matched))
+	}
+
+	var resized []string
+	for _, item := range items {
+		if item.Portion != "" {
+			resized = append(resized, loc.format("portion", loc.text(item.Portion), item.ItemName))
+		}
+	}
+	if len(resized) > 0 {
+		reasoning += loc.format("reasoning.portions", loc.join(resized))
+	}
+	return reasoning
+}
+
+// preferenceWeight returns the selection weight of an item: its per-item
+// weight times the weight of its taste profile, each defaulting to 1, times
+// its popularity weight under SelectionPopularity.
+func preferenceWeight(item menu.Item, opts GenerationOptions) float64 {
+	weight := 1.0
+	if itemWeight, ok := opts.PreferenceWeights[item.ItemName]; ok {
+		weight = itemWeight
+	}
+	if tasteWeight, ok := tastePreference(item.TasteProfile, opts.TastePreferences); ok {
+		weight *= tasteWeight
+	}
+	if opts.Selection == SelectionPopularity {
+		temperature := opts.Temperature
+		if temperature == 0 {
+			temperature = DefaultTemperature
+		}
+		weight *= math.Exp(item.PopularityScore / temperature)
+	}
+	return weight
+}
+
+// weighted reports whether items are picked by preferenceWeight rather than
+// with equal odds.
+func (opts GenerationOptions) weighted() bool {
+	return len(opts.PreferenceWeights) > 0 || len(opts.TastePreferences) > 0 || opts.Selection == SelectionPopularity
+}
+
+// tastePreference looks up the weight of a taste profile, ignoring case.
+func tastePreference(profile string, tastePreferences map[string]float64) (float64, bool) {
+	for name, weight := range tastePreferences {
+		if strings.EqualFold(name, profile) {
+			return weight, true
+		}
+	}
+	return 0, false
+}
+
+// pickItem selects a random item, biased by preferenceWeight. Without
+// weights every item is equally likely.
+func pickItem(rng *rand.Rand, items []menu.Item, opts GenerationOptions) menu.Item {
+	if !opts.weighted() {
+		return items[rng.Intn(len(items))]
+	}
+
+	totalWeight := 0.0
+	for _, item := range items {
+		totalWeight += preferenceWeight(item, opts)
+	}
+	if totalWeight <= 0 {
+		return items[rng.Intn(len(items))]
+	}
+
+	target := rng.Float64() * totalWeight
+	for _, item := range items {
+		target -= preferenceWeight(item, opts)
+		if target < 0 {
+			return item
+		}
+	}
+	return items[len(items)-1]
+}
+
+// comboSignature identifies a combo independently of the order of its items.
+func comboSignature(items ...menu.Item) string {
+	itemNames := make([]string, len(items))
+	for i, item := range items {
+		itemNames[i] = item.ItemName
+	}
+	sort.Strings(itemNames)
+	return strings.Join(itemNames, "_")
+}
+
+// generateDailyCombos generates unique combos for a single day, respecting all constraints.
+// slot describes the day being filled; allGeneratedComboSignatures feeds the
+// repetition rule and opts.Rules adds the caller's own rules.
+// With the enumerate strategy, candidates holds every combo that passes isValidCombo,
+// after portion adjustments;
+// with the sample strategy it is unused and combos are found by random sampling.
+// When it fills fewer than opts.CombosPerDay slots, the shortfall says why.
+// It stops early once ctx is done.
+func generateDailyCombos(
+	ctx context.Context,
+	categorizedMenu map[string][]menu.Item,
+	opts GenerationOptions,
+	usedItemsForDay1 *map[string]bool, // Pointer to track Day 1 item uniqueness
+	allGeneratedComboSignatures map[string]int, // Map: comboSignature -> lastDayIndexUsed
+	itemUses map[string]int, // Map: itemName -> times used so far in the plan
+	slot DayContext, // The day being filled, checked against the constraint rules
+	globalComboCounter *int, // For generating unique combo IDs across the week
+	rng *rand.Rand, // Per-plan random source, seeded for reproducibility
+	candidates []comboCandidate, // Precomputed valid combos for the enumerate strategy
+) ([]Combo, shortfall) {
+	dailyCombos := []Combo{}
+	currentDayIndex := slot.Index
+	slot.Options = opts
+	slot.lastServed = allGeneratedComboSignatures
+	currentDayUsedItems := opts.dayUsage.usedItems // Items used in combos for the current day
+	if currentDayUsedItems == nil {
+		currentDayUsedItems = make(map[string]bool)
+	}
+	dayMacros := opts.dayUsage.macros     // Macro totals of the combos chosen so far today
+	dayPrice := opts.dayUsage.price       // Price of the combos chosen so far today
+	dayCalories := opts.dayUsage.calories // Calories of the combos chosen so far today
+	calorieCap := 0                       // Most calories the current slot may use when MaxTotalCalories is set
+
+	template := opts.Template.orDefault()
+	if missing := missingTemplateCategories(categorizedMenu, template); len(missing) > 0 {
+		opts.log().Error("not enough items in all categories to form combos", "template", template.String(), "day", currentDayIndex+1)
+		return []Combo{}, shortfall{shortMissingItems, fmt.Sprintf("not enough %s items to fill the %s template", strings.Join(missing, " and "), template)}
+	}
+
+	// The most expensive combo the day's menu allows scores zero on cost.
+	priceCeiling := opts.MaxComboPrice
+	if priceCeiling <= 0 {
+		for _, category := range template {
+			priceCeiling += maxItemPrice(categorizedMenu[category])
+		}
+	}
+
+	// rejection checks the uniqueness and repetition rules that depend on the
+	// plan so far, returning the reason the combo breaks one or "" when it
+	// breaks none.
+	rejection := func(items []menu.Item, signature string) rejectReason {
+		for _, item := range items {
+			if usedItemsForDay1 != nil && (*usedItemsForDay1)[item.ItemName] { // Only for Day 1 (index 0)
+				return rejectUniqueness
+			}
+			if currentDayUsedItems[item.ItemName] {
+				return rejectUniqueness
+			}
+		}
+
+		if !opts.DayMacros.withinMax(dayMacros.add(itemMacros(items...))) {
+			return rejectDayLimits
+		}
+		if opts.MaxTotalCalories > 0 {
+			if calories, _ := menu.ComboMetrics(items...); calories > calorieCap {
+				return rejectDayLimits
+			}
+		}
+		if opts.MaxTotalPrice > 0 && dayPrice+menu.Price(items...) > opts.dayPriceBudget {
+			return rejectDayLimits
+		}
+
+		for _, item := range items {
+			if limit := opts.itemUseLimit(item.ItemName); limit > 0 && itemUses[item.ItemName] >= limit {
+				return rejectItemUses
+			}
+			if item.Stock != nil && itemUses[item.ItemName] >= *item.Stock {
+				return rejectOutOfStock
+			}
+		}
+
+		if opts.excludedCombos[signature] {
+			return rejectExcluded
+		}
+
+		if reason := checkRules(planRules, items, slot); reason != notRejected {
+			return reason
+		}
+		return checkRules(opts.Rules, items, slot)
+	}
+
+	const maxAttemptsPerCombo = 5000
+	// ctxCheckInterval is how many sampled combos are tried between checks
+	// for cancellation.
+	const ctxCheckInterval = 256
+
+	// slotRetries counts the combos rejected while filling the current slot,
+	// and slotRejections why.
+	slotRetries := 0
+	var slotRejections rejectionTally
+	var short shortfall
+
+	// The backtrack strategy searches the slots together, undoing a combo
+	// that leaves a later slot without one; findCombo then serves the
+	// combos it found. Without a full set it fills what it can like the
+	// enumerate strategy.
+	var solution []comboCandidate
+	if opts.Strategy == StrategyBacktrack {
+		type dayTotals struct {
+			macros   Macros
+			price    float64
+			calories int
+		}
+		var saved []dayTotals
+		search := slotSearch{
+			candidates: searchOrder(rng, candidates, opts),
+			allowed: func(slot int, c comboCandidate) bool {
+				if opts.MaxTotalCalories > 0 {
+					left, slotsLeft := opts.dayCalorieBudget-dayCalories, opts.CombosPerDay-slot+opts.dayUsage.laterCombos
+					calorieCap = left - (slotsLeft-1)*opts.MinCalories
+				}
+				return rejection(c.Items, c.Signature) == notRejected
+			},
+			take: func(c comboCandidate) {
+				saved = append(saved, dayTotals{dayMacros, dayPrice, dayCalories})
+				calories, _ := menu.ComboMetrics(c.Items...)
+				dayMacros = dayMacros.add(itemMacros(c.Items...))
+				dayPrice += menu.Price(c.Items...)
+				dayCalories += calories
+				for _, item := range c.Items {
+					currentDayUsedItems[item.ItemName] = true
+					itemUses[item.ItemName]++
+				}
+			},
+			undo: func(c comboCandidate) {
+				last := saved[len(saved)-1]
+				saved = saved[:len(saved)-1]
+				dayMacros, dayPrice, dayCalories = last.macros, last.price, last.calories
+				for _, item := range c.Items {
+					delete(currentDayUsedItems, item.ItemName)
+					itemUses[item.ItemName]--
+				}
+			},
+		}
+		if opts.triedSolutions != nil && opts.dayUsage.laterCombos == 0 {
+			// The day is complete with this meal; skip the sets of combos
+			// the day was already tried with.
+			search.complete = func(chosen []comboCandidate) bool {
+				signatures := slices.Clone(opts.dayUsage.signatures)
+				for _, c := range chosen {
+					signatures = append(signatures, c.Signature)
+				}
+				return !opts.triedSolutions[daySolutionKey(signatures)]
+			}
+		}
+		solution = search.solve(opts.CombosPerDay)
+		if solution == nil && search.complete != nil && len(opts.triedSolutions) > 0 {
+			// Every other way to fill the day was tried or too long to
+			// find; the plan goes back further instead.
+			return []Combo{}, shortfall{shortExhausted, "no other set of combos was found for the day"}
+		}
+	}
+
+	// findCombo looks for one combo that passes rejection and isValidCombo,
+	// resizing portions where that makes a sampled combo valid.
+	findCombo := func() (comboCandidate, bool) {
+		if solution != nil {
+			return solution[len(dailyCombos)], true
+		}
+		allowed := func(c comboCandidate) bool {
+			reason := rejection(c.Items, c.Signature)
+			if reason == notRejected {
+				return true
+			}
+			slotRetries++
+			slotRejections[reason]++
+			return false
+		}
+		if objective := optimizationObjective(opts.Optimize); objective != nil {
+			return bestCandidate(candidates, objective, allowed)
+		}
+		if opts.Strategy != StrategySample {
+			return pickCandidate(rng, candidates, opts, allowed)
+		}
+		for attempts := 0; attempts < maxAttemptsPerCombo; attempts++ {
+			if attempts%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return comboCandidate{}, false
+			}
+			items := make([]menu.Item, len(template))
+			for i, category := range template {
+				items[i] = pickItem(rng, categorizedMenu[category], opts)
+			}
+			if hasDuplicateItems(items) {
+				slotRejections[rejectDuplicateItems]++
+				continue
+			}
+			fitted, ok := fitPortions(items, opts)
+			if !ok {
+				slotRejections[comboRejection(items, opts)]++
+				continue
+			}
+			signature := comboSignature(fitted...)
+			reason := rejection(fitted, signature)
+			if reason == notRejected {
+				slotRetries += attempts
+				return comboCandidate{Items: fitted, Signature: signature}, true
+			}
+			slotRejections[reason]++
+		}
+		slotRetries += maxAttemptsPerCombo
+		return comboCandidate{}, false
+	}
+
+	for i := 0; i < opts.CombosPerDay; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		var candidate comboCandidate
+		comboFound := false
+		slotRetries = 0
+		slotRejections = rejectionTally{}
+
+		// With a calorie budget, first try to keep the slot within its fair
+		// share of what is left of the day's budget, then settle for any combo
+		// that still leaves room for the remaining slots at MinCalories.
+		calorieCaps := []int{0}
+		if opts.MaxTotalCalories > 0 {
+			left, slotsLeft := opts.dayCalorieBudget-dayCalories, opts.CombosPerDay-i+opts.dayUsage.laterCombos
+			calorieCaps = []int{left / slotsLeft, left - (slotsLeft-1)*opts.MinCalories}
+		}
+		for _, calorieCap = range calorieCaps {
+			if candidate, comboFound = findCombo(); comboFound {
+				break
+			}
+		}
+
+		if !comboFound && ctx.Err() != nil {
+			break
+		}
+		if !comboFound {
+			// Running out of combos indicates insufficient unique items or
+			// very strict constraints.
+			opts.log().Warn("no unique and valid combo found for slot",
+				"day", currentDayIndex+1, "slot", i+1, "strategy", opts.Strategy, "retries", slotRetries)
+			if Metrics != nil && !opts.proposing {
+				Metrics.AddInfeasibleSlot(opts.Strategy)
+			}
+			opts.span().Add("attempts", slotRetries)
+			opts.span().Add("infeasible_slots", 1)
+			opts.Debug.addSlot(currentDayIndex, i, len(candidates), false, slotRejections)
+			short = slotShortfall(opts, len(candidates), slotRejections)
+			break
+		}
+		opts.Debug.addSlot(currentDayIndex, i, len(candidates), true, slotRejections)
+		opts.log().Debug("slot filled", "day", currentDayIndex+1, "slot", i+1, "retries", slotRetries)
+		if Metrics != nil && !opts.proposing {
+			Metrics.ObserveAttempts(opts.Strategy, slotRetries+1)
+		}
+		opts.span().Add("attempts", slotRetries+1)
+
+		items := candidate.Items
+		totalCalories, avgPopularity := menu.ComboMetrics(items...)
+		macros := itemMacros(items...)
+
+		*globalComboCounter++ // Increment global counter for unique ID
+		combo := Combo{
+			ComboID:       fmt.Sprintf("combo_%d", *globalComboCounter),
+			CalorieCount:  totalCalories,
+			PopularityAvg: math.Round(avgPopularity*100) / 100,
+			Reasoning:     generateReasoning(items, totalCalories, avgPopularity, opts.TastePreferences, NewLocalizer(opts.Lang)),
+			HealthGrade:   healthGrade(items, opts.MinCalories, opts.MaxCalories, opts.HealthRubric),
+			Macros:        macros.rounded(),
+			Price:         menu.RoundPrice(menu.Price(items...)),
+			Score:         comboScore(items, priceCeiling, opts.ScoreWeights),
+		}
+		for i, item := range items {
+			combo.Components = append(combo.Components, ComboComponent{Category: template[i], ItemName: item.ItemName, Portion: item.Portion})
+			switch {
+			case template[i] == "main" && combo.Main == "":
+				combo.Main = item.ItemName
+			case template[i] == "side" && combo.Side == "":
+				combo.Side = item.ItemName
+			case template[i] == "drink" && combo.Drink == "":
+				combo.Drink = item.ItemName
+			}
+		}
+		dailyCombos = append(dailyCombos, combo)
+		dayMacros = dayMacros.add(macros)
+		dayPrice += menu.Price(items...)
+		dayCalories += totalCalories
+
+		for _, item := range items {
+			currentDayUsedItems[item.ItemName] = true
+			if usedItemsForDay1 != nil {
+				(*usedItemsForDay1)[item.ItemName] = true
+			}
+			itemUses[item.ItemName]++
+		}
+
+		allGeneratedComboSignatures[candidate.Signature] = currentDayIndex // Update last used day for this combo
+	}
+	if opts.dayUsage.laterCombos == 0 && !opts.DayMacros.allows(dayMacros) {
+		opts.log().Info("day does not meet the daily macro targets", "day", currentDayIndex+1)
+	}
+	rankCombos(dailyCombos)
+	return dailyCombos, short
+}
+
+// planGenerator holds the state shared by the days of a plan while they are
+// generated: the menu, the random source and what earlier days used.
+type planGenerator struct {
+	// ctx stops generation once it is done.
+	ctx             context.Context
+	opts            GenerationOptions
+	categorizedMenu map[string][]menu.Item
+	index           *ComboIndex
+	// candidates are the valid combos of the enumerate strategy, unless they
+	// differ by day because of a calorie schedule.
+	candidates []comboCandidate
+	rng        *rand.Rand
+
+	day1UsedItems map[string]bool
+	// comboSignatures maps each comboSignature to the last day index it was used on.
+	comboSignatures map[string]int
+	itemUses        map[string]int // Map: itemName -> times used across the plan
+	comboCounter    int            // To generate unique combo IDs across the entire plan
+	// warnings describe the meals generated with fewer combos than asked for.
+	warnings []PlanWarning
+	// tried holds, for each day, the sets of combos the backtrack strategy
+	// went back on; see daySolutionKey.
+	tried []map[string]bool
+	// candidateCache holds the candidate sets computed so far; see candidatesFor.
+	candidateCache map[candidateKey][]comboCandidate
+	// start is the date of the plan's first day, and restricted lists the
+	// items that are only served part of the year or on some weekdays.
+	start      time.Time
+	restricted []menu.Item
+}
+
+// newPlanGenerator prepares the generation of a plan from masterMenu. index
+// may be a prebuilt ComboIndex of masterMenu; when nil it is built on demand.
+func newPlanGenerator(ctx context.Context, masterMenu []menu.Item, opts GenerationOptions, index *ComboIndex, rng *rand.Rand) *planGenerator {
+	opts.Rules = opts.constraintRules()
+	g := &planGenerator{
+		ctx:             ctx,
+		opts:            opts,
+		categorizedMenu: menu.Categorize(masterMenu),
+		rng:             rng,
+		day1UsedItems:   make(map[string]bool),
+		comboSignatures: make(map[string]int),
+		itemUses:        make(map[string]int),
+		candidateCache:  make(map[candidateKey][]comboCandidate),
+		start:           opts.FirstDate(),
+	}
+	for _, item := range masterMenu {
+		if item.IsRestricted() {
+			g.restricted = append(g.restricted, item)
+		}
+	}
+	if opts.Strategy != StrategySample {
+		if index == nil {
+			index = NewComboIndex(masterMenu)
+		}
+		g.index = index
+		if len(opts.CalorieSchedule) == 0 {
+			g.candidates = g.candidatesFor(opts)
+		}
+	}
+	return g
+}
+
+// dayState is the menu and settings generation uses for one day of a plan.
+type dayState struct {
+	index int
+	name  string
+	date  time.Time
+	opts  GenerationOptions
+	// keep accepts the items allowed on the day; nil allows every item.
+	keep func(menu.Item) bool
+	// closure is the closure falling on the day, if any, and meals are the
+	// meals served under it.
+	closure *Closure
+	meals   []MealSlot
+	// menu and candidates hold only the items allowed on the day.
+	menu       map[string][]menu.Item
+	candidates []comboCandidate
+}
+
+// day prepares the generation of day dayIndex. priceBudget and calorieBudget
+// cap the day's price and calories when MaxTotalPrice and MaxTotalCalories
+// are set.
+func (g *planGenerator) day(dayIndex int, priceBudget float64, calorieBudget int) dayState {
+	opts := g.opts
+	day := dayState{
+		index:      dayIndex,
+		date:       g.start.AddDate(0, 0, dayIndex),
+		opts:       opts.forDay(dayIndex),
+		menu:       g.categorizedMenu,
+		candidates: g.candidates,
+	}
+	day.name = day.date.Weekday().String()
+	day.closure = opts.closureOn(day.date)
+	day.meals = day.closure.meals(opts.meals())
+	template := opts.dayTemplate(day.name)
+	if template != nil {
+		day.opts.Template = template
+	}
+	if opts.Strategy != StrategySample && (len(opts.CalorieSchedule) > 0 || template != nil) {
+		// The day has its own calorie window or template, so its candidates differ.
+		day.candidates = g.candidatesFor(day.opts)
+	}
+
+	// Restrict the menu to items allowed on this day.
+	day.keep = opts.itemFilter(day.name)
+	if unavailable := menu.Unavailable(g.restricted, day.date, day.name); unavailable != nil {
+		available := func(item menu.Item) bool { return !unavailable[item.ItemName] }
+		if keep := day.keep; keep != nil {
+			day.keep = func(item menu.Item) bool { return available(item) && keep(item) }
+		} else {
+			day.keep = available
+		}
+	}
+	if day.keep != nil {
+		day.menu = filterCategorizedMenu(g.categorizedMenu, day.keep)
+		day.candidates = filterCandidates(day.candidates, day.keep)
+	}
+	day.opts.dayPriceBudget = priceBudget
+	day.opts.dayCalorieBudget = calorieBudget
+	return day
+}
+
+// meal prepares the generation of one meal of a day: it returns the items
+// served at the meal, its candidate combos and its settings.
+func (g *planGenerator) meal(day dayState, meal MealSlot) (map[string][]menu.Item, []comboCandidate, GenerationOptions) {
+	mealOpts := day.opts.forMeal(meal)
+
+	mealMenu, mealCandidates := day.menu, day.candidates
+	if g.opts.Strategy != StrategySample && (meal.MaxCalories > 0 || len(meal.Template) > 0) {
+		// The meal has its own calorie window or template, so its candidates differ.
+		mealCandidates = g.candidatesFor(mealOpts)
+		if day.keep != nil {
+			mealCandidates = filterCandidates(mealCandidates, day.keep)
+		}
+	}
+	if meal.Name != "" {
+		keep := func(item menu.Item) bool { return servesMeal(item, meal.Name) }
+		mealMenu = filterCategorizedMenu(day.menu, keep)
+		mealCandidates = filterCandidates(mealCandidates, keep)
+	}
+	return mealMenu, mealCandidates, mealOpts
+}
+
+// generateMeal generates the combos of one meal of a day. usage holds what
+// the day's other meals use; its laterCombos counts the combos still to be
+// generated after this meal. The shortfall says why the meal got fewer
+// combos than asked for, if it did.
+func (g *planGenerator) generateMeal(day dayState, meal MealSlot, usage dayUsage) ([]Combo, GenerationOptions, shortfall) {
+	mealMenu, mealCandidates, mealOpts := g.meal(day, meal)
+	mealOpts.dayUsage = usage
+	if g.tried != nil {
+		mealOpts.triedSolutions = g.tried[day.index]
+	}
+
+	var currentDayItemUniquenessTracker *map[string]bool
+	if day.index == 0 { // Only for the first day of the plan
+		currentDayItemUniquenessTracker = &g.day1UsedItems
+	}
+	firstSlot := 0
+	if g.opts.Debug != nil {
+		firstSlot = len(g.opts.Debug.Slots)
+	}
+	mealCombos, short := generateDailyCombos(
+		g.ctx,
+		mealMenu,
+		mealOpts,
+		currentDayItemUniquenessTracker,
+		g.comboSignatures, // Pass the map for repetition window tracking
+		g.itemUses,        // Pass the per-item usage counts
+		DayContext{Index: day.index, Name: day.name, Date: day.date, Meal: meal.Name},
+		&g.comboCounter, // Pass global combo counter
+		g.rng,
+		mealCandidates,
+	)
+	for i := range mealCombos {
+		mealCombos[i].Meal = meal.Name
+	}
+	if g.opts.Debug != nil {
+		for i := firstSlot; i < len(g.opts.Debug.Slots); i++ {
+			g.opts.Debug.Slots[i].DayName = day.name
+			g.opts.Debug.Slots[i].Meal = meal.Name
+		}
+	}
+	if short.reason != "" && mealOpts.Template.hasOptional() && g.ctx.Err() == nil {
+		// No combo fills the optional roles any more; the rest of the meal
+		// leaves them out.
+		rest := meal
+		rest.Combos -= len(mealCombos)
+		rest.Template = mealOpts.Template.required()
+		usage.signatures = slices.Clip(usage.signatures)
+		for _, combo := range mealCombos {
+			usage.add(combo)
+		}
+		var restCombos []Combo
+		restCombos, _, short = g.generateMeal(day, rest, usage)
+		mealCombos = append(mealCombos, restCombos...)
+	}
+	return mealCombos, mealOpts, short
+}
+
+// generateDay generates the combos of one day. priceBudget and calorieBudget
+// cap the day's price and calories when MaxTotalPrice and MaxTotalCalories
+// are set. Locked combos are kept in their meals and count towards the
+// day's rules and limits; only the remaining slots are generated.
+func (g *planGenerator) generateDay(dayIndex int, priceBudget float64, calorieBudget int, locked []Combo) DailyMenu {
+	day := g.day(dayIndex, priceBudget, calorieBudget)
+	daySpan := g.opts.span().Child("generate_day")
+	defer daySpan.End()
+	daySpan.Set("day.index", dayIndex+1)
+	daySpan.Set("day.name", day.name)
+	day.opts.Span = daySpan
+	g.opts.log().Debug("generating day", "day", dayIndex+1, "day_name", day.name)
+	if day.closure != nil {
+		daySpan.Set("day.closure", day.closure.Date)
+		g.opts.log().Info("day falls on a closure", "day", dayIndex+1, "date", day.date.Format(time.DateOnly), "combos", day.closure.Combos, "reason", day.closure.Reason)
+	}
+
+	// Fill the day meal by meal. Without meal slots the whole day is a
+	// single unnamed meal of CombosPerDay combos; a closure shrinks the meals.
+	meals := day.meals
+	usage := dayUsage{usedItems: make(map[string]bool)}
+	lockedByMeal := make(map[string][]Combo)
+	for _, combo := range locked {
+		usage.add(combo)
+		lockedByMeal[combo.Meal] = append(lockedByMeal[combo.Meal], combo)
+		g.comboSignatures[combo.signature()] = dayIndex
+		if dayIndex == 0 {
+			for _, component := range combo.Components {
+				g.day1UsedItems[component.ItemName] = true
+			}
+		}
+	}
+	for _, meal := range meals {
+		usage.laterCombos += max(0, meal.Combos-len(lockedByMeal[meal.Name]))
+	}
+	dailyCombos := []Combo{}
+	var dayMeals []MealMenu
+	for _, meal := range meals {
+		kept := lockedByMeal[meal.Name]
+		meal.Combos = max(0, meal.Combos-len(kept))
+		usage.laterCombos -= meal.Combos
+		mealCombos, mealOpts, short := []Combo(nil), day.opts.forMeal(meal), shortfall{}
+		if meal.Combos > 0 {
+			mealCombos, mealOpts, short = g.generateMeal(day, meal, usage)
+		}
+		for _, combo := range mealCombos {
+			usage.add(combo)
+		}
+		if len(kept) > 0 {
+			mealCombos = append(append([]Combo(nil), kept...), mealCombos...)
+			rankCombos(mealCombos)
+		}
+		if short.reason != "" {
+			g.warnings = append(g.warnings, short.warning(dayIndex, day.name, meal.Name, len(mealCombos), len(kept)+meal.Combos))
+		}
+
+		mealMenuSummary := MealMenu{Name: meal.Name, MinCalories: mealOpts.MinCalories, MaxCalories: mealOpts.MaxCalories}
+		for _, combo := range mealCombos {
+			mealMenuSummary.ComboIDs = append(mealMenuSummary.ComboIDs, combo.ComboID)
+		}
+		dailyCombos = append(dailyCombos, mealCombos...)
+		if meal.Name != "" {
+			dayMeals = append(dayMeals, mealMenuSummary)
+		}
+	}
+
+	expected := 0
+	for _, meal := range meals {
+		expected += meal.Combos
+	}
+	if len(dailyCombos) < expected && g.ctx.Err() == nil {
+		// This happens when constraints are too strict for the available menu items.
+		g.opts.log().Warn("day is missing combos",
+			"day", dayIndex+1, "day_name", day.name, "generated", len(dailyCombos), "expected", expected)
+	}
+
+	daySpan.Set("combos", len(dailyCombos))
+	daily := DailyMenu{
+		Day:     NewLocalizer(g.opts.Lang).DayName(day.name),
+		Date:    day.date.Format(time.DateOnly),
+		Combos:  dailyCombos,
+		Meals:   dayMeals,
+		Closure: day.closure,
+	}
+	daily.updateTotals()
+	return daily
+}
+
+// updateTotals recomputes the macro, price and calorie totals of the day from its combos.
+func (day *DailyMenu) updateTotals() {
+	var dayMacros Macros
+	dayPrice, dayCalories := 0.0, 0
+	for _, combo := range day.Combos {
+		dayMacros = dayMacros.add(combo.Macros)
+		dayPrice += combo.Price
+		dayCalories += combo.CalorieCount
+	}
+	day.Macros = dayMacros.rounded()
+	day.TotalPrice = menu.RoundPrice(dayPrice)
+	day.TotalCalories = dayCalories
+}
+
+// updateTotals recomputes the price and calorie totals, the nutrition
+// summary and the diversity of the plan from its days.
+func (plan *MenuPlan) updateTotals(items []menu.Item) {
+	plan.TotalPrice, plan.TotalCalories = 0, 0
+	for _, day := range plan.MenuPlan {
+		for _, combo := range day.Combos {
+			plan.TotalPrice += combo.Price
+		}
+		plan.TotalCalories += day.TotalCalories
+	}
+	plan.TotalPrice = menu.RoundPrice(plan.TotalPrice)
+	plan.Nutrition = summarizeNutrition(plan.MenuPlan)
+	addPlanDiversity(plan, items)
+}
+
+// discardedDays adds up the days of a plan generated with DiscardDays as
+// they are handed to OnDay, keeping only what the plan's summaries need.
+type discardedDays struct {
+	price     float64
+	combos    int
+	diversity *diversityCounter
+}
+
+// add counts day and returns the digest kept in its place: its name,
+// calories and macros, for the nutrition summary.
+func (d *discardedDays) add(day DailyMenu) DailyMenu {
+	for _, combo := range day.Combos {
+		d.price += combo.Price
+	}
+	d.combos += len(day.Combos)
+	d.diversity.add(day.Combos)
+	return DailyMenu{Day: day.Day, Macros: day.Macros, TotalCalories: day.TotalCalories}
+}
+
+// summarize fills in the totals, nutrition summary and diversity of plan
+// from the digests of its days, which it then drops.
+func (d *discardedDays) summarize(plan *MenuPlan) {
+	plan.TotalPrice, plan.TotalCalories = menu.RoundPrice(d.price), 0
+	for _, day := range plan.MenuPlan {
+		plan.TotalCalories += day.TotalCalories
+	}
+	plan.Nutrition = summarizeNutrition(plan.MenuPlan)
+	plan.Diversity = d.diversity.stats()
+	plan.MenuPlan = nil
+}
+
+// Generate generates a menu plan covering opts.Days days from the items of
+// a menu, typically with options starting from DefaultOptions. The same
+// menu, options and seed always produce the same plan. It returns ctx's
+// error, and no plan, when ctx is done before the plan is.
+func Generate(ctx context.Context, masterMenu []menu.Item, opts GenerationOptions) (MenuPlan, error) {
+	return GenerateWithIndex(ctx, masterMenu, opts, nil)
+}
+
+// GenerateWithIndex is Generate with index, a prebuilt ComboIndex of
+// masterMenu, which is built on demand when nil.
+func GenerateWithIndex(ctx context.Context, masterMenu []menu.Item, opts GenerationOptions, index *ComboIndex) (MenuPlan, error) {
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+	opts.StartDate = opts.FirstDate().Format(time.DateOnly)
+	planOpts := opts
+	planOpts.Seed = &seed
+	start := time.Now()
+	opts.log().Info("generating plan", planOpts.constraintAttrs(), "menu_items", len(masterMenu))
+	planSpan := opts.span().Child("generate_plan")
+	defer planSpan.End()
+	planSpan.Set("plan.days", opts.Days)
+	planSpan.Set("plan.strategy", opts.Strategy)
+	planSpan.Set("plan.seed", seed)
+	opts.Span = planSpan
+	fullMenuPlan := MenuPlan{
+		Seed:          seed,
+		CalorieWindow: CalorieWindow{MinCalories: opts.MinCalories, MaxCalories: opts.MaxCalories},
+		Options:       &planOpts,
+		MenuPlan:      []DailyMenu{},
+		ExcludedItems: excludedItems(masterMenu, opts.ExcludeAllergens),
+	}
+
+	g := newPlanGenerator(ctx, masterMenu, opts, index, rng)
+	g.prefetchCandidates(opts.Days)
+	var catalog map[string]menu.Item
+	if opts.OnDay != nil {
+		catalog = make(map[string]menu.Item, len(masterMenu))
+		for _, item := range masterMenu {
+			catalog[item.ItemName] = item
+		}
+	}
+	remainingBudget := opts.MaxTotalPrice
+	remainingCalories := opts.MaxTotalCalories
+	// The backtrack strategy goes back to the state before a day to fill it
+	// again, and annealing may change any day, so their days are only
+	// streamed once the plan is done.
+	streamDays := opts.OnDay != nil && opts.Strategy != StrategyAnneal && opts.Strategy != StrategyBacktrack
+	var discarded *discardedDays
+	if streamDays && opts.DiscardDays {
+		discarded = &discardedDays{diversity: newDiversityCounter(catalog)}
+	}
+	var checkpoints []generatorCheckpoint
+	backtracks := 0
+	gaveUp := ""
+	if opts.Strategy == StrategyBacktrack {
+		g.tried = make([]map[string]bool, opts.Days)
+	}
+	for dayIndex := 0; dayIndex < opts.Days; dayIndex++ {
+		if g.tried != nil {
+			checkpoints = append(checkpoints[:dayIndex], g.checkpoint(remainingBudget, remainingCalories))
+		}
+		// Spread what is left of the plan's price and calorie budgets evenly
+		// over the remaining days; whatever a day does not use carries over
+		// to the next.
+		priceBudget, calorieBudget := 0.0, 0
+		if opts.MaxTotalPrice > 0 {
+			priceBudget = remainingBudget / float64(opts.Days-dayIndex)
+		}
+		if opts.MaxTotalCalories > 0 {
+			calorieBudget = remainingCalories / (opts.Days - dayIndex)
+		}
+
+		day := g.generateDay(dayIndex, priceBudget, calorieBudget, nil)
+		if err := ctx.Err(); err != nil {
+			planSpan.Fail(err.Error())
+			opts.log().Warn("plan generation stopped", "day", dayIndex+1, "error", err)
+			return MenuPlan{}, err
+		}
+		// A day filled again with combos it was tried with, such as a closed
+		// day, has nothing else to offer either.
+		if g.tried != nil && (shortByEarlierDays(g.warnings[checkpoints[dayIndex].warnings:]) || g.tried[dayIndex][daySolutionKey(day.signatures())]) {
+			switch {
+			case dayIndex > 0 && backtracks < maxDayBacktracks:
+				// Fill the day before with combos it was not tried with yet.
+				backtracks++
+				previous := dayIndex - 1
+				g.tried[dayIndex] = nil
+				if g.tried[previous] == nil {
+					g.tried[previous] = make(map[string]bool)
+				}
+				g.tried[previous][daySolutionKey(fullMenuPlan.MenuPlan[previous].signatures())] = true
+				g.restore(checkpoints[previous])
+				remainingBudget, remainingCalories = checkpoints[previous].remainingBudget, checkpoints[previous].remainingCalories
+				fullMenuPlan.MenuPlan = fullMenuPlan.MenuPlan[:previous]
+				dayIndex = previous - 1
+				continue
+			case backtracks > 0:
+				// No full plan was found; start over and fill the plan as
+				// far as it goes without going back.
+				gaveUp = backtrackExhausted
+				if dayIndex > 0 {
+					gaveUp = backtrackLimit
+				}
+				opts.log().Info("no full plan found by backtracking", "backtracks", backtracks, "gave_up", gaveUp)
+				g.tried = nil
+				g.restore(checkpoints[0])
+				remainingBudget, remainingCalories = checkpoints[0].remainingBudget, checkpoints[0].remainingCalories
+				fullMenuPlan.MenuPlan = fullMenuPlan.MenuPlan[:0]
+				dayIndex = -1
+				continue
+			}
+		}
+		for _, combo := range day.Combos {
+			remainingBudget -= combo.Price
+			remainingCalories -= combo.CalorieCount
+		}
+		fullMenuPlan.MenuPlan = append(fullMenuPlan.MenuPlan, day)
+		if streamDays {
+			// Diversity is otherwise filled in for all days at the end.
+			day.Diversity = comboDiversity(day.Combos, catalog)
+			opts.OnDay(dayIndex, day)
+			if discarded != nil {
+				fullMenuPlan.MenuPlan[dayIndex] = discarded.add(day)
+			}
+		}
+	}
+	if discarded != nil {
+		discarded.summarize(&fullMenuPlan)
+	} else {
+		fullMenuPlan.updateTotals(masterMenu)
+	}
+	if opts.Strategy == StrategyAnneal {
+		anneal(ctx, &fullMenuPlan, opts, masterMenu, g.index, rng)
+	}
+	if opts.OnDay != nil && !streamDays {
+		for dayIndex, day := range fullMenuPlan.MenuPlan {
+			opts.OnDay(dayIndex, day)
+		}
+	}
+	if backtracks > 0 {
+		planSpan.Set("plan.backtracks", backtracks)
+	}
+	if gaveUp == backtrackLimit {
+		noteBacktrackLimit(g.warnings)
+	}
+	if opts.Debug != nil && opts.Strategy == StrategyBacktrack {
+		opts.Debug.Backtrack = &BacktrackStats{Backtracks: backtracks, GaveUp: gaveUp}
+	}
+	fullMenuPlan.Warnings = g.warnings
+	fullMenuPlan.Debug = opts.Debug
+	combos := 0
+	for _, day := range fullMenuPlan.MenuPlan {
+		combos += len(day.Combos)
+	}
+	if discarded != nil {
+		combos = discarded.combos
+	}
+	elapsed := time.Since(start)
+	if Metrics != nil {
+		Metrics.ObserveDuration(opts.Strategy, elapsed)
+	}
+	planSpan.Set("combos", combos)
+	opts.log().Info("plan generated", "combos", combos, "duration_ms", float64(elapsed.Microseconds())/1000)
+	return fullMenuPlan, nil
+}
+
+// constraintAttrs groups the settings that shape a plan for logging.
+func (opts GenerationOptions) constraintAttrs() slog.Attr {
+	attrs := []any{
+		"days", opts.Days,
+		"combos_per_day", opts.combosPerDay(),
+		"min_calories", opts.MinCalories,
+		"max_calories", opts.MaxCalories,
+		"repeat_window", opts.RepeatWindow,
+		"popularity_tolerance", opts.PopularityTolerance,
+		"strategy", opts.Strategy,
+		"template", opts.Template.orDefault().String(),
+	}
+	if opts.Seed != nil {
+		attrs = append(attrs, "seed", *opts.Seed)
+	}
+	if opts.Optimize != "" {
+		attrs = append(attrs, "optimize", opts.Optimize)
+	}
+	if opts.Selection == SelectionPopularity {
+		attrs = append(attrs, "selection", opts.Selection)
+		if opts.Temperature != 0 {
+			attrs = append(attrs, "temperature", opts.Temperature)
+		}
+	}
+	if opts.Profile != "" {
+		attrs = append(attrs, "profile", opts.Profile)
+	}
+	if len(opts.DietaryTags) > 0 {
+		attrs = append(attrs, "dietary_tags", strings.Join(opts.DietaryTags, ","))
+	}
+	if len(opts.ExcludeAllergens) > 0 {
+		attrs = append(attrs, "exclude_allergens", strings.Join(opts.ExcludeAllergens, ","))
+	}
+	if opts.MaxItemUses > 0 {
+		attrs = append(attrs, "max_item_uses", opts.MaxItemUses)
+	}
+	if opts.MaxComboPrice > 0 {
+		attrs = append(attrs, "max_combo_price", opts.MaxComboPrice)
+	}
+	if opts.MaxTotalPrice > 0 {
+		attrs = append(attrs, "max_total_price", opts.MaxTotalPrice)
+	}
+	if opts.MaxTotalCalories > 0 {
+		attrs = append(attrs, "max_total_calories", opts.MaxTotalCalories)
+	}
+	return slog.Group("constraints", attrs...)
+}
+
+// log returns the logger generation messages are written to.
+func (opts GenerationOptions) log() *slog.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,130 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"task/menu"
+)
+
+// testMenu returns a menu of n items in each of the main, side and drink
+// categories, all of which combine into valid combos under DefaultOptions.
+func testMenu(n int) []menu.Item {
+	var items []menu.Item
+	for _, category := range []string{"main", "side", "drink"} {
+		calories := map[string]int{"main": 450, "side": 120, "drink": 100}[category]
+		for i := 1; i <= n; i++ {
+			items = append(items, menu.Item{
+				ItemName:        fmt.Sprintf("%s %d", category, i),
+				Category:        category,
+				Calories:        calories,
+				TasteProfile:    "savory",
+				PopularityScore: 0.7,
+			})
+		}
+	}
+	return items
+}
+
+func TestPreferenceWeightsFavourItem(t *testing.T) {
+	items := testMenu(4)
+	const preferred = "side 1"
+	count := func(weights map[string]float64) int {
+		n := 0
+		for seed := int64(1); seed <= 5; seed++ {
+			opts := DefaultOptions()
+			opts.Days = 7
+			opts.CombosPerDay = 1
+			opts.RepeatWindow = 0
+			opts.Seed = &seed
+			opts.PreferenceWeights = weights
+			opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+			plan, err := Generate(context.Background(), items, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, day := range plan.MenuPlan {
+				for _, combo := range day.Combos {
+					if combo.Side == preferred {
+						n++
+					}
+				}
+			}
+		}
+		return n
+	}
+
+	plain := count(nil)
+	weighted := count(map[string]float64{preferred: 50})
+	if weighted <= plain {
+		t.Fatalf("%s served %d times with a weight of 50, not more than the %d times without", preferred, weighted, plain)
+	}
+}
+
+func TestDiscardDaysKeepsSummaries(t *testing.T) {
+	items := testMenu(4)
+	generate := func(discard bool) (MenuPlan, []DailyMenu) {
+		seed := int64(3)
+		opts := DefaultOptions()
+		opts.Days = 10
+		opts.Seed = &seed
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		var streamed []DailyMenu
+		opts.OnDay = func(dayIndex int, day DailyMenu) { streamed = append(streamed, day) }
+		opts.DiscardDays = discard
+		plan, err := Generate(context.Background(), items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plan, streamed
+	}
+
+	kept, _ := generate(false)
+	discarded, streamed := generate(true)
+	if discarded.MenuPlan != nil {
+		t.Fatalf("plan kept %d days with DiscardDays", len(discarded.MenuPlan))
+	}
+	if !reflect.DeepEqual(streamed, kept.MenuPlan) {
+		t.Error("days handed to OnDay differ from the days of the same plan generated without DiscardDays")
+	}
+	// The options differ by OnDay and DiscardDays themselves.
+	kept.MenuPlan, kept.Options, discarded.Options = nil, nil, nil
+	if !reflect.DeepEqual(discarded, kept) {
+		t.Errorf("plan summaries differ with DiscardDays:\n got %+v\nwant %+v", discarded, kept)
+	}
+}
+
+func TestBacktrackLimitIsReported(t *testing.T) {
+	// Two items per category make eight combos, too few for nine days
+	// without a repeat, and going back a day cannot change that.
+	seed := int64(1)
+	opts := DefaultOptions()
+	opts.Strategy = StrategyBacktrack
+	opts.Days = 9
+	opts.CombosPerDay = 1
+	opts.RepeatWindow = 14
+	opts.Seed = &seed
+	opts.Debug = &GenerationDebug{}
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	plan, err := Generate(context.Background(), testMenu(2), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BacktrackStats{Backtracks: maxDayBacktracks, GaveUp: backtrackLimit}
+	if got := plan.Debug.Backtrack; got == nil || *got != want {
+		t.Errorf("backtrack stats are %+v, want %+v", got, want)
+	}
+	if len(plan.Warnings) == 0 {
+		t.Fatal("plan too long for its combos has no warnings")
+	}
+	for _, w := range plan.Warnings {
+		if !strings.Contains(w.Message, "backtrack search gave up") {
+			t.Errorf("warning %q does not say the backtrack search gave up", w.Message)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package planner
+
+import (
+	"math"
+
+	"task/menu"
+)
+
+// HealthRubric configures how a combo's healthiness grade is computed.
+// Each component is scored between 0 and 1 and the weighted average is
+// mapped to a letter grade using GradeCutoffs.
+type HealthRubric struct {
+	CalorieWeight float64 `json:"calorie_weight" yaml:"calorie_weight"`
+	ProteinWeight float64 `json:"protein_weight" yaml:"protein_weight"`
+	SodiumWeight  float64 `json:"sodium_weight" yaml:"sodium_weight"`
+	SugarWeight   float64 `json:"sugar_weight" yaml:"sugar_weight"`
+
+	// TargetProteinRatio is the share of calories from protein that earns a full protein score.
+	TargetProteinRatio float64 `json:"target_protein_ratio" yaml:"target_protein_ratio"`
+	// MaxSodiumMg and MaxSugarGrams are the per-combo amounts at which those scores drop to zero.
+	MaxSodiumMg   float64 `json:"max_sodium_mg" yaml:"max_sodium_mg"`
+	MaxSugarGrams float64 `json:"max_sugar_g" yaml:"max_sugar_g"`
+
+	// GradeCutoffs are the minimum scores for A, B, C and D; anything lower is an F.
+	GradeCutoffs [4]float64 `json:"grade_cutoffs" yaml:"grade_cutoffs"`
+}
+
+// DefaultHealthRubric is the rubric used when no other rubric is configured.
+var DefaultHealthRubric = HealthRubric{
+	CalorieWeight:      0.3,
+	ProteinWeight:      0.3,
+	SodiumWeight:       0.2,
+	SugarWeight:        0.2,
+	TargetProteinRatio: 0.2,
+	MaxSodiumMg:        2000,
+	MaxSugarGrams:      50,
+	GradeCutoffs:       [4]float64{0.8, 0.65, 0.5, 0.35},
+}
+
+// clamp01 limits v to the range [0, 1].
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// healthScore scores a set of items between 0 and 1 using the given rubric.
+// Calories score best at the midpoint of the min/max calorie band.
+func healthScore(items []menu.Item, minCalories, maxCalories int, rubric HealthRubric) float64 {
+	var calories, protein, sodium, sugar float64
+	for _, item := range items {
+		calories += float64(item.Calories)
+		protein += item.ProteinGrams
+		sodium += item.SodiumMg
+		sugar += item.SugarGrams
+	}
+
+	calorieScore := 1.0
+	if halfBand := float64(maxCalories-minCalories) / 2; halfBand > 0 {
+		midpoint := float64(minCalories) + halfBand
+		calorieScore = clamp01(1 - math.Abs(calories-midpoint)/halfBand)
+	}
+
+	proteinScore := 0.0
+	if calories > 0 && rubric.TargetProteinRatio > 0 {
+		proteinScore = clamp01((protein * 4 / calories) / rubric.TargetProteinRatio)
+	}
+
+	sodiumScore := 1.0
+	if rubric.MaxSodiumMg > 0 {
+		sodiumScore = clamp01(1 - sodium/rubric.MaxSodiumMg)
+	}
+
+	sugarScore := 1.0
+	if rubric.MaxSugarGrams > 0 {
+		sugarScore = clamp01(1 - sugar/rubric.MaxSugarGrams)
+	}
+
+	totalWeight := rubric.CalorieWeight + rubric.ProteinWeight + rubric.SodiumWeight + rubric.SugarWeight
+	if totalWeight <= 0 {
+		return 0
+	}
+	return (calorieScore*rubric.CalorieWeight +
+		proteinScore*rubric.ProteinWeight +
+		sodiumScore*rubric.SodiumWeight +
+		sugarScore*rubric.SugarWeight) / totalWeight
+}
+
+// healthGrade converts the health score of a set of items into a letter grade from A to F.
+func healthGrade(items []menu.Item, minCalories, maxCalories int, rubric HealthRubric) string {
+	score := healthScore(items, minCalories, maxCalories, rubric)
+	for i, cutoff := range rubric.GradeCutoffs {
+		if score >= cutoff {
+			return string(rune('A' + i))
+		}
+	}
+	return "F"
+}
@@ -0,0 +1,34 @@
+package planner
+
+import (
+	"testing"
+
+	"task/menu"
+)
+
+func TestHealthGradeFavoursBalancedCombos(t *testing.T) {
+	const minCalories, maxCalories = 550, 800
+	balanced := []menu.Item{
+		{ItemName: "Grilled Fish", Category: "main", Calories: 450, ProteinGrams: 38, SodiumMg: 300, SugarGrams: 2},
+		{ItemName: "Green Salad", Category: "side", Calories: 120, ProteinGrams: 4, SodiumMg: 80, SugarGrams: 3},
+		{ItemName: "Coconut Water", Category: "drink", Calories: 100, SodiumMg: 20, SugarGrams: 6},
+	}
+	salty := []menu.Item{
+		{ItemName: "Chole Bhature", Category: "main", Calories: 750, ProteinGrams: 12, SodiumMg: 1400, SugarGrams: 6},
+		{ItemName: "Masala Fries", Category: "side", Calories: 330, ProteinGrams: 3, SodiumMg: 900, SugarGrams: 1},
+		{ItemName: "Mango Shake", Category: "drink", Calories: 280, ProteinGrams: 5, SodiumMg: 150, SugarGrams: 40},
+	}
+
+	goodScore := healthScore(balanced, minCalories, maxCalories, DefaultHealthRubric)
+	badScore := healthScore(salty, minCalories, maxCalories, DefaultHealthRubric)
+	if goodScore <= badScore {
+		t.Fatalf("balanced combo scored %.3f, not above the salty combo's %.3f", goodScore, badScore)
+	}
+
+	good := healthGrade(balanced, minCalories, maxCalories, DefaultHealthRubric)
+	bad := healthGrade(salty, minCalories, maxCalories, DefaultHealthRubric)
+	// Grades run from A to F, so a better grade is a smaller letter.
+	if good >= bad {
+		t.Fatalf("balanced combo graded %s, not better than the salty combo's %s", good, bad)
+	}
+}
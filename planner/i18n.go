@@ -0,0 +1,157 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"task/menu"
+)
+
+// defaultLanguage is the language plans are written in unless another is
+// asked for. Its catalog holds every message, so other catalogs may leave
+// messages out.
+const defaultLanguage = "en"
+
+// messageCatalogs hold the text shown to diners by language code: day
+// names, taste profiles and the sentences of a combo's reasoning. Keys are
+// the English day names and taste profiles, and message IDs for the
+// sentences, which are fmt formats taking the same arguments in every
+// language.
+var messageCatalogs = map[string]map[string]string{
+	"en": {
+		"reasoning":            "This combo features %s, consists of popular choices (average popularity: %.2f), and meets the calorie target (%d kcal).",
+		"reasoning.preference": " It matches your preference for %s food.",
+		"reasoning.portions":   " It serves %s to fit the calorie window.",
+		"taste.single":         "a %s profile",
+		"taste.mixed":          "a %s and mixed taste profile",
+		"taste.mixed.any":      "a mixed taste profile",
+		"portion":              "a %s %s",
+		"and":                  " and ",
+	},
+	"es": {
+		"Monday": "Lunes", "Tuesday": "Martes", "Wednesday": "Miércoles", "Thursday": "Jueves",
+		"Friday": "Viernes", "Saturday": "Sábado", "Sunday": "Domingo",
+		"spicy": "picante", "sweet": "dulce", "savory": "salado", "fresh": "fresco",
+		"reasoning":            "Este combo ofrece %s, reúne opciones populares (popularidad media: %.2f) y cumple el objetivo de calorías (%d kcal).",
+		"reasoning.preference": " Coincide con tu preferencia por la comida %s.",
+		"reasoning.portions":   " Sirve %s para ajustarse al rango de calorías.",
+		"taste.single":         "un perfil %s",
+		"taste.mixed":          "un perfil de sabor %s y variado",
+		"taste.mixed.any":      "un perfil de sabor variado",
+		"portion":              "una ración %[1]s de %[2]s",
+		"and":                  " y ",
+	},
+	"fr": {
+		"Monday": "Lundi", "Tuesday": "Mardi", "Wednesday": "Mercredi", "Thursday": "Jeudi",
+		"Friday": "Vendredi", "Saturday": "Samedi", "Sunday": "Dimanche",
+		"spicy": "épicé", "sweet": "sucré", "savory": "salé", "fresh": "frais",
+		"reasoning":            "Ce combo offre %s, réunit des choix populaires (popularité moyenne : %.2f) et respecte l'objectif calorique (%d kcal).",
+		"reasoning.preference": " Il correspond à votre préférence pour la cuisine %s.",
+		"reasoning.portions":   " Il sert %s pour respecter la fourchette de calories.",
+		"taste.single":         "un profil %s",
+		"taste.mixed":          "un profil de saveurs %s et varié",
+		"taste.mixed.any":      "un profil de saveurs varié",
+		"portion":              "une portion %[1]s de %[2]s",
+		"and":                  " et ",
+	},
+	"de": {
+		"Monday": "Montag", "Tuesday": "Dienstag", "Wednesday": "Mittwoch", "Thursday": "Donnerstag",
+		"Friday": "Freitag", "Saturday": "Samstag", "Sunday": "Sonntag",
+		"spicy": "scharf", "sweet": "süß", "savory": "herzhaft", "fresh": "frisch",
+		"reasoning":            "Diese Kombination bietet %s, besteht aus beliebten Gerichten (durchschnittliche Beliebtheit: %.2f) und erfüllt das Kalorienziel (%d kcal).",
+		"reasoning.preference": " Sie entspricht Ihrer Vorliebe für Gerichte, die %s sind.",
+		"reasoning.portions":   " Sie serviert %s, um den Kalorienbereich einzuhalten.",
+		"taste.single":         "ein Geschmacksprofil, das %s ist",
+		"taste.mixed":          "ein gemischtes Geschmacksprofil, vor allem %s",
+		"taste.mixed.any":      "ein gemischtes Geschmacksprofil",
+		"portion":              "eine %[1]s Portion %[2]s",
+		"and":                  " und ",
+	},
+}
+
+// supportedLanguages lists the language codes with a message catalog.
+func supportedLanguages() []string {
+	languages := make([]string, 0, len(messageCatalogs))
+	for lang := range messageCatalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Localizer writes the text of one language.
+type Localizer struct {
+	messages map[string]string
+}
+
+// NewLocalizer returns the Localizer of lang, falling back to the default
+// language when lang is empty or has no catalog.
+func NewLocalizer(lang string) Localizer {
+	return Localizer{messages: messageCatalogs[lang]}
+}
+
+// text returns the message with the given key. Day names and taste
+// profiles missing from the catalog are returned unchanged.
+func (l Localizer) text(key string) string {
+	if message, ok := l.messages[key]; ok {
+		return message
+	}
+	if message, ok := messageCatalogs[defaultLanguage][key]; ok {
+		return message
+	}
+	return key
+}
+
+// format formats the message with the given key.
+func (l Localizer) format(key string, args ...any) string {
+	return fmt.Sprintf(l.text(key), args...)
+}
+
+// DayName returns the localized name of an English day name, which may be
+// in any case. Other names are returned unchanged.
+func (l Localizer) DayName(day string) string {
+	for _, name := range menu.DayNames {
+		if strings.EqualFold(name, day) {
+			return l.text(name)
+		}
+	}
+	return day
+}
+
+// PlanLocalizer returns the Localizer of the language plan was written in.
+func PlanLocalizer(plan MenuPlan) Localizer {
+	if plan.Options == nil {
+		return NewLocalizer(defaultLanguage)
+	}
+	return NewLocalizer(plan.Options.Lang)
+}
+
+// join joins parts with the localized word for "and".
+func (l Localizer) join(parts []string) string {
+	return strings.Join(parts, l.text("and"))
+}
+
+// NegotiateLanguage picks the supported language the Accept-Language header
+// prefers most, matching regional variants such as "es-MX" by their base
+// language. It returns "" when the header names none of them.
+func NegotiateLanguage(header string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if messageCatalogs[base] != nil && quality > bestQuality {
+			best, bestQuality = base, quality
+		}
+	}
+	return best
+}
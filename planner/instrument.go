@@ -0,0 +1,51 @@
+package planner
+
+import "time"
+
+// Span is one timed step of generation, such as a day, recorded in the
+// caller's trace. GenerationOptions.Span is the span a plan is generated
+// under; leave it nil to record nothing.
+type Span interface {
+	// Child starts a span of the same trace with this span as its parent.
+	Child(name string) Span
+	// Set records an attribute of the span.
+	Set(key string, value any)
+	// Add adds n to an integer attribute of the span, such as a count of attempts.
+	Add(key string, n int)
+	// Fail marks the span as failed with message.
+	Fail(message string)
+	// End finishes the span.
+	End()
+}
+
+// noSpan records nothing; it stands in for a nil Span.
+type noSpan struct{}
+
+func (noSpan) Child(string) Span { return noSpan{} }
+func (noSpan) Set(string, any)   {}
+func (noSpan) Add(string, int)   {}
+func (noSpan) Fail(string)       {}
+func (noSpan) End()              {}
+
+// span returns the span generation is recorded under.
+func (opts GenerationOptions) span() Span {
+	if opts.Span == nil {
+		return noSpan{}
+	}
+	return opts.Span
+}
+
+// MetricsRecorder receives measurements of every generation so a program
+// embedding the planner can export them. Its methods may be called by
+// several generations at once.
+type MetricsRecorder interface {
+	// ObserveAttempts records the combos tried to fill one slot.
+	ObserveAttempts(strategy string, attempts int)
+	// AddInfeasibleSlot counts a slot no valid combo could be found for.
+	AddInfeasibleSlot(strategy string)
+	// ObserveDuration records how long a plan took to generate.
+	ObserveDuration(strategy string, elapsed time.Duration)
+}
+
+// Metrics records the measurements of generation; nil records nothing.
+var Metrics MetricsRecorder
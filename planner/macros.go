@@ -0,0 +1,107 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+
+	"task/menu"
+)
+
+// Macros holds protein, carbohydrate and fat amounts in grams.
+type Macros struct {
+	ProteinGrams float64 `json:"protein_g"`
+	CarbsGrams   float64 `json:"carbs_g"`
+	FatGrams     float64 `json:"fat_g"`
+}
+
+// add returns the sum of m and other.
+func (m Macros) add(other Macros) Macros {
+	return Macros{
+		ProteinGrams: m.ProteinGrams + other.ProteinGrams,
+		CarbsGrams:   m.CarbsGrams + other.CarbsGrams,
+		FatGrams:     m.FatGrams + other.FatGrams,
+	}
+}
+
+// scaled returns m multiplied by factor.
+func (m Macros) scaled(factor float64) Macros {
+	return Macros{ProteinGrams: m.ProteinGrams * factor, CarbsGrams: m.CarbsGrams * factor, FatGrams: m.FatGrams * factor}
+}
+
+// rounded returns m rounded to one decimal place.
+func (m Macros) rounded() Macros {
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return Macros{ProteinGrams: round(m.ProteinGrams), CarbsGrams: round(m.CarbsGrams), FatGrams: round(m.FatGrams)}
+}
+
+// itemMacros returns the combined macros of items.
+func itemMacros(items ...menu.Item) Macros {
+	var total Macros
+	for _, item := range items {
+		total = total.add(Macros{ProteinGrams: item.ProteinGrams, CarbsGrams: item.CarbsGrams, FatGrams: item.FatGrams})
+	}
+	return total
+}
+
+// MacroRange bounds a macro in grams. A zero Max means there is no upper bound.
+type MacroRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// MacroTargets bounds the protein, carbohydrate and fat of a combo or a day.
+type MacroTargets struct {
+	Protein MacroRange `json:"protein_g"`
+	Carbs   MacroRange `json:"carbs_g"`
+	Fat     MacroRange `json:"fat_g"`
+}
+
+// macroBound pairs a range with its name and the amount being checked.
+type macroBound struct {
+	name  string
+	r     MacroRange
+	value float64
+}
+
+// ranges pairs each range with its name and the matching amount in m.
+func (t MacroTargets) ranges(m Macros) []macroBound {
+	return []macroBound{
+		{"protein_g", t.Protein, m.ProteinGrams},
+		{"carbs_g", t.Carbs, m.CarbsGrams},
+		{"fat_g", t.Fat, m.FatGrams},
+	}
+}
+
+// allows reports whether m lies within every range.
+func (t MacroTargets) allows(m Macros) bool {
+	for _, c := range t.ranges(m) {
+		if c.value < c.r.Min || (c.r.Max > 0 && c.value > c.r.Max) {
+			return false
+		}
+	}
+	return true
+}
+
+// withinMax reports whether m stays under every upper bound; used to build up
+// a day's combos before the lower bounds can be met.
+func (t MacroTargets) withinMax(m Macros) bool {
+	for _, c := range t.ranges(m) {
+		if c.r.Max > 0 && c.value > c.r.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// validate reports the first range that cannot be satisfied.
+func (t MacroTargets) validate() error {
+	for _, c := range t.ranges(Macros{}) {
+		if c.r.Min < 0 || c.r.Max < 0 {
+			return fmt.Errorf("%s bounds must not be negative", c.name)
+		}
+		if c.r.Max > 0 && c.r.Max < c.r.Min {
+			return fmt.Errorf("%s max must not be less than min", c.name)
+		}
+	}
+	return nil
+}
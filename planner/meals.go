@@ -0,0 +1,114 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"task/menu"
+)
+
+// MealSlot is one named meal of the day, such as breakfast, with its own
+// number of combos and calorie window. Only items whose Meals include the
+// slot's name, or that list no meals at all, are served at it.
+type MealSlot struct {
+	Name   string `json:"name" yaml:"name"`
+	Combos int    `json:"combos" yaml:"combos"`
+	// MinCalories and MaxCalories bound each combo of the meal. When
+	// MaxCalories is zero the plan's calorie window applies.
+	MinCalories int `json:"min_calories" yaml:"min_calories"`
+	MaxCalories int `json:"max_calories" yaml:"max_calories"`
+	// Template, when set, overrides the plan's combo template for this meal,
+	// e.g. "main+dessert".
+	Template ComboTemplate `json:"template" yaml:"template"`
+}
+
+// validateMealSlots reports the first meal slot that cannot be used.
+func validateMealSlots(slots []MealSlot) error {
+	seen := make(map[string]bool)
+	for i, slot := range slots {
+		name := strings.ToLower(slot.Name)
+		if name == "" {
+			return fmt.Errorf("meal_slots[%d]: name is required", i)
+		}
+		if seen[name] {
+			return fmt.Errorf("meal_slots[%d]: duplicate meal %q", i, slot.Name)
+		}
+		seen[name] = true
+		if slot.Combos < 1 || slot.Combos > maxCombosPerDay {
+			return fmt.Errorf("meal_slots[%d]: combos must be between 1 and %d, got %d", i, maxCombosPerDay, slot.Combos)
+		}
+		if slot.MinCalories < 0 || slot.MaxCalories < 0 {
+			return fmt.Errorf("meal_slots[%d]: calories must not be negative", i)
+		}
+		if slot.MaxCalories > 0 && slot.MaxCalories < slot.MinCalories {
+			return fmt.Errorf("meal_slots[%d]: max_calories (%d) must not be less than min_calories (%d)", i, slot.MaxCalories, slot.MinCalories)
+		}
+	}
+	total := 0
+	for _, slot := range slots {
+		total += slot.Combos
+	}
+	if total > maxCombosPerDay {
+		return fmt.Errorf("meal_slots: at most %d combos per day in total, got %d", maxCombosPerDay, total)
+	}
+	return nil
+}
+
+// servesMeal reports whether item may be served at the named meal.
+func servesMeal(item menu.Item, meal string) bool {
+	return len(item.Meals) == 0 || menu.ContainsFold(item.Meals, meal)
+}
+
+// meals returns the meal slots a day is filled with. Without configured
+// slots the day is one unnamed meal of CombosPerDay combos.
+func (opts GenerationOptions) meals() []MealSlot {
+	if len(opts.MealSlots) > 0 {
+		return opts.MealSlots
+	}
+	return []MealSlot{{Combos: opts.CombosPerDay}}
+}
+
+// forMeal returns the options that apply to meal, given those of its day.
+func (opts GenerationOptions) forMeal(meal MealSlot) GenerationOptions {
+	opts.CombosPerDay = meal.Combos
+	if meal.MaxCalories > 0 {
+		opts.MinCalories, opts.MaxCalories = meal.MinCalories, meal.MaxCalories
+	}
+	if len(meal.Template) > 0 {
+		opts.Template = meal.Template
+	}
+	return opts
+}
+
+// combosPerDay returns the number of combos each day should have.
+func (opts GenerationOptions) combosPerDay() int {
+	total := 0
+	for _, meal := range opts.meals() {
+		total += meal.Combos
+	}
+	return total
+}
+
+// dayUsage carries what earlier meals of the same day have used, so that
+// day-level rules and limits hold across meal slots.
+type dayUsage struct {
+	usedItems map[string]bool
+	macros    Macros
+	price     float64
+	calories  int
+	// signatures are the comboSignatures of the combos chosen so far.
+	signatures []string
+	// laterCombos is the number of combos still to be chosen for later meals.
+	laterCombos int
+}
+
+// add records a combo chosen for the day.
+func (u *dayUsage) add(combo Combo) {
+	for _, component := range combo.Components {
+		u.usedItems[component.ItemName] = true
+	}
+	u.macros = u.macros.add(combo.Macros)
+	u.price += combo.Price
+	u.calories += combo.CalorieCount
+	u.signatures = append(u.signatures, combo.signature())
+}
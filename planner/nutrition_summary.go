@@ -0,0 +1,91 @@
+package planner
+
+import (
+	"math"
+
+	"task/menu"
+)
+
+// NutritionSummary sums up what a plan serves day by day and on average,
+// so it can be checked without adding up the combos.
+type NutritionSummary struct {
+	// Days holds the totals of each day, in plan order.
+	Days []DayNutrition `json:"days"`
+	// Total sums every day of the plan.
+	Total NutritionTotals `json:"total"`
+	// DailyAverage is Total divided by the number of days.
+	DailyAverage NutritionTotals `json:"daily_average"`
+	// WeeklyAverages holds the average day of each week of the plan: days 1
+	// to 7, 8 to 14 and so on. The last week may be shorter.
+	WeeklyAverages []NutritionTotals `json:"weekly_averages"`
+}
+
+// DayNutrition is the nutrition served on one day.
+type DayNutrition struct {
+	Day      string  `json:"day"`
+	Calories float64 `json:"calories"`
+	Macros   *Macros `json:"macros,omitempty"`
+}
+
+// NutritionTotals are the calories and macros of a set of combos. Macros is
+// left out when the menu carries no macro values.
+type NutritionTotals struct {
+	Calories float64 `json:"calories"`
+	Macros   *Macros `json:"macros,omitempty"`
+}
+
+// summarizeNutrition computes the nutrition summary of the days.
+func summarizeNutrition(days []DailyMenu) NutritionSummary {
+	summary := NutritionSummary{Days: []DayNutrition{}, WeeklyAverages: []NutritionTotals{}}
+	var totalMacros Macros
+	for _, day := range days {
+		totalMacros = totalMacros.add(day.Macros)
+	}
+	hasMacros := totalMacros != Macros{}
+	// average returns the average day of days.
+	average := func(days []DailyMenu) NutritionTotals {
+		var calories int
+		var macros Macros
+		for _, day := range days {
+			calories += day.TotalCalories
+			macros = macros.add(day.Macros)
+		}
+		n := float64(len(days))
+		return nutritionTotals(float64(calories)/n, macros.scaled(1/n), hasMacros)
+	}
+
+	calories := 0
+	for _, day := range days {
+		totals := nutritionTotals(float64(day.TotalCalories), day.Macros, hasMacros)
+		summary.Days = append(summary.Days, DayNutrition{Day: day.Day, Calories: totals.Calories, Macros: totals.Macros})
+		calories += day.TotalCalories
+	}
+	summary.Total = nutritionTotals(float64(calories), totalMacros, hasMacros)
+	if len(days) == 0 {
+		return summary
+	}
+	summary.DailyAverage = average(days)
+	for start := 0; start < len(days); start += len(menu.DayNames) {
+		summary.WeeklyAverages = append(summary.WeeklyAverages, average(days[start:min(start+len(menu.DayNames), len(days))]))
+	}
+	return summary
+}
+
+// nutritionTotals returns the totals of calories and macros, rounded to a
+// tenth, leaving the macros out unless hasMacros is set.
+func nutritionTotals(calories float64, macros Macros, hasMacros bool) NutritionTotals {
+	totals := NutritionTotals{Calories: math.Round(calories*10) / 10}
+	if hasMacros {
+		rounded := macros.rounded()
+		totals.Macros = &rounded
+	}
+	return totals
+}
+
+// NutritionCheck records the result of cross-checking a combo's calories
+// against the external nutrition service.
+type NutritionCheck struct {
+	VerifiedCalories int    `json:"verified_calories"`
+	Discrepancy      bool   `json:"discrepancy"`
+	Source           string `json:"source"`
+}
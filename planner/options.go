@@ -0,0 +1,513 @@
+package planner
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/menu"
+)
+
+// Limits for the generation settings accepted from callers.
+const (
+	maxDays         = 31
+	maxCombosPerDay = 10
+)
+
+// Generation strategies selectable with the strategy query parameter.
+const (
+	// StrategyEnumerate picks from every valid combo, so a slot is only left
+	// empty when no valid combo exists.
+	StrategyEnumerate = "enumerate"
+	// StrategySample tries random combos until one is valid or the attempts run out.
+	StrategySample = "sample"
+	// StrategyAnneal starts from a plan found like StrategyEnumerate and
+	// improves it by simulated annealing, swapping combos while that raises
+	// the popularity, diversity and cost objective of ScoreWeights.
+	StrategyAnneal = "anneal"
+	// StrategyBacktrack picks from every valid combo like StrategyEnumerate
+	// but searches each meal's slots together and goes back to earlier days
+	// when their combos leave a later day short, so a plan is only left
+	// short when no full plan exists or the search runs out of steps; see
+	// BacktrackStats.
+	StrategyBacktrack = "backtrack"
+)
+
+// CalorieWindow is the calorie range allowed for each combo on one day of a plan.
+type CalorieWindow struct {
+	MinCalories int `json:"min_calories"`
+	MaxCalories int `json:"max_calories"`
+}
+
+// Optimization modes selectable with the optimize query parameter.
+const (
+	// OptimizePopularity fills every slot with the most popular combo the
+	// constraints still allow instead of a random one.
+	OptimizePopularity = "popularity"
+	// OptimizeCost fills every slot with the cheapest combo the constraints
+	// still allow, so the plan costs as little as it can. Unpriced items
+	// count as free.
+	OptimizeCost = "cost"
+)
+
+const (
+	// SelectionUniform picks every allowed item or combo with equal odds,
+	// before preference weights.
+	SelectionUniform = "uniform"
+	// SelectionPopularity weighs items by their popularity scores, so plans
+	// trend popular while less popular items still appear.
+	SelectionPopularity = "popularity"
+	// DefaultTemperature is the temperature of popularity-weighted selection
+	// when none is set.
+	DefaultTemperature = 0.25
+	// minTemperature keeps the selection weights within floating point range.
+	minTemperature = 0.05
+)
+
+// GenerationOptions controls the size and constraints of a generated menu
+// plan. Stored plans keep the options they were generated with, so the JSON
+// field names follow the query parameters and request body fields.
+type GenerationOptions struct {
+	Days                int     `json:"days"`
+	CombosPerDay        int     `json:"combos_per_day"`
+	MinCalories         int     `json:"min_calories"`
+	MaxCalories         int     `json:"max_calories"`
+	PopularityTolerance float64 `json:"popularity_tolerance"`
+	// RepeatWindow is the number of days a combo must wait before it can be
+	// served again: 0 allows repeats on consecutive days, 7 rules them out
+	// within a week.
+	RepeatWindow int `json:"repeat_window"`
+	// MaxItemUses caps how many times any single item may appear in the
+	// plan; ItemUseLimits sets the cap for individual items. Zero means no cap.
+	MaxItemUses   int            `json:"max_item_uses,omitempty"`
+	ItemUseLimits map[string]int `json:"item_use_limits,omitempty"`
+	// Template lists the categories each combo is made of; empty means main+side+drink.
+	Template ComboTemplate `json:"template,omitempty"`
+	// MealSlots splits each day into named meals with their own combos and
+	// calorie windows; CombosPerDay is ignored when it is set.
+	MealSlots []MealSlot `json:"meal_slots,omitempty"`
+	// CalorieSchedule overrides MinCalories and MaxCalories day by day. Plans
+	// longer than the schedule wrap around to its start.
+	CalorieSchedule []CalorieWindow `json:"calorie_schedule,omitempty"`
+	// PreferenceWeights maps item names to selection weights for this request only.
+	PreferenceWeights map[string]float64 `json:"preference_weights,omitempty"`
+	// TastePreferences maps taste profiles to selection weights, biasing
+	// selection towards preferred profiles without relaxing any constraint.
+	TastePreferences map[string]float64 `json:"taste_preferences,omitempty"`
+	// Seed makes generation reproducible; when nil a time-based seed is used.
+	Seed *int64 `json:"seed,omitempty"`
+	// ScoreWeights weighs the components of each combo's ranking score.
+	ScoreWeights ScoreWeights `json:"score_weights"`
+	// Strategy selects how combos are searched for: StrategyEnumerate,
+	// StrategySample, StrategyAnneal or StrategyBacktrack.
+	Strategy string `json:"strategy"`
+	// Selection is how random selection weighs items: SelectionUniform, the
+	// default, or SelectionPopularity.
+	Selection string `json:"selection,omitempty"`
+	// Temperature tunes SelectionPopularity: an item's weight is
+	// exp(popularity_score / Temperature), so lower temperatures favour
+	// popular items more strongly and higher ones approach uniform odds.
+	// Zero means DefaultTemperature.
+	Temperature float64 `json:"temperature,omitempty"`
+	// Optimize, when set, replaces random selection with a greedy search that
+	// maximizes the named objective. It requires the enumerate strategy.
+	Optimize string `json:"optimize,omitempty"`
+	// DietaryTags must be carried by every item in the plan.
+	DietaryTags []string `json:"dietary_tags,omitempty"`
+	// DayDietaryTags maps day names to tags required only on that day.
+	DayDietaryTags map[string][]string `json:"day_dietary_tags,omitempty"`
+	// DayTemplates maps day names to the combo template used on that day
+	// instead of Template, such as one with a dessert on Sundays. Meal
+	// slots with a template of their own keep it.
+	DayTemplates map[string]ComboTemplate `json:"day_templates,omitempty"`
+	// ExcludeAllergens are allergens no item in the plan may contain.
+	ExcludeAllergens []string `json:"exclude_allergens,omitempty"`
+	// ExcludeItems names items that may not appear in the plan.
+	ExcludeItems []string `json:"exclude_items,omitempty"`
+	// Profile names the stored preference profile applied to the plan, if any.
+	Profile string `json:"profile,omitempty"`
+	// Preset names the preset the plan's calorie and macro targets and
+	// combos per day were taken from, if any; see LookupPreset.
+	Preset string `json:"preset,omitempty"`
+	// ComboMacros bounds the macros of every combo; DayMacros bounds the
+	// combined macros of each day's combos.
+	ComboMacros MacroTargets `json:"combo_macros"`
+	DayMacros   MacroTargets `json:"day_macros"`
+	// MaxComboPrice caps the price of each combo; MaxTotalPrice caps the price
+	// of the whole plan. Zero means no cap.
+	MaxComboPrice float64 `json:"max_combo_price,omitempty"`
+	MaxTotalPrice float64 `json:"max_total_price,omitempty"`
+	// MaxTotalCalories caps the calories of the whole plan. Zero means no cap.
+	MaxTotalCalories int `json:"max_total_calories,omitempty"`
+	// StartDate is the date (YYYY-MM-DD) of the plan's first day, which
+	// decides the items in season each day. Empty means the Monday of the
+	// current week; generated plans record the date used.
+	StartDate string `json:"start_date,omitempty"`
+	// Closures lists the dates the cafeteria is closed or serves fewer
+	// combos; the days of the plan falling on them are left empty or shrunk.
+	Closures []Closure `json:"closures,omitempty"`
+	// RuleDefs declares further constraint rules in data, such as a file of
+	// cafeteria policies; they are checked after Rules.
+	RuleDefs []RuleDef `json:"rules,omitempty"`
+	// Lang is the language code the plan's day names and reasoning are
+	// written in; empty means English. See messageCatalogs.
+	Lang string `json:"lang,omitempty"`
+	// Units is the energy unit calories are reported in, kcal or kJ; empty
+	// means kcal. The calorie options themselves are always in kcal;
+	// ParseOptions converts those given in the query.
+	Units string `json:"units,omitempty"`
+
+	// Debug, when set, collects the search statistics of every slot.
+	Debug *GenerationDebug `json:"-"`
+	// HealthRubric grades the generated combos.
+	HealthRubric HealthRubric `json:"-"`
+	// Rules are checked for every combo on top of the built-in constraints;
+	// a combo breaking one is not served.
+	Rules []ConstraintRule `json:"-"`
+	// Logger receives generation messages, carrying the caller's context;
+	// nil uses the default logger.
+	Logger *slog.Logger `json:"-"`
+	// Span is the trace span generation is recorded under; nil when not traced.
+	Span Span `json:"-"`
+	// OnDay, when set, receives each day of the plan as soon as it is
+	// generated, for streaming progress to the client.
+	OnDay func(dayIndex int, day DailyMenu) `json:"-"`
+	// DiscardDays, when set with OnDay, leaves each day out of the returned
+	// plan once OnDay has it, so a long plan is not held in memory; the
+	// plan's totals, nutrition summary and diversity still cover every day.
+	// The anneal and backtrack strategies need every day until the plan is
+	// done and keep them regardless.
+	DiscardDays bool `json:"-"`
+
+	// dayPriceBudget caps the price of a single day's combos when
+	// MaxTotalPrice is set. It is assigned per day while spreading
+	// MaxTotalPrice across the plan.
+	dayPriceBudget float64
+	// dayCalorieBudget likewise caps a day's calories when MaxTotalCalories is set.
+	dayCalorieBudget int
+	// dayUsage is what earlier meals of the day being generated have used.
+	dayUsage dayUsage
+	// triedSolutions holds the sets of combos, by daySolutionKey, the
+	// backtrack strategy may not fill the day with again.
+	triedSolutions map[string]bool
+	// proposing marks the search for a swap while annealing, whose slots
+	// are not counted in the generation metrics.
+	proposing bool
+	// excludedCombos holds signatures of combos that may not be chosen, such
+	// as a combo being swapped out.
+	excludedCombos map[string]bool
+}
+
+// DefaultOptions returns the settings plans are generated with unless they
+// are overridden: a week of three combos a day of 550 to 800 kcal each,
+// found with the enumerate strategy.
+func DefaultOptions() GenerationOptions {
+	return GenerationOptions{
+		Days:                7,
+		CombosPerDay:        3,
+		MinCalories:         550,
+		MaxCalories:         800,
+		PopularityTolerance: 0.15,
+		RepeatWindow:        3,
+		Strategy:            StrategyEnumerate,
+		ScoreWeights:        DefaultScoreWeights,
+		HealthRubric:        DefaultHealthRubric,
+	}
+}
+
+// ParseOptions applies the preset, days, combos_per_day, min_calories,
+// max_calories, max_total_calories, repeat_window, max_item_uses,
+// popularity_tolerance, max_combo_price, max_total_price, seed, strategy,
+// selection, temperature, template, optimize, start_date, lang, units, dietary_tags, exclude_allergens, exclude_items and
+// taste_preferences query parameters on top of defaults. With units=kJ the
+// calorie parameters are given in kilojoules and converted to kcal.
+// A preset replaces the defaults it covers; the other parameters override it.
+// The result should be checked with Validate once all overrides are applied.
+func ParseOptions(defaults GenerationOptions, query url.Values) (GenerationOptions, error) {
+	opts := defaults
+	if raw := query.Get("preset"); raw != "" {
+		preset, err := LookupPreset(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid preset: %w", err)
+		}
+		preset.Apply(&opts)
+	}
+
+	intParams := []struct {
+		name   string
+		target *int
+	}{
+		{"days", &opts.Days},
+		{"combos_per_day", &opts.CombosPerDay},
+		{"min_calories", &opts.MinCalories},
+		{"max_calories", &opts.MaxCalories},
+		{"max_total_calories", &opts.MaxTotalCalories},
+		{"repeat_window", &opts.RepeatWindow},
+		{"max_item_uses", &opts.MaxItemUses},
+	}
+	for _, p := range intParams {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s %q: must be an integer", p.name, raw)
+		}
+		*p.target = value
+	}
+
+	floatParams := []struct {
+		name   string
+		target *float64
+	}{
+		{"popularity_tolerance", &opts.PopularityTolerance},
+		{"max_combo_price", &opts.MaxComboPrice},
+		{"max_total_price", &opts.MaxTotalPrice},
+		{"temperature", &opts.Temperature},
+	}
+	for _, p := range floatParams {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s %q: must be a number", p.name, raw)
+		}
+		*p.target = value
+	}
+
+	if raw := query.Get("seed"); raw != "" {
+		seed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid seed %q: must be an integer", raw)
+		}
+		opts.Seed = &seed
+	}
+
+	if raw := query.Get("strategy"); raw != "" {
+		opts.Strategy = raw
+	}
+	if raw := query.Get("template"); raw != "" {
+		template, err := ParseComboTemplate(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid template: %w", err)
+		}
+		opts.Template = template
+	}
+	if raw := query.Get("selection"); raw != "" {
+		opts.Selection = raw
+	}
+	if raw := query.Get("optimize"); raw != "" {
+		opts.Optimize = raw
+	}
+	if raw := query.Get("start_date"); raw != "" {
+		opts.StartDate = raw
+	}
+	if raw := query.Get("lang"); raw != "" {
+		opts.Lang = strings.ToLower(raw)
+	}
+	if raw := query.Get("units"); raw != "" {
+		unit, err := menu.ParseEnergyUnit(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid units: %w", err)
+		}
+		opts.Units = unit
+		for _, p := range intParams {
+			if strings.HasSuffix(p.name, "_calories") && query.Get(p.name) != "" {
+				*p.target = menu.ToKcal(*p.target, unit)
+			}
+		}
+	}
+
+	opts.DietaryTags = SplitList(query.Get("dietary_tags"))
+	opts.ExcludeAllergens = SplitList(query.Get("exclude_allergens"))
+	opts.ExcludeItems = SplitList(query.Get("exclude_items"))
+
+	if raw := query.Get("taste_preferences"); raw != "" {
+		prefs, err := ParseWeightList(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid taste_preferences %q: %w", raw, err)
+		}
+		opts.TastePreferences = prefs
+	}
+
+	return opts, nil
+}
+
+// Validate checks that the options describe a plan that can be generated.
+func (opts GenerationOptions) Validate() error {
+	if opts.Days < 1 || opts.Days > maxDays {
+		return fmt.Errorf("days must be between 1 and %d, got %d", maxDays, opts.Days)
+	}
+	if opts.CombosPerDay < 1 || opts.CombosPerDay > maxCombosPerDay {
+		return fmt.Errorf("combos_per_day must be between 1 and %d, got %d", maxCombosPerDay, opts.CombosPerDay)
+	}
+	if opts.MinCalories < 0 {
+		return fmt.Errorf("min_calories must not be negative, got %d", opts.MinCalories)
+	}
+	if opts.MaxCalories < opts.MinCalories {
+		return fmt.Errorf("max_calories (%d) must not be less than min_calories (%d)", opts.MaxCalories, opts.MinCalories)
+	}
+	if err := validateMealSlots(opts.MealSlots); err != nil {
+		return err
+	}
+	for i, window := range opts.CalorieSchedule {
+		if window.MinCalories < 0 {
+			return fmt.Errorf("calorie_schedule[%d]: min_calories must not be negative, got %d", i, window.MinCalories)
+		}
+		if window.MaxCalories < window.MinCalories {
+			return fmt.Errorf("calorie_schedule[%d]: max_calories (%d) must not be less than min_calories (%d)", i, window.MaxCalories, window.MinCalories)
+		}
+	}
+	if opts.RepeatWindow < 0 || opts.RepeatWindow > maxDays {
+		return fmt.Errorf("repeat_window must be between 0 and %d, got %d", maxDays, opts.RepeatWindow)
+	}
+	if opts.MaxItemUses < 0 {
+		return fmt.Errorf("max_item_uses must not be negative, got %d", opts.MaxItemUses)
+	}
+	for name, limit := range opts.ItemUseLimits {
+		if limit < 0 {
+			return fmt.Errorf("item_use_limits: limit for %q must not be negative, got %d", name, limit)
+		}
+	}
+	if opts.PopularityTolerance < 0 || opts.PopularityTolerance > 1 {
+		return fmt.Errorf("popularity_tolerance must be between 0 and 1, got %g", opts.PopularityTolerance)
+	}
+	switch opts.Strategy {
+	case StrategyEnumerate, StrategySample, StrategyAnneal, StrategyBacktrack:
+	default:
+		return fmt.Errorf("strategy must be %q, %q, %q or %q, got %q", StrategyEnumerate, StrategySample, StrategyAnneal, StrategyBacktrack, opts.Strategy)
+	}
+	switch opts.Optimize {
+	case "":
+	case OptimizePopularity, OptimizeCost:
+		if opts.Strategy != StrategyEnumerate {
+			return fmt.Errorf("optimize=%s requires the %q strategy", opts.Optimize, StrategyEnumerate)
+		}
+	default:
+		return fmt.Errorf("optimize must be %q or %q, got %q", OptimizePopularity, OptimizeCost, opts.Optimize)
+	}
+	switch opts.Selection {
+	case "", SelectionUniform, SelectionPopularity:
+	default:
+		return fmt.Errorf("selection must be %q or %q, got %q", SelectionUniform, SelectionPopularity, opts.Selection)
+	}
+	if opts.Temperature != 0 && opts.Temperature < minTemperature {
+		return fmt.Errorf("temperature must be at least %g, got %g", minTemperature, opts.Temperature)
+	}
+	if err := opts.ScoreWeights.validate(); err != nil {
+		return fmt.Errorf("score_weights: %w", err)
+	}
+	if opts.StartDate != "" {
+		if _, err := time.Parse(time.DateOnly, opts.StartDate); err != nil {
+			return fmt.Errorf("start_date must be a date (YYYY-MM-DD), got %q", opts.StartDate)
+		}
+	}
+	if err := validateClosures(opts.Closures); err != nil {
+		return err
+	}
+	if err := validateRuleDefs(opts.RuleDefs); err != nil {
+		return err
+	}
+	if opts.Lang != "" && messageCatalogs[opts.Lang] == nil {
+		return fmt.Errorf("lang must be one of %s, got %q", strings.Join(supportedLanguages(), ", "), opts.Lang)
+	}
+	if opts.Preset != "" {
+		if _, err := LookupPreset(opts.Preset); err != nil {
+			return err
+		}
+	}
+	if opts.Units != "" && opts.Units != menu.UnitKcal && opts.Units != menu.UnitKJ {
+		return fmt.Errorf("units must be %s or %s, got %q", menu.UnitKcal, menu.UnitKJ, opts.Units)
+	}
+	if opts.MaxTotalCalories < 0 {
+		return fmt.Errorf("max_total_calories must not be negative, got %d", opts.MaxTotalCalories)
+	}
+	if opts.MaxComboPrice < 0 {
+		return fmt.Errorf("max_combo_price must not be negative, got %g", opts.MaxComboPrice)
+	}
+	if opts.MaxTotalPrice < 0 {
+		return fmt.Errorf("max_total_price must not be negative, got %g", opts.MaxTotalPrice)
+	}
+	if err := opts.ComboMacros.validate(); err != nil {
+		return fmt.Errorf("combo_macros: %w", err)
+	}
+	if err := opts.DayMacros.validate(); err != nil {
+		return fmt.Errorf("day_macros: %w", err)
+	}
+	for day := range opts.DayDietaryTags {
+		if !menu.IsDayName(day) {
+			return fmt.Errorf("day_dietary_tags: unknown day %q", day)
+		}
+	}
+	for day := range opts.DayTemplates {
+		if !menu.IsDayName(day) {
+			return fmt.Errorf("day_templates: unknown day %q", day)
+		}
+	}
+	for name, weight := range opts.PreferenceWeights {
+		if weight < 0 {
+			return fmt.Errorf("invalid preference weight for %q: must be a non-negative number", name)
+		}
+	}
+	for profile, weight := range opts.TastePreferences {
+		if weight < 0 {
+			return fmt.Errorf("invalid taste preference for %q: must be a non-negative number", profile)
+		}
+	}
+	return nil
+}
+
+// itemUseLimit returns how many times the named item may appear in the plan, or 0 for no cap.
+func (opts GenerationOptions) itemUseLimit(itemName string) int {
+	if limit, ok := opts.ItemUseLimits[itemName]; ok {
+		return limit
+	}
+	return opts.MaxItemUses
+}
+
+// FirstDate returns the date of the plan's first day.
+func (opts GenerationOptions) FirstDate() time.Time {
+	if start, err := time.Parse(time.DateOnly, opts.StartDate); err == nil {
+		return start
+	}
+	return StartOfWeek(time.Now())
+}
+
+// forDay returns the options that apply to the day at dayIndex.
+func (opts GenerationOptions) forDay(dayIndex int) GenerationOptions {
+	if len(opts.CalorieSchedule) > 0 {
+		window := opts.CalorieSchedule[dayIndex%len(opts.CalorieSchedule)]
+		opts.MinCalories, opts.MaxCalories = window.MinCalories, window.MaxCalories
+	}
+	return opts
+}
+
+// ParseWeightList parses comma-separated name:weight pairs such as "spicy:2,sweet:0.5".
+func ParseWeightList(raw string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range SplitList(raw) {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected name:weight, got %q", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("weight for %q must be a number", name)
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights, nil
+}
+
+// StartOfWeek returns the Monday of the week containing t, at midnight UTC.
+func StartOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
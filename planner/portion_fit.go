@@ -0,0 +1,59 @@
+package planner
+
+import "task/menu"
+
+// fitPortions returns items, with portion sizes adjusted where needed, such
+// that they pass isValidCombo. The regular sizes are kept when they already
+// pass; otherwise the variant changing the fewest items is chosen, and among
+// those the one closest to the middle of the calorie window. It reports false
+// when no choice of portions passes.
+func fitPortions(items []menu.Item, opts GenerationOptions) ([]menu.Item, bool) {
+	if isValidCombo(items, opts) {
+		return items, true
+	}
+	if !hasPortions(items) {
+		return nil, false
+	}
+
+	midpoint := (opts.MinCalories + opts.MaxCalories) / 2
+	var best []menu.Item
+	bestChanged, bestDistance := 0, 0
+	current := make([]menu.Item, len(items))
+	// try chooses a size for position pos; changed counts the items resized so far.
+	var try func(pos, changed int)
+	try = func(pos, changed int) {
+		if best != nil && changed > bestChanged {
+			return
+		}
+		if pos == len(items) {
+			if changed == 0 || !isValidCombo(current, opts) {
+				return
+			}
+			calories, _ := menu.ComboMetrics(current...)
+			distance := max(calories-midpoint, midpoint-calories)
+			if best == nil || changed < bestChanged || distance < bestDistance {
+				best = append([]menu.Item(nil), current...)
+				bestChanged, bestDistance = changed, distance
+			}
+			return
+		}
+		current[pos] = items[pos]
+		try(pos+1, changed)
+		for _, p := range items[pos].Portions {
+			current[pos] = menu.WithPortion(items[pos], p)
+			try(pos+1, changed+1)
+		}
+	}
+	try(0, 0)
+	return best, best != nil
+}
+
+// hasPortions reports whether any item declares portion sizes.
+func hasPortions(items []menu.Item) bool {
+	for _, item := range items {
+		if len(item.Portions) > 0 {
+			return true
+		}
+	}
+	return false
+}
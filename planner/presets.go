@@ -0,0 +1,72 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Preset is a named set of calorie and macro targets and a number of combos
+// per day for a common audience, so callers can ask for a plan for kids
+// without knowing the numbers behind it.
+type Preset struct {
+	Name string
+	// MinCalories and MaxCalories are the calorie window of each combo.
+	MinCalories  int
+	MaxCalories  int
+	CombosPerDay int
+	// ComboMacros bounds the macros of each combo.
+	ComboMacros MacroTargets
+}
+
+// presets are the presets the preset query parameter selects, by name.
+var presets = []Preset{
+	{
+		Name:         "kids",
+		MinCalories:  500,
+		MaxCalories:  680,
+		CombosPerDay: 2,
+		ComboMacros:  MacroTargets{Fat: MacroRange{Max: 30}},
+	},
+	{
+		Name:         "light",
+		MinCalories:  550,
+		MaxCalories:  720,
+		CombosPerDay: 3,
+		ComboMacros:  MacroTargets{Fat: MacroRange{Max: 28}},
+	},
+	{
+		Name:         "athlete",
+		MinCalories:  750,
+		MaxCalories:  1100,
+		CombosPerDay: 4,
+		ComboMacros:  MacroTargets{Protein: MacroRange{Min: 20}},
+	},
+}
+
+// LookupPreset returns the preset called name.
+func LookupPreset(name string) (Preset, error) {
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (expected one of %s)", name, strings.Join(PresetNames(), ", "))
+}
+
+// PresetNames returns the names of the presets.
+func PresetNames() []string {
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		names[i] = preset.Name
+	}
+	return names
+}
+
+// Apply replaces the calorie window, combos per day and combo macro targets
+// of opts with those of the preset and records its name.
+func (p Preset) Apply(opts *GenerationOptions) {
+	opts.Preset = p.Name
+	opts.MinCalories, opts.MaxCalories = p.MinCalories, p.MaxCalories
+	opts.CombosPerDay = p.CombosPerDay
+	opts.ComboMacros = p.ComboMacros
+}
@@ -0,0 +1,310 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"task/menu"
+)
+
+var (
+	// errDayNotFound is returned when a day reference does not name a day of the plan.
+	errDayNotFound = errors.New("day not found in plan")
+	// ErrComboNotFound is returned when a combo ID does not name a combo of the plan.
+	ErrComboNotFound = errors.New("combo not found in plan")
+	// ErrNoAlternative is returned when no other combo satisfies the plan's constraints.
+	ErrNoAlternative = errors.New("no alternative combo satisfies the plan's constraints")
+)
+
+// FindDay resolves a day reference, a 1-based day number, a date such as
+// "2024-07-02" or a day name such as "tuesday", to an index into
+// plan.MenuPlan. A name matches the first day with that name, in English or
+// in the language of the plan.
+func FindDay(plan MenuPlan, ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(plan.MenuPlan) {
+			return 0, fmt.Errorf("%w: day %d is outside 1-%d", errDayNotFound, n, len(plan.MenuPlan))
+		}
+		return n - 1, nil
+	}
+	loc := PlanLocalizer(plan)
+	for i, day := range plan.MenuPlan {
+		if day.Date == ref || strings.EqualFold(day.Day, ref) || strings.EqualFold(day.Day, loc.DayName(ref)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", errDayNotFound, ref)
+}
+
+// PlanOptions returns the generation settings of a stored plan. Plans stored
+// without them fall back to defaults with the plan's calorie window.
+func PlanOptions(plan MenuPlan, defaults GenerationOptions) GenerationOptions {
+	if plan.Options != nil {
+		opts := *plan.Options
+		opts.Seed = nil
+		opts.HealthRubric = defaults.HealthRubric
+		opts.Rules = defaults.Rules
+		return opts
+	}
+	opts := defaults
+	opts.Days = len(plan.MenuPlan)
+	if plan.CalorieWindow.MaxCalories > 0 {
+		opts.MinCalories, opts.MaxCalories = plan.CalorieWindow.MinCalories, plan.CalorieWindow.MaxCalories
+	}
+	return opts
+}
+
+// signature returns the comboSignature of a generated combo.
+func (c Combo) signature() string {
+	items := make([]menu.Item, len(c.Components))
+	for i, component := range c.Components {
+		items[i] = menu.Item{ItemName: component.ItemName}
+	}
+	return comboSignature(items...)
+}
+
+// comboNumber returns the numeric suffix of a combo ID such as "combo_12", or 0.
+func comboNumber(comboID string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(comboID, "combo_"))
+	return n
+}
+
+// keepDays primes g with what every day of plan except skipDay uses, so
+// that a day generated next respects the repetition window, item use
+// limits and combo numbering of the rest of the plan. It returns the price
+// and calories of those days.
+func (g *planGenerator) keepDays(plan MenuPlan, skipDay int) (float64, int) {
+	price, calories := 0.0, 0
+	for d, day := range plan.MenuPlan {
+		if d == skipDay {
+			continue
+		}
+		for _, combo := range day.Combos {
+			// The repetition window is checked as the distance back to the
+			// last use, so later days are mirrored to the same distance
+			// before skipDay, and the closest use wins.
+			lastUsed := d
+			if d > skipDay {
+				lastUsed = skipDay - (d - skipDay)
+			}
+			signature := combo.signature()
+			if previous, ok := g.comboSignatures[signature]; !ok || lastUsed > previous {
+				g.comboSignatures[signature] = lastUsed
+			}
+			for _, component := range combo.Components {
+				g.itemUses[component.ItemName]++
+			}
+			g.comboCounter = max(g.comboCounter, comboNumber(combo.ComboID))
+			price += combo.Price
+			calories += combo.CalorieCount
+		}
+	}
+	return price, calories
+}
+
+// PlanLocks names the parts of a stored plan that regeneration keeps. Kept
+// combos stay on their day and meal and are treated as fixed by the
+// uniqueness, repetition and item use rules.
+type PlanLocks struct {
+	// Combos lists the IDs of combos to keep, e.g. "combo_5".
+	Combos []string `json:"locked_combos"`
+	// Items lists item names; every combo serving one of them is kept.
+	Items []string `json:"locked_items"`
+}
+
+// keeps reports whether combo is locked.
+func (l PlanLocks) keeps(combo Combo) bool {
+	if slices.Contains(l.Combos, combo.ComboID) {
+		return true
+	}
+	for _, component := range combo.Components {
+		if menu.ContainsFold(l.Items, component.ItemName) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedCombos returns the combos of day that locks keep.
+func lockedCombos(day DailyMenu, locks PlanLocks) []Combo {
+	var locked []Combo
+	for _, combo := range day.Combos {
+		if locks.keeps(combo) {
+			locked = append(locked, combo)
+		}
+	}
+	return locked
+}
+
+// RegenerateDay replaces the combos of day dayIndex of plan with newly
+// generated ones, leaving the other days and the day's locked combos
+// untouched. The new combos follow opts, the plan's settings as returned by
+// PlanOptions, respecting its repetition window, item use limits and budgets
+// against the rest of the plan, and the seed they were drawn with is
+// recorded on the day. It returns ctx's error, leaving plan unchanged, when
+// ctx is done first.
+func RegenerateDay(ctx context.Context, plan *MenuPlan, opts GenerationOptions, dayIndex int, locks PlanLocks, masterMenu []menu.Item, index *ComboIndex, seed int64) error {
+	g := newPlanGenerator(ctx, masterMenu, opts, index, rand.New(rand.NewSource(seed)))
+	otherPrice, otherCalories := g.keepDays(*plan, dayIndex)
+
+	locked := lockedCombos(plan.MenuPlan[dayIndex], locks)
+	for _, combo := range locked {
+		for _, component := range combo.Components {
+			g.itemUses[component.ItemName]++
+		}
+	}
+	priceBudget, calorieBudget := 0.0, 0
+	if opts.MaxTotalPrice > 0 {
+		priceBudget = max(0, opts.MaxTotalPrice-otherPrice)
+	}
+	if opts.MaxTotalCalories > 0 {
+		calorieBudget = max(0, opts.MaxTotalCalories-otherCalories)
+	}
+	day := g.generateDay(dayIndex, priceBudget, calorieBudget, locked)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	day.Day, day.Date = plan.MenuPlan[dayIndex].Day, plan.MenuPlan[dayIndex].Date
+	day.Seed = &seed
+	plan.MenuPlan[dayIndex] = day
+	plan.Warnings = replaceDayWarnings(plan.Warnings, dayIndex, g.warnings)
+	plan.updateTotals(masterMenu)
+	return nil
+}
+
+// RegeneratePlan regenerates every day of plan with its settings opts,
+// keeping only the combos named by locks. Budgets are spread over the days
+// as for a new plan, after setting aside what the locked combos cost. It
+// returns ctx's error, leaving plan unchanged, when ctx is done first.
+func RegeneratePlan(ctx context.Context, plan *MenuPlan, opts GenerationOptions, locks PlanLocks, masterMenu []menu.Item, index *ComboIndex, seed int64) error {
+	opts.Days = len(plan.MenuPlan)
+	g := newPlanGenerator(ctx, masterMenu, opts, index, rand.New(rand.NewSource(seed)))
+	g.prefetchCandidates(opts.Days)
+
+	locked := make([][]Combo, len(plan.MenuPlan))
+	lockedPrice := make([]float64, len(plan.MenuPlan))
+	lockedCalories := make([]int, len(plan.MenuPlan))
+	remainingBudget := opts.MaxTotalPrice
+	remainingCalories := opts.MaxTotalCalories
+	for d, day := range plan.MenuPlan {
+		for _, combo := range day.Combos {
+			// New combos are numbered after every existing one, so no ID is reused.
+			g.comboCounter = max(g.comboCounter, comboNumber(combo.ComboID))
+		}
+		locked[d] = lockedCombos(day, locks)
+		for _, combo := range locked[d] {
+			for _, component := range combo.Components {
+				g.itemUses[component.ItemName]++
+			}
+			lockedPrice[d] += combo.Price
+			lockedCalories[d] += combo.CalorieCount
+			remainingBudget -= combo.Price
+			remainingCalories -= combo.CalorieCount
+		}
+	}
+
+	days := make([]DailyMenu, len(plan.MenuPlan))
+	for d := range plan.MenuPlan {
+		// Locked combos of later days count as used at the same distance
+		// before this day, so the repetition window holds in both directions.
+		for later := d + 1; later < len(locked); later++ {
+			for _, combo := range locked[later] {
+				mirrored := d - (later - d)
+				if previous, ok := g.comboSignatures[combo.signature()]; !ok || mirrored > previous {
+					g.comboSignatures[combo.signature()] = mirrored
+				}
+			}
+		}
+
+		daysLeft := len(plan.MenuPlan) - d
+		priceBudget, calorieBudget := 0.0, 0
+		if opts.MaxTotalPrice > 0 {
+			priceBudget = max(0, remainingBudget/float64(daysLeft)) + lockedPrice[d]
+		}
+		if opts.MaxTotalCalories > 0 {
+			calorieBudget = max(0, remainingCalories/daysLeft) + lockedCalories[d]
+		}
+		days[d] = g.generateDay(d, priceBudget, calorieBudget, locked[d])
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		days[d].Day, days[d].Date = plan.MenuPlan[d].Day, plan.MenuPlan[d].Date
+		days[d].Seed = &seed
+		remainingBudget -= days[d].TotalPrice - lockedPrice[d]
+		remainingCalories -= days[d].TotalCalories - lockedCalories[d]
+	}
+	plan.MenuPlan = days
+	plan.Warnings = g.warnings
+	plan.updateTotals(masterMenu)
+	return nil
+}
+
+// FindCombo returns the day and position within the day of the combo with the given ID.
+func FindCombo(plan MenuPlan, comboID string) (int, int, error) {
+	for d, day := range plan.MenuPlan {
+		for c, combo := range day.Combos {
+			if combo.ComboID == comboID {
+				return d, c, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("%w: %q", ErrComboNotFound, comboID)
+}
+
+// SwapCombo replaces one combo of plan with a different valid combo for the
+// same meal, keeping its combo ID. The new combo shares no item with the
+// other combos of its day and respects the repetition window, item use
+// limits and budgets against the rest of the plan.
+func SwapCombo(ctx context.Context, plan *MenuPlan, opts GenerationOptions, dayIndex, comboIndex int, masterMenu []menu.Item, index *ComboIndex, seed int64) error {
+	old := plan.MenuPlan[dayIndex].Combos[comboIndex]
+	opts.excludedCombos = map[string]bool{old.signature(): true}
+	g := newPlanGenerator(ctx, masterMenu, opts, index, rand.New(rand.NewSource(seed)))
+	otherPrice, otherCalories := g.keepDays(*plan, dayIndex)
+
+	priceBudget, calorieBudget := 0.0, 0
+	if opts.MaxTotalPrice > 0 {
+		priceBudget = max(0, opts.MaxTotalPrice-otherPrice)
+	}
+	if opts.MaxTotalCalories > 0 {
+		calorieBudget = max(0, opts.MaxTotalCalories-otherCalories)
+	}
+	day := g.day(dayIndex, priceBudget, calorieBudget)
+
+	// The day's other combos stay, so their items are taken.
+	usage := dayUsage{usedItems: make(map[string]bool)}
+	for c, combo := range plan.MenuPlan[dayIndex].Combos {
+		if c == comboIndex {
+			continue
+		}
+		usage.add(combo)
+		for _, component := range combo.Components {
+			g.itemUses[component.ItemName]++
+			g.day1UsedItems[component.ItemName] = true
+		}
+	}
+
+	meal := MealSlot{Name: old.Meal, Combos: 1}
+	for _, slot := range opts.MealSlots {
+		if slot.Name == old.Meal {
+			meal = slot
+			meal.Combos = 1
+		}
+	}
+	combos, _, _ := g.generateMeal(day, meal, usage)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(combos) == 0 {
+		return ErrNoAlternative
+	}
+	combos[0].ComboID = old.ComboID
+	plan.MenuPlan[dayIndex].Combos[comboIndex] = combos[0]
+	plan.MenuPlan[dayIndex].updateTotals()
+	plan.updateTotals(masterMenu)
+	return nil
+}
@@ -0,0 +1,146 @@
+package planner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"task/menu"
+)
+
+// Kinds of declarative rules.
+const (
+	RuleMaxItemsWithTag = "max_items_with_tag"
+	RuleMinItemsWithTag = "min_items_with_tag"
+	RuleAvoidTogether   = "avoid_together"
+)
+
+// RuleDef declares a constraint rule in data, so policies such as "at most
+// one fried item per combo" can be changed without changing code:
+//
+//	{"rule": "max_items_with_tag", "tag": "fried", "max": 1}
+//	{"rule": "min_items_with_tag", "tag": "vegan", "min": 2, "days": ["Monday"]}
+//	{"rule": "avoid_together", "items": ["Chole Bhature", "Masala Fries"]}
+type RuleDef struct {
+	// Rule is the kind of rule: max_items_with_tag, min_items_with_tag or
+	// avoid_together.
+	Rule string `json:"rule" yaml:"rule"`
+	// Tag is the dietary tag counted by the tag rules, ignoring case.
+	Tag string `json:"tag,omitempty" yaml:"tag"`
+	// Max and Min bound how many items of a combo carry Tag.
+	Max int `json:"max,omitempty" yaml:"max"`
+	Min int `json:"min,omitempty" yaml:"min"`
+	// Items are the items avoid_together keeps out of the same combo.
+	Items []string `json:"items,omitempty" yaml:"items"`
+	// Days and Meals limit the rule to the named weekdays and meals; empty
+	// applies it everywhere.
+	Days  []string `json:"days,omitempty" yaml:"days"`
+	Meals []string `json:"meals,omitempty" yaml:"meals"`
+}
+
+// ParseRuleDefs reads a JSON array of rule definitions, such as the contents
+// of a rules file, and checks that each can be compiled.
+func ParseRuleDefs(data []byte) ([]RuleDef, error) {
+	var defs []RuleDef
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&defs); err != nil {
+		return nil, err
+	}
+	if err := validateRuleDefs(defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// validateRuleDefs checks that every rule definition can be compiled.
+func validateRuleDefs(defs []RuleDef) error {
+	for i, def := range defs {
+		if _, err := def.compile(); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// compile turns def into the rule it declares.
+func (def RuleDef) compile() (ConstraintRule, error) {
+	for _, day := range def.Days {
+		if !menu.IsDayName(day) {
+			return nil, fmt.Errorf("unknown day %q", day)
+		}
+	}
+	var check func(combo []menu.Item) bool
+	switch def.Rule {
+	case RuleMaxItemsWithTag, RuleMinItemsWithTag:
+		if strings.TrimSpace(def.Tag) == "" {
+			return nil, fmt.Errorf("%s needs a tag", def.Rule)
+		}
+		if def.Max < 0 || def.Min < 0 {
+			return nil, fmt.Errorf("%s: max and min must not be negative", def.Rule)
+		}
+		tag := []string{def.Tag}
+		countTagged := func(combo []menu.Item) int {
+			n := 0
+			for _, item := range combo {
+				if menu.HasAllTags(item, tag) {
+					n++
+				}
+			}
+			return n
+		}
+		if def.Rule == RuleMaxItemsWithTag {
+			check = func(combo []menu.Item) bool { return countTagged(combo) <= def.Max }
+		} else {
+			check = func(combo []menu.Item) bool { return countTagged(combo) >= def.Min }
+		}
+	case RuleAvoidTogether:
+		if len(def.Items) < 2 {
+			return nil, fmt.Errorf("%s needs at least two items", def.Rule)
+		}
+		check = func(combo []menu.Item) bool {
+			n := 0
+			for _, item := range combo {
+				if menu.ContainsFold(def.Items, item.ItemName) {
+					n++
+				}
+			}
+			return n < 2
+		}
+	case "":
+		return nil, fmt.Errorf("rule must name the kind of rule (%s, %s or %s)", RuleMaxItemsWithTag, RuleMinItemsWithTag, RuleAvoidTogether)
+	default:
+		return nil, fmt.Errorf("unknown rule %q (expected %s, %s or %s)", def.Rule, RuleMaxItemsWithTag, RuleMinItemsWithTag, RuleAvoidTogether)
+	}
+	broken := fmt.Errorf("the combo breaks the %s rule", def.Rule)
+	return RuleFunc(func(combo []menu.Item, day DayContext) error {
+		if len(def.Days) > 0 && !menu.ContainsFold(def.Days, day.Name) {
+			return nil
+		}
+		if len(def.Meals) > 0 && !menu.ContainsFold(def.Meals, day.Meal) {
+			return nil
+		}
+		if !check(combo) {
+			return broken
+		}
+		return nil
+	}), nil
+}
+
+// constraintRules returns opts.Rules followed by the rules compiled from
+// opts.RuleDefs. Definitions that do not compile are left out; Validate
+// reports them.
+func (opts GenerationOptions) constraintRules() []ConstraintRule {
+	if len(opts.RuleDefs) == 0 {
+		return opts.Rules
+	}
+	rules := make([]ConstraintRule, 0, len(opts.Rules)+len(opts.RuleDefs))
+	rules = append(rules, opts.Rules...)
+	for _, def := range opts.RuleDefs {
+		if rule, err := def.compile(); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
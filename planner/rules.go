@@ -0,0 +1,130 @@
+package planner
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"task/menu"
+)
+
+// ConstraintRule is a constraint every combo of a plan must meet. The
+// calorie window, the popularity tolerance and the repetition window are
+// rules; callers add their own, such as "no two fried items", with
+// GenerationOptions.Rules instead of changing the generator.
+type ConstraintRule interface {
+	// Validate returns an error saying why combo may not be served in the
+	// slot described by day, or nil when it may.
+	Validate(combo []menu.Item, day DayContext) error
+}
+
+// RuleFunc adapts an ordinary function to a ConstraintRule.
+type RuleFunc func(combo []menu.Item, day DayContext) error
+
+// Validate returns f(combo, day).
+func (f RuleFunc) Validate(combo []menu.Item, day DayContext) error {
+	return f(combo, day)
+}
+
+// DayContext describes the slot a combo is checked for.
+type DayContext struct {
+	// Index is the 0-based position of the day in the plan; Name is its
+	// weekday and Date its calendar date.
+	Index int
+	Name  string
+	Date  time.Time
+	// Meal names the meal being filled when meal slots are used.
+	Meal string
+	// Options are the generation settings of the meal, such as its
+	// calorie window.
+	Options GenerationOptions
+	// lastServed maps the signature of every combo served so far to the
+	// index of the day it was last served on.
+	lastServed map[string]int
+}
+
+// LastServed returns the index of the day combo was last served on, or
+// false when the plan has not served it yet.
+func (d DayContext) LastServed(combo []menu.Item) (int, bool) {
+	day, ok := d.lastServed[comboSignature(combo...)]
+	return day, ok
+}
+
+// Errors of the built-in rules.
+var (
+	errCalorieWindow    = errors.New("the combo's calories are outside the calorie window")
+	errPopularitySpread = errors.New("the popularity of the combo's items differs by more than the popularity tolerance")
+	errRepeated         = errors.New("the combo was served within the repeat window")
+)
+
+// planRules depend on the combos served so far and are checked for every slot.
+var planRules = []ConstraintRule{repetitionWindow{}}
+
+// comboRules only depend on the combo and the options, so they are checked
+// once per combo when the candidates are precomputed, not for every slot.
+// The combo index applies them by calories and popularity spread as well.
+var comboRules = []ConstraintRule{calorieWindow{}, popularitySpread{}}
+
+// calorieWindow requires a combo's calories to lie within the calorie
+// window of its day.
+type calorieWindow struct{}
+
+func (calorieWindow) Validate(combo []menu.Item, day DayContext) error {
+	calories, _ := menu.ComboMetrics(combo...)
+	if calories < day.Options.MinCalories || calories > day.Options.MaxCalories {
+		return errCalorieWindow
+	}
+	return nil
+}
+
+// popularitySpread requires the popularity scores of a combo's items to
+// lie within the popularity tolerance of each other.
+type popularitySpread struct{}
+
+func (popularitySpread) Validate(combo []menu.Item, day DayContext) error {
+	scores := make([]float64, len(combo))
+	for i, item := range combo {
+		scores[i] = item.PopularityScore
+	}
+	sort.Float64s(scores)
+	if len(scores) > 1 && scores[len(scores)-1]-scores[0] > day.Options.PopularityTolerance {
+		return errPopularitySpread
+	}
+	return nil
+}
+
+// repetitionWindow keeps a combo from being served again within
+// RepeatWindow days.
+type repetitionWindow struct{}
+
+func (repetitionWindow) Validate(combo []menu.Item, day DayContext) error {
+	if last, ok := day.LastServed(combo); ok && day.Index-last < day.Options.RepeatWindow {
+		return errRepeated
+	}
+	return nil
+}
+
+// checkRules returns the reason the first of rules that combo breaks is
+// tallied under, or notRejected when it breaks none.
+func checkRules(rules []ConstraintRule, combo []menu.Item, day DayContext) rejectReason {
+	for _, rule := range rules {
+		if err := rule.Validate(combo, day); err != nil {
+			return ruleRejection(err)
+		}
+	}
+	return notRejected
+}
+
+// ruleRejection returns the rejection reason of an error of a rule.
+func ruleRejection(err error) rejectReason {
+	switch {
+	case errors.Is(err, errCalorieWindow):
+		return rejectCalories
+	case errors.Is(err, errPopularitySpread):
+		return rejectPopularity
+	case errors.Is(err, errRepeated):
+		return rejectRepetition
+	default:
+		return rejectRule
+	}
+}
@@ -0,0 +1,85 @@
+package planner
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"task/menu"
+)
+
+// ScoreWeights configures how combos are scored for ranking. Each component
+// is scored between 0 and 1 and the weighted average is the combo's score:
+// popularity is the average popularity of the items, diversity the share of
+// distinct taste profiles and cost how cheap the combo is compared with the
+// most expensive combo the day's menu allows.
+type ScoreWeights struct {
+	Popularity float64 `json:"popularity" yaml:"popularity"`
+	Diversity  float64 `json:"diversity" yaml:"diversity"`
+	Cost       float64 `json:"cost" yaml:"cost"`
+}
+
+// DefaultScoreWeights are the weights used when no others are configured.
+var DefaultScoreWeights = ScoreWeights{Popularity: 0.6, Diversity: 0.2, Cost: 0.2}
+
+// validate reports whether the weights can produce a score.
+func (w ScoreWeights) validate() error {
+	if w.Popularity < 0 || w.Diversity < 0 || w.Cost < 0 {
+		return errors.New("score weights must not be negative")
+	}
+	if w.Popularity+w.Diversity+w.Cost <= 0 {
+		return errors.New("at least one score weight must be positive")
+	}
+	return nil
+}
+
+// comboScore scores a set of items between 0 and 1. priceCeiling is the price
+// that earns a cost score of zero.
+func comboScore(items []menu.Item, priceCeiling float64, weights ScoreWeights) float64 {
+	if len(items) == 0 {
+		return 0
+	}
+	popularity := 0.0
+	profiles := make(map[string]bool)
+	for _, item := range items {
+		popularity += item.PopularityScore
+		profiles[item.TasteProfile] = true
+	}
+	popularityScore := popularity / float64(len(items))
+
+	diversityScore := 1.0
+	if len(items) > 1 {
+		diversityScore = float64(len(profiles)-1) / float64(len(items)-1)
+	}
+
+	costScore := 1.0
+	if priceCeiling > 0 {
+		costScore = clamp01(1 - menu.Price(items...)/priceCeiling)
+	}
+
+	totalWeight := weights.Popularity + weights.Diversity + weights.Cost
+	if totalWeight <= 0 {
+		return 0
+	}
+	score := (popularityScore*weights.Popularity +
+		diversityScore*weights.Diversity +
+		costScore*weights.Cost) / totalWeight
+	return math.Round(score*1000) / 1000
+}
+
+// maxItemPrice returns the highest price among items.
+func maxItemPrice(items []menu.Item) float64 {
+	highest := 0.0
+	for _, item := range items {
+		highest = math.Max(highest, item.Price)
+	}
+	return highest
+}
+
+// rankCombos orders combos from the highest score to the lowest, keeping the
+// generation order among equal scores.
+func rankCombos(combos []Combo) {
+	sort.SliceStable(combos, func(i, j int) bool {
+		return combos[i].Score > combos[j].Score
+	})
+}
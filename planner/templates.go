@@ -0,0 +1,253 @@
+package planner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"task/menu"
+)
+
+// maxTemplateComponents limits how many items a combo template may hold.
+const maxTemplateComponents = 6
+
+// ComboTemplate lists the roles a combo is made of, one entry per item,
+// e.g. main, side, side, drink. A role is a category, marked optional by a
+// trailing "?": an optional role is filled only when an item fits, as in
+// main+side+drink+dessert?.
+type ComboTemplate []string
+
+// optionalMarker ends an optional role.
+const optionalMarker = "?"
+
+// standardTemplate is the classic main+side+drink combo.
+var standardTemplate = ComboTemplate{"main", "side", "drink"}
+
+// ParseComboTemplate parses a template such as "main+2 sides+drink". Parts
+// may be separated by "+" or ","; use commas in query strings, where "+"
+// decodes to a space. A count may precede a category, whose plural "s" is
+// then optional, and a trailing "?" makes the part optional, as in
+// "main+side+drink+dessert?".
+func ParseComboTemplate(raw string) (ComboTemplate, error) {
+	var template ComboTemplate
+	parts := strings.FieldsFunc(raw, func(r rune) bool { return r == '+' || r == ',' })
+	for _, part := range parts {
+		part, optional := strings.CutSuffix(strings.TrimSpace(part), optionalMarker)
+		fields := strings.Fields(strings.ToLower(part))
+		count := 1
+		switch len(fields) {
+		case 1:
+		case 2:
+			n, err := strconv.Atoi(fields[0])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid count in %q", strings.TrimSpace(part))
+			}
+			count = n
+			fields = fields[1:]
+			if count > 1 {
+				fields[0] = strings.TrimSuffix(fields[0], "s")
+			}
+		default:
+			return nil, fmt.Errorf("invalid template part %q", strings.TrimSpace(part))
+		}
+		role := fields[0]
+		if optional {
+			role += optionalMarker
+		}
+		for i := 0; i < count; i++ {
+			template = append(template, role)
+		}
+	}
+	if len(template) == 0 {
+		return nil, fmt.Errorf("template %q has no components", raw)
+	}
+	if len(template.required()) == 0 {
+		return nil, fmt.Errorf("template %q has no required components", raw)
+	}
+	if len(template) > maxTemplateComponents {
+		return nil, fmt.Errorf("template %q has more than %d components", raw, maxTemplateComponents)
+	}
+	return template, nil
+}
+
+// UnmarshalText parses a template from config files and JSON request bodies.
+func (t *ComboTemplate) UnmarshalText(text []byte) error {
+	parsed, err := ParseComboTemplate(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalText formats the template as text.
+func (t ComboTemplate) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// String formats the template in the form ParseComboTemplate accepts.
+func (t ComboTemplate) String() string {
+	var parts []string
+	for i := 0; i < len(t); {
+		j := i
+		for j < len(t) && t[j] == t[i] {
+			j++
+		}
+		if j-i == 1 {
+			parts = append(parts, t[i])
+		} else {
+			category, optional := strings.CutSuffix(t[i], optionalMarker)
+			part := fmt.Sprintf("%d %ss", j-i, category)
+			if optional {
+				part += optionalMarker
+			}
+			parts = append(parts, part)
+		}
+		i = j
+	}
+	return strings.Join(parts, "+")
+}
+
+// isStandard reports whether t is the main+side+drink template, which the
+// ComboIndex serves. An empty template also means the standard one.
+func (t ComboTemplate) isStandard() bool {
+	if len(t) == 0 {
+		return true
+	}
+	if len(t) != len(standardTemplate) {
+		return false
+	}
+	for i := range t {
+		if t[i] != standardTemplate[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// orDefault returns the categories of t with every optional role filled, or
+// the standard template when t is empty.
+func (t ComboTemplate) orDefault() ComboTemplate {
+	if len(t) == 0 {
+		return standardTemplate
+	}
+	if !t.hasOptional() {
+		return t
+	}
+	filled := make(ComboTemplate, len(t))
+	for i, role := range t {
+		filled[i] = strings.TrimSuffix(role, optionalMarker)
+	}
+	return filled
+}
+
+// hasOptional reports whether t has optional roles.
+func (t ComboTemplate) hasOptional() bool {
+	for _, role := range t {
+		if strings.HasSuffix(role, optionalMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// required returns t without its optional roles.
+func (t ComboTemplate) required() ComboTemplate {
+	if !t.hasOptional() {
+		return t
+	}
+	var required ComboTemplate
+	for _, role := range t {
+		if !strings.HasSuffix(role, optionalMarker) {
+			required = append(required, role)
+		}
+	}
+	return required
+}
+
+// ComboComponent is one item of a combo together with the template category it fills.
+type ComboComponent struct {
+	Category string `json:"category"`
+	ItemName string `json:"item_name"`
+	// Portion is the size served when it is not the item's regular portion.
+	Portion string `json:"portion,omitempty"`
+}
+
+// templateCandidates returns every combo for the template of opts that passes
+// isValidCombo, resizing portions where needed, using the index for the
+// standard template. Combos filling two positions with the same item, which
+// items of several categories allow, are left out.
+func templateCandidates(index *ComboIndex, categorized map[string][]menu.Item, opts GenerationOptions) []comboCandidate {
+	template := opts.Template.orDefault()
+	if template.isStandard() {
+		return index.validCombos(opts)
+	}
+	var candidates []comboCandidate
+	items := make([]menu.Item, len(template))
+	chosen := make([]int, len(template))
+	// fill chooses an item for position pos. A repeated category takes items
+	// after the one chosen for its previous position, so each set of items
+	// is enumerated once.
+	var fill func(pos int)
+	fill = func(pos int) {
+		if pos == len(template) {
+			if hasDuplicateItems(items) {
+				return
+			}
+			if fitted, ok := fitPortions(items, opts); ok {
+				combo := append([]menu.Item(nil), fitted...)
+				candidates = append(candidates, comboCandidate{Items: combo, Signature: comboSignature(combo...)})
+			}
+			return
+		}
+		start := 0
+		for p := pos - 1; p >= 0; p-- {
+			if template[p] == template[pos] {
+				start = chosen[p] + 1
+				break
+			}
+		}
+		options := categorized[template[pos]]
+		for i := start; i < len(options); i++ {
+			items[pos], chosen[pos] = options[i], i
+			fill(pos + 1)
+		}
+	}
+	fill(0)
+	return candidates
+}
+
+// hasDuplicateItems reports whether any item appears more than once in items.
+// Combos are small, so comparing every pair beats allocating a set.
+func hasDuplicateItems(items []menu.Item) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if items[i].ItemName == items[j].ItemName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasTemplateItems reports whether categorized holds enough items to fill template.
+func hasTemplateItems(categorized map[string][]menu.Item, template ComboTemplate) bool {
+	return len(missingTemplateCategories(categorized, template)) == 0
+}
+
+// missingTemplateCategories returns the categories of template that
+// categorized holds too few items of, in template order.
+func missingTemplateCategories(categorized map[string][]menu.Item, template ComboTemplate) []string {
+	needed := make(map[string]int)
+	for _, category := range template {
+		needed[category]++
+	}
+	var missing []string
+	for _, category := range template {
+		if count, ok := needed[category]; ok && len(categorized[category]) < count {
+			missing = append(missing, category)
+		}
+		delete(needed, category)
+	}
+	return missing
+}
@@ -0,0 +1,86 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Reasons a meal is left with fewer combos than asked for, besides the
+// rejection reasons of generation_debug.go.
+const (
+	// shortMissingItems means a template category has too few items once the
+	// day's filters are applied.
+	shortMissingItems = "missing_items"
+	// shortNoCandidates means no combo passes the calorie window,
+	// popularity tolerance and per-combo limits at all.
+	shortNoCandidates = "no_candidates"
+	// shortExhausted means the backtrack strategy found no set of combos for
+	// the day besides those it went back on.
+	shortExhausted = "exhausted"
+)
+
+// PlanWarning describes a meal of the plan that has fewer combos than were
+// asked for, and why. Without meal slots the meal is the whole day.
+type PlanWarning struct {
+	// Day is the 1-based day index.
+	Day       int    `json:"day"`
+	DayName   string `json:"day_name"`
+	Meal      string `json:"meal,omitempty"`
+	Generated int    `json:"generated"`
+	Expected  int    `json:"expected"`
+	// Reason is missing_items, no_candidates or the rejection reason that
+	// turned down most of the remaining candidates, such as uniqueness.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// shortfall explains why generateDailyCombos filled fewer slots than asked
+// for. The zero value means every slot was filled.
+type shortfall struct {
+	reason, detail string
+}
+
+// warning describes the shortfall of a meal of the named day as a plan warning.
+func (s shortfall) warning(dayIndex int, dayName, meal string, generated, expected int) PlanWarning {
+	w := PlanWarning{
+		Day:       dayIndex + 1,
+		DayName:   dayName,
+		Meal:      meal,
+		Generated: generated,
+		Expected:  expected,
+		Reason:    s.reason,
+	}
+	label := w.DayName
+	if meal != "" {
+		label += " " + meal
+	}
+	w.Message = fmt.Sprintf("%s has %d of %d combos: %s", label, generated, expected, s.detail)
+	return w
+}
+
+// slotShortfall explains why a slot could not be filled from what its
+// search rejected.
+func slotShortfall(opts GenerationOptions, candidates int, tally rejectionTally) shortfall {
+	usesCandidates := opts.Strategy != StrategySample || optimizationObjective(opts.Optimize) != nil
+	reason := tally.main()
+	if (usesCandidates && candidates == 0) || reason == notRejected {
+		return shortfall{shortNoCandidates, fmt.Sprintf(
+			"no combo fits the calorie window of %d-%d calories, the popularity tolerance and the per-combo limits",
+			opts.MinCalories, opts.MaxCalories)}
+	}
+	return shortfall{reason.String(), "the remaining candidates were mostly rejected for " + rejectReasonDescriptions[reason]}
+}
+
+// replaceDayWarnings returns warnings without those of day dayIndex, plus
+// added, ordered by day.
+func replaceDayWarnings(warnings []PlanWarning, dayIndex int, added []PlanWarning) []PlanWarning {
+	kept := []PlanWarning{}
+	for _, w := range warnings {
+		if w.Day != dayIndex+1 {
+			kept = append(kept, w)
+		}
+	}
+	kept = append(kept, added...)
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Day < kept[j].Day })
+	return kept
+}
@@ -0,0 +1,1810 @@
+// The gRPC API of the menu planner, for internal service-to-service callers.
+// It shares the planner core with the HTTP API: requests are authenticated
+// with the same API keys or bearer tokens, given in the x-api-key or
+// authorization metadata, and the x-tenant-id metadata selects the tenant.
+//
+// Regenerate the Go code after editing this file:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative plannerpb/planner.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.3
+// source: plannerpb/planner.proto
+
+package plannerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GenerateMenuRequest holds the same settings as the /generate-menu query
+// parameters. Unset fields use the tenant's configured defaults.
+type GenerateMenuRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Days                *int32   `protobuf:"varint,1,opt,name=days,proto3,oneof" json:"days,omitempty"`
+	CombosPerDay        *int32   `protobuf:"varint,2,opt,name=combos_per_day,json=combosPerDay,proto3,oneof" json:"combos_per_day,omitempty"`
+	MinCalories         *int32   `protobuf:"varint,3,opt,name=min_calories,json=minCalories,proto3,oneof" json:"min_calories,omitempty"`
+	MaxCalories         *int32   `protobuf:"varint,4,opt,name=max_calories,json=maxCalories,proto3,oneof" json:"max_calories,omitempty"`
+	MaxTotalCalories    *int32   `protobuf:"varint,5,opt,name=max_total_calories,json=maxTotalCalories,proto3,oneof" json:"max_total_calories,omitempty"`
+	RepeatWindow        *int32   `protobuf:"varint,6,opt,name=repeat_window,json=repeatWindow,proto3,oneof" json:"repeat_window,omitempty"`
+	MaxItemUses         *int32   `protobuf:"varint,7,opt,name=max_item_uses,json=maxItemUses,proto3,oneof" json:"max_item_uses,omitempty"`
+	PopularityTolerance *float64 `protobuf:"fixed64,8,opt,name=popularity_tolerance,json=popularityTolerance,proto3,oneof" json:"popularity_tolerance,omitempty"`
+	MaxComboPrice       *float64 `protobuf:"fixed64,9,opt,name=max_combo_price,json=maxComboPrice,proto3,oneof" json:"max_combo_price,omitempty"`
+	MaxTotalPrice       *float64 `protobuf:"fixed64,10,opt,name=max_total_price,json=maxTotalPrice,proto3,oneof" json:"max_total_price,omitempty"`
+	Seed                *int64   `protobuf:"varint,11,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	// strategy is "enumerate", "sample", "anneal" or "backtrack".
+	Strategy string `protobuf:"bytes,12,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// template is a combo template such as "main+2 sides+drink".
+	Template string `protobuf:"bytes,13,opt,name=template,proto3" json:"template,omitempty"`
+	// optimize is "popularity" or "cost" to replace random selection.
+	Optimize         string   `protobuf:"bytes,14,opt,name=optimize,proto3" json:"optimize,omitempty"`
+	DietaryTags      []string `protobuf:"bytes,15,rep,name=dietary_tags,json=dietaryTags,proto3" json:"dietary_tags,omitempty"`
+	ExcludeAllergens []string `protobuf:"bytes,16,rep,name=exclude_allergens,json=excludeAllergens,proto3" json:"exclude_allergens,omitempty"`
+	ExcludeItems     []string `protobuf:"bytes,17,rep,name=exclude_items,json=excludeItems,proto3" json:"exclude_items,omitempty"`
+	// taste_preferences weights taste profiles, e.g. {"spicy": 2}.
+	TastePreferences map[string]float64 `protobuf:"bytes,18,rep,name=taste_preferences,json=tastePreferences,proto3" json:"taste_preferences,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	// profile names a stored preference profile to apply.
+	Profile string `protobuf:"bytes,19,opt,name=profile,proto3" json:"profile,omitempty"`
+}
+
+func (x *GenerateMenuRequest) Reset() {
+	*x = GenerateMenuRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateMenuRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateMenuRequest) ProtoMessage() {}
+
+func (x *GenerateMenuRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateMenuRequest.ProtoReflect.Descriptor instead.
+func (*GenerateMenuRequest) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenerateMenuRequest) GetDays() int32 {
+	if x != nil && x.Days != nil {
+		return *x.Days
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetCombosPerDay() int32 {
+	if x != nil && x.CombosPerDay != nil {
+		return *x.CombosPerDay
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMinCalories() int32 {
+	if x != nil && x.MinCalories != nil {
+		return *x.MinCalories
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMaxCalories() int32 {
+	if x != nil && x.MaxCalories != nil {
+		return *x.MaxCalories
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMaxTotalCalories() int32 {
+	if x != nil && x.MaxTotalCalories != nil {
+		return *x.MaxTotalCalories
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetRepeatWindow() int32 {
+	if x != nil && x.RepeatWindow != nil {
+		return *x.RepeatWindow
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMaxItemUses() int32 {
+	if x != nil && x.MaxItemUses != nil {
+		return *x.MaxItemUses
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetPopularityTolerance() float64 {
+	if x != nil && x.PopularityTolerance != nil {
+		return *x.PopularityTolerance
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMaxComboPrice() float64 {
+	if x != nil && x.MaxComboPrice != nil {
+		return *x.MaxComboPrice
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetMaxTotalPrice() float64 {
+	if x != nil && x.MaxTotalPrice != nil {
+		return *x.MaxTotalPrice
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+func (x *GenerateMenuRequest) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *GenerateMenuRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *GenerateMenuRequest) GetOptimize() string {
+	if x != nil {
+		return x.Optimize
+	}
+	return ""
+}
+
+func (x *GenerateMenuRequest) GetDietaryTags() []string {
+	if x != nil {
+		return x.DietaryTags
+	}
+	return nil
+}
+
+func (x *GenerateMenuRequest) GetExcludeAllergens() []string {
+	if x != nil {
+		return x.ExcludeAllergens
+	}
+	return nil
+}
+
+func (x *GenerateMenuRequest) GetExcludeItems() []string {
+	if x != nil {
+		return x.ExcludeItems
+	}
+	return nil
+}
+
+func (x *GenerateMenuRequest) GetTastePreferences() map[string]float64 {
+	if x != nil {
+		return x.TastePreferences
+	}
+	return nil
+}
+
+func (x *GenerateMenuRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+type GetPlanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlanId string `protobuf:"bytes,1,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+}
+
+func (x *GetPlanRequest) Reset() {
+	*x = GetPlanRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPlanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlanRequest) ProtoMessage() {}
+
+func (x *GetPlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlanRequest.ProtoReflect.Descriptor instead.
+func (*GetPlanRequest) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetPlanRequest) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+type ListMenuItemsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListMenuItemsRequest) Reset() {
+	*x = ListMenuItemsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListMenuItemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMenuItemsRequest) ProtoMessage() {}
+
+func (x *ListMenuItemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMenuItemsRequest.ProtoReflect.Descriptor instead.
+func (*ListMenuItemsRequest) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{2}
+}
+
+type ListMenuItemsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*MenuItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *ListMenuItemsResponse) Reset() {
+	*x = ListMenuItemsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListMenuItemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMenuItemsResponse) ProtoMessage() {}
+
+func (x *ListMenuItemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMenuItemsResponse.ProtoReflect.Descriptor instead.
+func (*ListMenuItemsResponse) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListMenuItemsResponse) GetItems() []*MenuItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type Portion struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size     string  `protobuf:"bytes,1,opt,name=size,proto3" json:"size,omitempty"`
+	Calories int32   `protobuf:"varint,2,opt,name=calories,proto3" json:"calories,omitempty"`
+	Price    float64 `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+}
+
+func (x *Portion) Reset() {
+	*x = Portion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Portion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Portion) ProtoMessage() {}
+
+func (x *Portion) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Portion.ProtoReflect.Descriptor instead.
+func (*Portion) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Portion) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *Portion) GetCalories() int32 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *Portion) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type MenuItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ItemName        string     `protobuf:"bytes,1,opt,name=item_name,json=itemName,proto3" json:"item_name,omitempty"`
+	Category        string     `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Calories        int32      `protobuf:"varint,3,opt,name=calories,proto3" json:"calories,omitempty"`
+	TasteProfile    string     `protobuf:"bytes,4,opt,name=taste_profile,json=tasteProfile,proto3" json:"taste_profile,omitempty"`
+	PopularityScore float64    `protobuf:"fixed64,5,opt,name=popularity_score,json=popularityScore,proto3" json:"popularity_score,omitempty"`
+	ProteinG        float64    `protobuf:"fixed64,6,opt,name=protein_g,json=proteinG,proto3" json:"protein_g,omitempty"`
+	SodiumMg        float64    `protobuf:"fixed64,7,opt,name=sodium_mg,json=sodiumMg,proto3" json:"sodium_mg,omitempty"`
+	SugarG          float64    `protobuf:"fixed64,8,opt,name=sugar_g,json=sugarG,proto3" json:"sugar_g,omitempty"`
+	CarbsG          float64    `protobuf:"fixed64,9,opt,name=carbs_g,json=carbsG,proto3" json:"carbs_g,omitempty"`
+	FatG            float64    `protobuf:"fixed64,10,opt,name=fat_g,json=fatG,proto3" json:"fat_g,omitempty"`
+	Price           float64    `protobuf:"fixed64,11,opt,name=price,proto3" json:"price,omitempty"`
+	Meals           []string   `protobuf:"bytes,12,rep,name=meals,proto3" json:"meals,omitempty"`
+	DietaryTags     []string   `protobuf:"bytes,13,rep,name=dietary_tags,json=dietaryTags,proto3" json:"dietary_tags,omitempty"`
+	Allergens       []string   `protobuf:"bytes,14,rep,name=allergens,proto3" json:"allergens,omitempty"`
+	Portions        []*Portion `protobuf:"bytes,15,rep,name=portions,proto3" json:"portions,omitempty"`
+}
+
+func (x *MenuItem) Reset() {
+	*x = MenuItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MenuItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MenuItem) ProtoMessage() {}
+
+func (x *MenuItem) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MenuItem.ProtoReflect.Descriptor instead.
+func (*MenuItem) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MenuItem) GetItemName() string {
+	if x != nil {
+		return x.ItemName
+	}
+	return ""
+}
+
+func (x *MenuItem) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *MenuItem) GetCalories() int32 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *MenuItem) GetTasteProfile() string {
+	if x != nil {
+		return x.TasteProfile
+	}
+	return ""
+}
+
+func (x *MenuItem) GetPopularityScore() float64 {
+	if x != nil {
+		return x.PopularityScore
+	}
+	return 0
+}
+
+func (x *MenuItem) GetProteinG() float64 {
+	if x != nil {
+		return x.ProteinG
+	}
+	return 0
+}
+
+func (x *MenuItem) GetSodiumMg() float64 {
+	if x != nil {
+		return x.SodiumMg
+	}
+	return 0
+}
+
+func (x *MenuItem) GetSugarG() float64 {
+	if x != nil {
+		return x.SugarG
+	}
+	return 0
+}
+
+func (x *MenuItem) GetCarbsG() float64 {
+	if x != nil {
+		return x.CarbsG
+	}
+	return 0
+}
+
+func (x *MenuItem) GetFatG() float64 {
+	if x != nil {
+		return x.FatG
+	}
+	return 0
+}
+
+func (x *MenuItem) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *MenuItem) GetMeals() []string {
+	if x != nil {
+		return x.Meals
+	}
+	return nil
+}
+
+func (x *MenuItem) GetDietaryTags() []string {
+	if x != nil {
+		return x.DietaryTags
+	}
+	return nil
+}
+
+func (x *MenuItem) GetAllergens() []string {
+	if x != nil {
+		return x.Allergens
+	}
+	return nil
+}
+
+func (x *MenuItem) GetPortions() []*Portion {
+	if x != nil {
+		return x.Portions
+	}
+	return nil
+}
+
+type Macros struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProteinG float64 `protobuf:"fixed64,1,opt,name=protein_g,json=proteinG,proto3" json:"protein_g,omitempty"`
+	CarbsG   float64 `protobuf:"fixed64,2,opt,name=carbs_g,json=carbsG,proto3" json:"carbs_g,omitempty"`
+	FatG     float64 `protobuf:"fixed64,3,opt,name=fat_g,json=fatG,proto3" json:"fat_g,omitempty"`
+}
+
+func (x *Macros) Reset() {
+	*x = Macros{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Macros) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Macros) ProtoMessage() {}
+
+func (x *Macros) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Macros.ProtoReflect.Descriptor instead.
+func (*Macros) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Macros) GetProteinG() float64 {
+	if x != nil {
+		return x.ProteinG
+	}
+	return 0
+}
+
+func (x *Macros) GetCarbsG() float64 {
+	if x != nil {
+		return x.CarbsG
+	}
+	return 0
+}
+
+func (x *Macros) GetFatG() float64 {
+	if x != nil {
+		return x.FatG
+	}
+	return 0
+}
+
+type CalorieWindow struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MinCalories int32 `protobuf:"varint,1,opt,name=min_calories,json=minCalories,proto3" json:"min_calories,omitempty"`
+	MaxCalories int32 `protobuf:"varint,2,opt,name=max_calories,json=maxCalories,proto3" json:"max_calories,omitempty"`
+}
+
+func (x *CalorieWindow) Reset() {
+	*x = CalorieWindow{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalorieWindow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalorieWindow) ProtoMessage() {}
+
+func (x *CalorieWindow) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalorieWindow.ProtoReflect.Descriptor instead.
+func (*CalorieWindow) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CalorieWindow) GetMinCalories() int32 {
+	if x != nil {
+		return x.MinCalories
+	}
+	return 0
+}
+
+func (x *CalorieWindow) GetMaxCalories() int32 {
+	if x != nil {
+		return x.MaxCalories
+	}
+	return 0
+}
+
+type DiversityStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DistinctItems int32   `protobuf:"varint,1,opt,name=distinct_items,json=distinctItems,proto3" json:"distinct_items,omitempty"`
+	TotalSlots    int32   `protobuf:"varint,2,opt,name=total_slots,json=totalSlots,proto3" json:"total_slots,omitempty"`
+	ItemVariety   float64 `protobuf:"fixed64,3,opt,name=item_variety,json=itemVariety,proto3" json:"item_variety,omitempty"`
+	TasteEntropy  float64 `protobuf:"fixed64,4,opt,name=taste_entropy,json=tasteEntropy,proto3" json:"taste_entropy,omitempty"`
+}
+
+func (x *DiversityStats) Reset() {
+	*x = DiversityStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DiversityStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiversityStats) ProtoMessage() {}
+
+func (x *DiversityStats) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiversityStats.ProtoReflect.Descriptor instead.
+func (*DiversityStats) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DiversityStats) GetDistinctItems() int32 {
+	if x != nil {
+		return x.DistinctItems
+	}
+	return 0
+}
+
+func (x *DiversityStats) GetTotalSlots() int32 {
+	if x != nil {
+		return x.TotalSlots
+	}
+	return 0
+}
+
+func (x *DiversityStats) GetItemVariety() float64 {
+	if x != nil {
+		return x.ItemVariety
+	}
+	return 0
+}
+
+func (x *DiversityStats) GetTasteEntropy() float64 {
+	if x != nil {
+		return x.TasteEntropy
+	}
+	return 0
+}
+
+type ComboComponent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Category string `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	ItemName string `protobuf:"bytes,2,opt,name=item_name,json=itemName,proto3" json:"item_name,omitempty"`
+	Portion  string `protobuf:"bytes,3,opt,name=portion,proto3" json:"portion,omitempty"`
+}
+
+func (x *ComboComponent) Reset() {
+	*x = ComboComponent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComboComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComboComponent) ProtoMessage() {}
+
+func (x *ComboComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComboComponent.ProtoReflect.Descriptor instead.
+func (*ComboComponent) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ComboComponent) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ComboComponent) GetItemName() string {
+	if x != nil {
+		return x.ItemName
+	}
+	return ""
+}
+
+func (x *ComboComponent) GetPortion() string {
+	if x != nil {
+		return x.Portion
+	}
+	return ""
+}
+
+type Combo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ComboId         string            `protobuf:"bytes,1,opt,name=combo_id,json=comboId,proto3" json:"combo_id,omitempty"`
+	Components      []*ComboComponent `protobuf:"bytes,2,rep,name=components,proto3" json:"components,omitempty"`
+	CalorieCount    int32             `protobuf:"varint,3,opt,name=calorie_count,json=calorieCount,proto3" json:"calorie_count,omitempty"`
+	PopularityScore float64           `protobuf:"fixed64,4,opt,name=popularity_score,json=popularityScore,proto3" json:"popularity_score,omitempty"`
+	Reasoning       string            `protobuf:"bytes,5,opt,name=reasoning,proto3" json:"reasoning,omitempty"`
+	HealthGrade     string            `protobuf:"bytes,6,opt,name=health_grade,json=healthGrade,proto3" json:"health_grade,omitempty"`
+	Macros          *Macros           `protobuf:"bytes,7,opt,name=macros,proto3" json:"macros,omitempty"`
+	Price           float64           `protobuf:"fixed64,8,opt,name=price,proto3" json:"price,omitempty"`
+	Meal            string            `protobuf:"bytes,9,opt,name=meal,proto3" json:"meal,omitempty"`
+	Score           float64           `protobuf:"fixed64,10,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *Combo) Reset() {
+	*x = Combo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Combo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Combo) ProtoMessage() {}
+
+func (x *Combo) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Combo.ProtoReflect.Descriptor instead.
+func (*Combo) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Combo) GetComboId() string {
+	if x != nil {
+		return x.ComboId
+	}
+	return ""
+}
+
+func (x *Combo) GetComponents() []*ComboComponent {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *Combo) GetCalorieCount() int32 {
+	if x != nil {
+		return x.CalorieCount
+	}
+	return 0
+}
+
+func (x *Combo) GetPopularityScore() float64 {
+	if x != nil {
+		return x.PopularityScore
+	}
+	return 0
+}
+
+func (x *Combo) GetReasoning() string {
+	if x != nil {
+		return x.Reasoning
+	}
+	return ""
+}
+
+func (x *Combo) GetHealthGrade() string {
+	if x != nil {
+		return x.HealthGrade
+	}
+	return ""
+}
+
+func (x *Combo) GetMacros() *Macros {
+	if x != nil {
+		return x.Macros
+	}
+	return nil
+}
+
+func (x *Combo) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Combo) GetMeal() string {
+	if x != nil {
+		return x.Meal
+	}
+	return ""
+}
+
+func (x *Combo) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type MealMenu struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name        string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	MinCalories int32    `protobuf:"varint,2,opt,name=min_calories,json=minCalories,proto3" json:"min_calories,omitempty"`
+	MaxCalories int32    `protobuf:"varint,3,opt,name=max_calories,json=maxCalories,proto3" json:"max_calories,omitempty"`
+	ComboIds    []string `protobuf:"bytes,4,rep,name=combo_ids,json=comboIds,proto3" json:"combo_ids,omitempty"`
+}
+
+func (x *MealMenu) Reset() {
+	*x = MealMenu{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MealMenu) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MealMenu) ProtoMessage() {}
+
+func (x *MealMenu) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MealMenu.ProtoReflect.Descriptor instead.
+func (*MealMenu) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MealMenu) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MealMenu) GetMinCalories() int32 {
+	if x != nil {
+		return x.MinCalories
+	}
+	return 0
+}
+
+func (x *MealMenu) GetMaxCalories() int32 {
+	if x != nil {
+		return x.MaxCalories
+	}
+	return 0
+}
+
+func (x *MealMenu) GetComboIds() []string {
+	if x != nil {
+		return x.ComboIds
+	}
+	return nil
+}
+
+type DailyMenu struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Day           string          `protobuf:"bytes,1,opt,name=day,proto3" json:"day,omitempty"`
+	Combos        []*Combo        `protobuf:"bytes,2,rep,name=combos,proto3" json:"combos,omitempty"`
+	Macros        *Macros         `protobuf:"bytes,3,opt,name=macros,proto3" json:"macros,omitempty"`
+	TotalPrice    float64         `protobuf:"fixed64,4,opt,name=total_price,json=totalPrice,proto3" json:"total_price,omitempty"`
+	TotalCalories int32           `protobuf:"varint,5,opt,name=total_calories,json=totalCalories,proto3" json:"total_calories,omitempty"`
+	Diversity     *DiversityStats `protobuf:"bytes,6,opt,name=diversity,proto3" json:"diversity,omitempty"`
+	Meals         []*MealMenu     `protobuf:"bytes,7,rep,name=meals,proto3" json:"meals,omitempty"`
+	// seed is set on days regenerated after the plan was created.
+	Seed *int64 `protobuf:"varint,8,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+}
+
+func (x *DailyMenu) Reset() {
+	*x = DailyMenu{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DailyMenu) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailyMenu) ProtoMessage() {}
+
+func (x *DailyMenu) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailyMenu.ProtoReflect.Descriptor instead.
+func (*DailyMenu) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DailyMenu) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *DailyMenu) GetCombos() []*Combo {
+	if x != nil {
+		return x.Combos
+	}
+	return nil
+}
+
+func (x *DailyMenu) GetMacros() *Macros {
+	if x != nil {
+		return x.Macros
+	}
+	return nil
+}
+
+func (x *DailyMenu) GetTotalPrice() float64 {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return 0
+}
+
+func (x *DailyMenu) GetTotalCalories() int32 {
+	if x != nil {
+		return x.TotalCalories
+	}
+	return 0
+}
+
+func (x *DailyMenu) GetDiversity() *DiversityStats {
+	if x != nil {
+		return x.Diversity
+	}
+	return nil
+}
+
+func (x *DailyMenu) GetMeals() []*MealMenu {
+	if x != nil {
+		return x.Meals
+	}
+	return nil
+}
+
+func (x *DailyMenu) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+type ExcludedItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ItemName  string   `protobuf:"bytes,1,opt,name=item_name,json=itemName,proto3" json:"item_name,omitempty"`
+	Allergens []string `protobuf:"bytes,2,rep,name=allergens,proto3" json:"allergens,omitempty"`
+}
+
+func (x *ExcludedItem) Reset() {
+	*x = ExcludedItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExcludedItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExcludedItem) ProtoMessage() {}
+
+func (x *ExcludedItem) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExcludedItem.ProtoReflect.Descriptor instead.
+func (*ExcludedItem) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExcludedItem) GetItemName() string {
+	if x != nil {
+		return x.ItemName
+	}
+	return ""
+}
+
+func (x *ExcludedItem) GetAllergens() []string {
+	if x != nil {
+		return x.Allergens
+	}
+	return nil
+}
+
+type MenuPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlanId        string                 `protobuf:"bytes,1,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Seed          int64                  `protobuf:"varint,3,opt,name=seed,proto3" json:"seed,omitempty"`
+	CalorieWindow *CalorieWindow         `protobuf:"bytes,4,opt,name=calorie_window,json=calorieWindow,proto3" json:"calorie_window,omitempty"`
+	MenuPlan      []*DailyMenu           `protobuf:"bytes,5,rep,name=menu_plan,json=menuPlan,proto3" json:"menu_plan,omitempty"`
+	TotalPrice    float64                `protobuf:"fixed64,6,opt,name=total_price,json=totalPrice,proto3" json:"total_price,omitempty"`
+	TotalCalories int32                  `protobuf:"varint,7,opt,name=total_calories,json=totalCalories,proto3" json:"total_calories,omitempty"`
+	Diversity     *DiversityStats        `protobuf:"bytes,8,opt,name=diversity,proto3" json:"diversity,omitempty"`
+	ExcludedItems []*ExcludedItem        `protobuf:"bytes,9,rep,name=excluded_items,json=excludedItems,proto3" json:"excluded_items,omitempty"`
+}
+
+func (x *MenuPlan) Reset() {
+	*x = MenuPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_plannerpb_planner_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MenuPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MenuPlan) ProtoMessage() {}
+
+func (x *MenuPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_plannerpb_planner_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MenuPlan.ProtoReflect.Descriptor instead.
+func (*MenuPlan) Descriptor() ([]byte, []int) {
+	return file_plannerpb_planner_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MenuPlan) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *MenuPlan) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *MenuPlan) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *MenuPlan) GetCalorieWindow() *CalorieWindow {
+	if x != nil {
+		return x.CalorieWindow
+	}
+	return nil
+}
+
+func (x *MenuPlan) GetMenuPlan() []*DailyMenu {
+	if x != nil {
+		return x.MenuPlan
+	}
+	return nil
+}
+
+func (x *MenuPlan) GetTotalPrice() float64 {
+	if x != nil {
+		return x.TotalPrice
+	}
+	return 0
+}
+
+func (x *MenuPlan) GetTotalCalories() int32 {
+	if x != nil {
+		return x.TotalCalories
+	}
+	return 0
+}
+
+func (x *MenuPlan) GetDiversity() *DiversityStats {
+	if x != nil {
+		return x.Diversity
+	}
+	return nil
+}
+
+func (x *MenuPlan) GetExcludedItems() []*ExcludedItem {
+	if x != nil {
+		return x.ExcludedItems
+	}
+	return nil
+}
+
+var File_plannerpb_planner_proto protoreflect.FileDescriptor
+
+var file_plannerpb_planner_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x70, 0x62, 0x2f, 0x70, 0x6c, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x70, 0x6c, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa9, 0x08, 0x0a, 0x13, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x4d, 0x65, 0x6e, 0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17,
+	0x0a, 0x04, 0x64, 0x61, 0x79, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x04,
+	0x64, 0x61, 0x79, 0x73, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x62, 0x6f,
+	0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x64, 0x61, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48,
+	0x01, 0x52, 0x0c, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x73, 0x50, 0x65, 0x72, 0x44, 0x61, 0x79, 0x88,
+	0x01, 0x01, 0x12, 0x26, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x0b, 0x6d, 0x69, 0x6e, 0x43,
+	0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a, 0x0c, 0x6d, 0x61,
+	0x78, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x03, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x88,
+	0x01, 0x01, 0x12, 0x31, 0x0a, 0x12, 0x6d, 0x61, 0x78, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x48, 0x04,
+	0x52, 0x10, 0x6d, 0x61, 0x78, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d, 0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x5f,
+	0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x48, 0x05, 0x52, 0x0c,
+	0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x88, 0x01, 0x01, 0x12,
+	0x27, 0x0a, 0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x69, 0x74, 0x65, 0x6d, 0x5f, 0x75, 0x73, 0x65, 0x73,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x48, 0x06, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x49, 0x74, 0x65,
+	0x6d, 0x55, 0x73, 0x65, 0x73, 0x88, 0x01, 0x01, 0x12, 0x36, 0x0a, 0x14, 0x70, 0x6f, 0x70, 0x75,
+	0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07, 0x52, 0x13, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61,
+	0x72, 0x69, 0x74, 0x79, 0x54, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x2b, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x5f, 0x70, 0x72,
+	0x69, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x48, 0x08, 0x52, 0x0d, 0x6d, 0x61, 0x78,
+	0x43, 0x6f, 0x6d, 0x62, 0x6f, 0x50, 0x72, 0x69, 0x63, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a,
+	0x0f, 0x6d, 0x61, 0x78, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x09, 0x52, 0x0d, 0x6d, 0x61, 0x78, 0x54, 0x6f, 0x74,
+	0x61, 0x6c, 0x50, 0x72, 0x69, 0x63, 0x65, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x73, 0x65,
+	0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x48, 0x0a, 0x52, 0x04, 0x73, 0x65, 0x65, 0x64,
+	0x88, 0x01, 0x01, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12,
+	0x1a, 0x0a, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6f,
+	0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6f,
+	0x70, 0x74, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x65, 0x74, 0x61,
+	0x72, 0x79, 0x5f, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x69, 0x65, 0x74, 0x61, 0x72, 0x79, 0x54, 0x61, 0x67, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x78,
+	0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x67, 0x65, 0x6e, 0x73, 0x18,
+	0x10, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x41, 0x6c,
+	0x6c, 0x65, 0x72, 0x67, 0x65, 0x6e, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x5f, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c,
+	0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x62, 0x0a, 0x11,
+	0x74, 0x61, 0x73, 0x74, 0x65, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
+	0x73, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x6e,
+	0x75, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x54, 0x61, 0x73, 0x74, 0x65, 0x50, 0x72,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10,
+	0x74, 0x61, 0x73, 0x74, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x1a, 0x43, 0x0a, 0x15, 0x54, 0x61,
+	0x73, 0x74, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
+	0x07, 0x0a, 0x05, 0x5f, 0x64, 0x61, 0x79, 0x73, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x63, 0x6f, 0x6d,
+	0x62, 0x6f, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x64, 0x61, 0x79, 0x42, 0x0f, 0x0a, 0x0d, 0x5f,
+	0x6d, 0x69, 0x6e, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x42, 0x0f, 0x0a, 0x0d,
+	0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x42, 0x15, 0x0a,
+	0x13, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x61, 0x6c, 0x6f,
+	0x72, 0x69, 0x65, 0x73, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x5f,
+	0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x69,
+	0x74, 0x65, 0x6d, 0x5f, 0x75, 0x73, 0x65, 0x73, 0x42, 0x17, 0x0a, 0x15, 0x5f, 0x70, 0x6f, 0x70,
+	0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63,
+	0x65, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x5f,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x73, 0x65,
+	0x65, 0x64, 0x22, 0x29, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x6e, 0x49, 0x64, 0x22, 0x16, 0x0a,
+	0x14, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x6e, 0x75, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x43, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x6e,
+	0x75, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a,
+	0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x6e, 0x75, 0x49,
+	0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x4f, 0x0a, 0x07, 0x50, 0x6f,
+	0x72, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x22, 0xce, 0x03, 0x0a, 0x08,
+	0x4d, 0x65, 0x6e, 0x75, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x74, 0x65, 0x6d,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x74, 0x65,
+	0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72,
+	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72,
+	0x79, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x23, 0x0a,
+	0x0d, 0x74, 0x61, 0x73, 0x74, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x74, 0x61, 0x73, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69,
+	0x6c, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79,
+	0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x70, 0x6f,
+	0x70, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1b, 0x0a,
+	0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e, 0x5f, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e, 0x47, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x6f,
+	0x64, 0x69, 0x75, 0x6d, 0x5f, 0x6d, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x73,
+	0x6f, 0x64, 0x69, 0x75, 0x6d, 0x4d, 0x67, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x75, 0x67, 0x61, 0x72,
+	0x5f, 0x67, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x73, 0x75, 0x67, 0x61, 0x72, 0x47,
+	0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x72, 0x62, 0x73, 0x5f, 0x67, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x06, 0x63, 0x61, 0x72, 0x62, 0x73, 0x47, 0x12, 0x13, 0x0a, 0x05, 0x66, 0x61, 0x74,
+	0x5f, 0x67, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x66, 0x61, 0x74, 0x47, 0x12, 0x14,
+	0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x18, 0x0c, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69,
+	0x65, 0x74, 0x61, 0x72, 0x79, 0x5f, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0b, 0x64, 0x69, 0x65, 0x74, 0x61, 0x72, 0x79, 0x54, 0x61, 0x67, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x67, 0x65, 0x6e, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x09, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x67, 0x65, 0x6e, 0x73, 0x12, 0x2f, 0x0a, 0x08, 0x70,
+	0x6f, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x72, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x08, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x53, 0x0a, 0x06,
+	0x4d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x69,
+	0x6e, 0x5f, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x65,
+	0x69, 0x6e, 0x47, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x72, 0x62, 0x73, 0x5f, 0x67, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x63, 0x61, 0x72, 0x62, 0x73, 0x47, 0x12, 0x13, 0x0a, 0x05,
+	0x66, 0x61, 0x74, 0x5f, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x66, 0x61, 0x74,
+	0x47, 0x22, 0x55, 0x0a, 0x0d, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x57, 0x69, 0x6e, 0x64,
+	0x6f, 0x77, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x69, 0x6e, 0x43, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78,
+	0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x22, 0xa0, 0x01, 0x0a, 0x0e, 0x44, 0x69, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x64,
+	0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74, 0x5f, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74, 0x49, 0x74, 0x65,
+	0x6d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x6c, 0x6f, 0x74,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x6c,
+	0x6f, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x74, 0x65, 0x6d, 0x5f, 0x76, 0x61, 0x72, 0x69,
+	0x65, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x69, 0x74, 0x65, 0x6d, 0x56,
+	0x61, 0x72, 0x69, 0x65, 0x74, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x61, 0x73, 0x74, 0x65, 0x5f,
+	0x65, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x74,
+	0x61, 0x73, 0x74, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x22, 0x63, 0x0a, 0x0e, 0x43,
+	0x6f, 0x6d, 0x62, 0x6f, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x74, 0x65,
+	0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x74,
+	0x65, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0xdb, 0x02, 0x0a, 0x05, 0x43, 0x6f, 0x6d, 0x62, 0x6f, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6f,
+	0x6d, 0x62, 0x6f, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6d, 0x62, 0x6f, 0x49, 0x64, 0x12, 0x3a, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
+	0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x6c, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x62, 0x6f, 0x43, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61,
+	0x72, 0x69, 0x74, 0x79, 0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0f, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x53, 0x63, 0x6f, 0x72,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x69, 0x6e, 0x67, 0x12,
+	0x21, 0x0a, 0x0c, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x5f, 0x67, 0x72, 0x61, 0x64, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x47, 0x72, 0x61,
+	0x64, 0x65, 0x12, 0x2a, 0x0a, 0x06, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x4d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x52, 0x06, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6d, 0x65, 0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72,
+	0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x81,
+	0x01, 0x0a, 0x08, 0x4d, 0x65, 0x61, 0x6c, 0x4d, 0x65, 0x6e, 0x75, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x69, 0x6e, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x5f, 0x69,
+	0x64, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x49,
+	0x64, 0x73, 0x22, 0xc4, 0x02, 0x0a, 0x09, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x4d, 0x65, 0x6e, 0x75,
+	0x12, 0x10, 0x0a, 0x03, 0x64, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64,
+	0x61, 0x79, 0x12, 0x29, 0x0a, 0x06, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6f, 0x6d, 0x62, 0x6f, 0x52, 0x06, 0x63, 0x6f, 0x6d, 0x62, 0x6f, 0x73, 0x12, 0x2a, 0x0a,
+	0x06, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e,
+	0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x63, 0x72, 0x6f,
+	0x73, 0x52, 0x06, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x6f, 0x74,
+	0x61, 0x6c, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0d, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65,
+	0x73, 0x12, 0x38, 0x0a, 0x09, 0x64, 0x69, 0x76, 0x65, 0x72, 0x73, 0x69, 0x74, 0x79, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x69, 0x76, 0x65, 0x72, 0x73, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x09, 0x64, 0x69, 0x76, 0x65, 0x72, 0x73, 0x69, 0x74, 0x79, 0x12, 0x2a, 0x0a, 0x05, 0x6d,
+	0x65, 0x61, 0x6c, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x6c, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x61, 0x6c, 0x4d, 0x65, 0x6e, 0x75,
+	0x52, 0x05, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x12, 0x17, 0x0a, 0x04, 0x73, 0x65, 0x65, 0x64, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x04, 0x73, 0x65, 0x65, 0x64, 0x88, 0x01, 0x01,
+	0x42, 0x07, 0x0a, 0x05, 0x5f, 0x73, 0x65, 0x65, 0x64, 0x22, 0x49, 0x0a, 0x0c, 0x45, 0x78, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x74, 0x65,
+	0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x74,
+	0x65, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x67,
+	0x65, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x6c, 0x6c, 0x65, 0x72,
+	0x67, 0x65, 0x6e, 0x73, 0x22, 0xab, 0x03, 0x0a, 0x08, 0x4d, 0x65, 0x6e, 0x75, 0x50, 0x6c, 0x61,
+	0x6e, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x65, 0x65, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x65, 0x65, 0x64, 0x12, 0x40, 0x0a, 0x0e, 0x63, 0x61, 0x6c,
+	0x6f, 0x72, 0x69, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x52, 0x0d, 0x63, 0x61,
+	0x6c, 0x6f, 0x72, 0x69, 0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x32, 0x0a, 0x09, 0x6d,
+	0x65, 0x6e, 0x75, 0x5f, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x61, 0x69, 0x6c,
+	0x79, 0x4d, 0x65, 0x6e, 0x75, 0x52, 0x08, 0x6d, 0x65, 0x6e, 0x75, 0x50, 0x6c, 0x61, 0x6e, 0x12,
+	0x1f, 0x0a, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x12, 0x25, 0x0a, 0x0e, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43,
+	0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x09, 0x64, 0x69, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x74, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x6c, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x76, 0x65, 0x72, 0x73, 0x69, 0x74,
+	0x79, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x09, 0x64, 0x69, 0x76, 0x65, 0x72, 0x73, 0x69, 0x74,
+	0x79, 0x12, 0x3f, 0x0a, 0x0e, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x69, 0x74,
+	0x65, 0x6d, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x6c, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x49,
+	0x74, 0x65, 0x6d, 0x52, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x49, 0x74, 0x65,
+	0x6d, 0x73, 0x32, 0xe7, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x6e, 0x75, 0x50, 0x6c, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x12, 0x45, 0x0a, 0x0c, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4d, 0x65,
+	0x6e, 0x75, 0x12, 0x1f, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x6e, 0x75, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x65, 0x6e, 0x75, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x3b, 0x0a, 0x07, 0x47, 0x65, 0x74,
+	0x50, 0x6c, 0x61, 0x6e, 0x12, 0x1a, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x14, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65,
+	0x6e, 0x75, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x54, 0x0a, 0x0d, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65,
+	0x6e, 0x75, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x20, 0x2e, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x6e, 0x75, 0x49, 0x74, 0x65,
+	0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x70, 0x6c, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x6e, 0x75, 0x49,
+	0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x10, 0x5a, 0x0e,
+	0x74, 0x61, 0x73, 0x6b, 0x2f, 0x70, 0x6c, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x70, 0x62, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_plannerpb_planner_proto_rawDescOnce sync.Once
+	file_plannerpb_planner_proto_rawDescData = file_plannerpb_planner_proto_rawDesc
+)
+
+func file_plannerpb_planner_proto_rawDescGZIP() []byte {
+	file_plannerpb_planner_proto_rawDescOnce.Do(func() {
+		file_plannerpb_planner_proto_rawDescData = protoimpl.X.CompressGZIP(file_plannerpb_planner_proto_rawDescData)
+	})
+	return file_plannerpb_planner_proto_rawDescData
+}
+
+var file_plannerpb_planner_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_plannerpb_planner_proto_goTypes = []any{
+	(*GenerateMenuRequest)(nil),   // 0: planner.v1.GenerateMenuRequest
+	(*GetPlanRequest)(nil),        // 1: planner.v1.GetPlanRequest
+	(*ListMenuItemsRequest)(nil),  // 2: planner.v1.ListMenuItemsRequest
+	(*ListMenuItemsResponse)(nil), // 3: planner.v1.ListMenuItemsResponse
+	(*Portion)(nil),               // 4: planner.v1.Portion
+	(*MenuItem)(nil),              // 5: planner.v1.MenuItem
+	(*Macros)(nil),                // 6: planner.v1.Macros
+	(*CalorieWindow)(nil),         // 7: planner.v1.CalorieWindow
+	(*DiversityStats)(nil),        // 8: planner.v1.DiversityStats
+	(*ComboComponent)(nil),        // 9: planner.v1.ComboComponent
+	(*Combo)(nil),                 // 10: planner.v1.Combo
+	(*MealMenu)(nil),              // 11: planner.v1.MealMenu
+	(*DailyMenu)(nil),             // 12: planner.v1.DailyMenu
+	(*ExcludedItem)(nil),          // 13: planner.v1.ExcludedItem
+	(*MenuPlan)(nil),              // 14: planner.v1.MenuPlan
+	nil,                           // 15: planner.v1.GenerateMenuRequest.TastePreferencesEntry
+	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+}
+var file_plannerpb_planner_proto_depIdxs = []int32{
+	15, // 0: planner.v1.GenerateMenuRequest.taste_preferences:type_name -> planner.v1.GenerateMenuRequest.TastePreferencesEntry
+	5,  // 1: planner.v1.ListMenuItemsResponse.items:type_name -> planner.v1.MenuItem
+	4,  // 2: planner.v1.MenuItem.portions:type_name -> planner.v1.Portion
+	9,  // 3: planner.v1.Combo.components:type_name -> planner.v1.ComboComponent
+	6,  // 4: planner.v1.Combo.macros:type_name -> planner.v1.Macros
+	10, // 5: planner.v1.DailyMenu.combos:type_name -> planner.v1.Combo
+	6,  // 6: planner.v1.DailyMenu.macros:type_name -> planner.v1.Macros
+	8,  // 7: planner.v1.DailyMenu.diversity:type_name -> planner.v1.DiversityStats
+	11, // 8: planner.v1.DailyMenu.meals:type_name -> planner.v1.MealMenu
+	16, // 9: planner.v1.MenuPlan.created_at:type_name -> google.protobuf.Timestamp
+	7,  // 10: planner.v1.MenuPlan.calorie_window:type_name -> planner.v1.CalorieWindow
+	12, // 11: planner.v1.MenuPlan.menu_plan:type_name -> planner.v1.DailyMenu
+	8,  // 12: planner.v1.MenuPlan.diversity:type_name -> planner.v1.DiversityStats
+	13, // 13: planner.v1.MenuPlan.excluded_items:type_name -> planner.v1.ExcludedItem
+	0,  // 14: planner.v1.MenuPlanner.GenerateMenu:input_type -> planner.v1.GenerateMenuRequest
+	1,  // 15: planner.v1.MenuPlanner.GetPlan:input_type -> planner.v1.GetPlanRequest
+	2,  // 16: planner.v1.MenuPlanner.ListMenuItems:input_type -> planner.v1.ListMenuItemsRequest
+	14, // 17: planner.v1.MenuPlanner.GenerateMenu:output_type -> planner.v1.MenuPlan
+	14, // 18: planner.v1.MenuPlanner.GetPlan:output_type -> planner.v1.MenuPlan
+	3,  // 19: planner.v1.MenuPlanner.ListMenuItems:output_type -> planner.v1.ListMenuItemsResponse
+	17, // [17:20] is the sub-list for method output_type
+	14, // [14:17] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_plannerpb_planner_proto_init() }
+func file_plannerpb_planner_proto_init() {
+	if File_plannerpb_planner_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_plannerpb_planner_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*GenerateMenuRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetPlanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListMenuItemsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ListMenuItemsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*Portion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*MenuItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Macros); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*CalorieWindow); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*DiversityStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*ComboComponent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*Combo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*MealMenu); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*DailyMenu); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*ExcludedItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_plannerpb_planner_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*MenuPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_plannerpb_planner_proto_msgTypes[0].OneofWrappers = []any{}
+	file_plannerpb_planner_proto_msgTypes[12].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_plannerpb_planner_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_plannerpb_planner_proto_goTypes,
+		DependencyIndexes: file_plannerpb_planner_proto_depIdxs,
+		MessageInfos:      file_plannerpb_planner_proto_msgTypes,
+	}.Build()
+	File_plannerpb_planner_proto = out.File
+	file_plannerpb_planner_proto_rawDesc = nil
+	file_plannerpb_planner_proto_goTypes = nil
+	file_plannerpb_planner_proto_depIdxs = nil
+}
@@ -0,0 +1,213 @@
+// The gRPC API of the menu planner, for internal service-to-service callers.
+// It shares the planner core with the HTTP API: requests are authenticated
+// with the same API keys or bearer tokens, given in the x-api-key or
+// authorization metadata, and the x-tenant-id metadata selects the tenant.
+//
+// Regenerate the Go code after editing this file:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative plannerpb/planner.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: plannerpb/planner.proto
+
+package plannerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MenuPlanner_GenerateMenu_FullMethodName  = "/planner.v1.MenuPlanner/GenerateMenu"
+	MenuPlanner_GetPlan_FullMethodName       = "/planner.v1.MenuPlanner/GetPlan"
+	MenuPlanner_ListMenuItems_FullMethodName = "/planner.v1.MenuPlanner/ListMenuItems"
+)
+
+// MenuPlannerClient is the client API for MenuPlanner service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MenuPlannerClient interface {
+	// GenerateMenu generates a plan from the master menu and stores it.
+	GenerateMenu(ctx context.Context, in *GenerateMenuRequest, opts ...grpc.CallOption) (*MenuPlan, error)
+	// GetPlan returns a stored plan.
+	GetPlan(ctx context.Context, in *GetPlanRequest, opts ...grpc.CallOption) (*MenuPlan, error)
+	// ListMenuItems returns the master menu.
+	ListMenuItems(ctx context.Context, in *ListMenuItemsRequest, opts ...grpc.CallOption) (*ListMenuItemsResponse, error)
+}
+
+type menuPlannerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMenuPlannerClient(cc grpc.ClientConnInterface) MenuPlannerClient {
+	return &menuPlannerClient{cc}
+}
+
+func (c *menuPlannerClient) GenerateMenu(ctx context.Context, in *GenerateMenuRequest, opts ...grpc.CallOption) (*MenuPlan, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MenuPlan)
+	err := c.cc.Invoke(ctx, MenuPlanner_GenerateMenu_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *menuPlannerClient) GetPlan(ctx context.Context, in *GetPlanRequest, opts ...grpc.CallOption) (*MenuPlan, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MenuPlan)
+	err := c.cc.Invoke(ctx, MenuPlanner_GetPlan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *menuPlannerClient) ListMenuItems(ctx context.Context, in *ListMenuItemsRequest, opts ...grpc.CallOption) (*ListMenuItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMenuItemsResponse)
+	err := c.cc.Invoke(ctx, MenuPlanner_ListMenuItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MenuPlannerServer is the server API for MenuPlanner service.
+// All implementations must embed UnimplementedMenuPlannerServer
+// for forward compatibility.
+type MenuPlannerServer interface {
+	// GenerateMenu generates a plan from the master menu and stores it.
+	GenerateMenu(context.Context, *GenerateMenuRequest) (*MenuPlan, error)
+	// GetPlan returns a stored plan.
+	GetPlan(context.Context, *GetPlanRequest) (*MenuPlan, error)
+	// ListMenuItems returns the master menu.
+	ListMenuItems(context.Context, *ListMenuItemsRequest) (*ListMenuItemsResponse, error)
+	mustEmbedUnimplementedMenuPlannerServer()
+}
+
+// UnimplementedMenuPlannerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMenuPlannerServer struct{}
+
+func (UnimplementedMenuPlannerServer) GenerateMenu(context.Context, *GenerateMenuRequest) (*MenuPlan, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateMenu not implemented")
+}
+func (UnimplementedMenuPlannerServer) GetPlan(context.Context, *GetPlanRequest) (*MenuPlan, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlan not implemented")
+}
+func (UnimplementedMenuPlannerServer) ListMenuItems(context.Context, *ListMenuItemsRequest) (*ListMenuItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMenuItems not implemented")
+}
+func (UnimplementedMenuPlannerServer) mustEmbedUnimplementedMenuPlannerServer() {}
+func (UnimplementedMenuPlannerServer) testEmbeddedByValue()                     {}
+
+// UnsafeMenuPlannerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MenuPlannerServer will
+// result in compilation errors.
+type UnsafeMenuPlannerServer interface {
+	mustEmbedUnimplementedMenuPlannerServer()
+}
+
+func RegisterMenuPlannerServer(s grpc.ServiceRegistrar, srv MenuPlannerServer) {
+	// If the following call pancis, it indicates UnimplementedMenuPlannerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MenuPlanner_ServiceDesc, srv)
+}
+
+func _MenuPlanner_GenerateMenu_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateMenuRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MenuPlannerServer).GenerateMenu(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MenuPlanner_GenerateMenu_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MenuPlannerServer).GenerateMenu(ctx, req.(*GenerateMenuRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MenuPlanner_GetPlan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MenuPlannerServer).GetPlan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MenuPlanner_GetPlan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MenuPlannerServer).GetPlan(ctx, req.(*GetPlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MenuPlanner_ListMenuItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMenuItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MenuPlannerServer).ListMenuItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MenuPlanner_ListMenuItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MenuPlannerServer).ListMenuItems(ctx, req.(*ListMenuItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MenuPlanner_ServiceDesc is the grpc.ServiceDesc for MenuPlanner service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MenuPlanner_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "planner.v1.MenuPlanner",
+	HandlerType: (*MenuPlannerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenerateMenu",
+			Handler:    _MenuPlanner_GenerateMenu_Handler,
+		},
+		{
+			MethodName: "GetPlan",
+			Handler:    _MenuPlanner_GetPlan_Handler,
+		},
+		{
+			MethodName: "ListMenuItems",
+			Handler:    _MenuPlanner_ListMenuItems_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plannerpb/planner.proto",
+}
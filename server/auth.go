@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"task/planner"
+)
+
+// Access scopes granted to API keys and OIDC roles. Each scope includes the
+// ones before it: generate callers may also read, and admins may do anything.
+const (
+	// scopeRead allows reading plans, menu items and profiles.
+	scopeRead = "read"
+	// scopeGenerate also allows generating and changing plans and posting feedback.
+	scopeGenerate = "generate"
+	// scopeAdmin also allows editing the master menu and profiles.
+	scopeAdmin = "admin"
+)
+
+var scopeLevels = map[string]int{scopeRead: 1, scopeGenerate: 2, scopeAdmin: 3}
+
+// APIKey is a key accepted by the API, given in the X-API-Key header or as an
+// "Authorization: Bearer" token.
+type APIKey struct {
+	// Name identifies the key's holder in logs.
+	Name  string `json:"name" yaml:"name"`
+	Key   string `json:"key" yaml:"key"`
+	Scope string `json:"scope" yaml:"scope"`
+	// Tenants limits the key to the named tenants; "" is the top-level
+	// tenant. Empty allows every tenant.
+	Tenants []string `json:"tenants,omitempty" yaml:"tenants"`
+}
+
+// AuthConfig lists the accepted API keys and the optional OIDC provider
+// whose bearer tokens are accepted. The API is open to everyone when neither
+// is configured.
+type AuthConfig struct {
+	APIKeys []APIKey   `json:"api_keys" yaml:"api_keys"`
+	OIDC    OIDCConfig `json:"oidc" yaml:"oidc"`
+}
+
+// validate reports the first key or OIDC setting that cannot be used.
+func (cfg AuthConfig) validate() error {
+	if err := cfg.OIDC.validate(); err != nil {
+		return fmt.Errorf("oidc: %w", err)
+	}
+	seen := make(map[string]bool, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		if key.Key == "" {
+			return fmt.Errorf("api_keys[%d]: key is required", i)
+		}
+		if seen[key.Key] {
+			return fmt.Errorf("api_keys[%d]: duplicate key", i)
+		}
+		seen[key.Key] = true
+		if _, ok := scopeLevels[key.Scope]; !ok {
+			return fmt.Errorf("api_keys[%d]: scope must be %q, %q or %q, got %q", i, scopeRead, scopeGenerate, scopeAdmin, key.Scope)
+		}
+	}
+	return nil
+}
+
+// parseAPIKeys parses the API_KEYS environment variable: comma-separated
+// key:scope pairs, e.g. "k1:read,k2:admin".
+func parseAPIKeys(raw string) ([]APIKey, error) {
+	var keys []APIKey
+	for i, pair := range planner.SplitList(raw) {
+		key, scope, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected key:scope, got entry %d without a scope", i+1)
+		}
+		keys = append(keys, APIKey{Name: fmt.Sprintf("API_KEYS[%d]", i), Key: key, Scope: scope})
+	}
+	return keys, nil
+}
+
+// apiKeys holds the accepted API keys, set from the configuration at startup.
+var apiKeys []APIKey
+
+// tokenVerifier checks OIDC bearer tokens; nil when OIDC is not configured.
+var tokenVerifier *oidcVerifier
+
+// principal is the authenticated caller of a request.
+type principal struct {
+	name  string
+	scope string
+	// tenants lists the tenants the caller may use; nil allows every tenant.
+	tenants []string
+}
+
+// errUnauthenticated reports a request presenting no usable credentials.
+var errUnauthenticated = errors.New("an API key or bearer token is required")
+
+// authenticate identifies the caller from an API key or, when OIDC is
+// configured, a JWT bearer token.
+func authenticate(r *http.Request) (principal, error) {
+	return authenticateCredential(r.Context(), requestAPIKey(r))
+}
+
+// authenticateCredential identifies the caller presenting an API key or
+// bearer token, as sent over HTTP or gRPC.
+func authenticateCredential(ctx context.Context, presented string) (principal, error) {
+	if presented == "" {
+		return principal{}, errUnauthenticated
+	}
+	if key, ok := findAPIKey(presented); ok {
+		caller := principal{name: key.Name, scope: key.Scope}
+		if caller.name == "" {
+			// Name unnamed keys by a digest so logs and rate limits can tell them apart.
+			sum := sha256.Sum256([]byte(key.Key))
+			caller.name = "key-" + hex.EncodeToString(sum[:6])
+		}
+		if len(key.Tenants) > 0 {
+			caller.tenants = key.Tenants
+		}
+		return caller, nil
+	}
+	if tokenVerifier != nil && strings.Count(presented, ".") == 2 {
+		return tokenVerifier.verify(ctx, presented)
+	}
+	return principal{}, errors.New("invalid API key")
+}
+
+// requestAPIKey returns the key presented by the request, or "".
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// findAPIKey returns the configured key matching presented, comparing in
+// constant time.
+func findAPIKey(presented string) (APIKey, bool) {
+	for _, key := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(presented)) == 1 {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// requireScope wraps next so that, when API keys or OIDC are configured, it
+// only runs for callers holding at least the given scope for the request's
+// tenant.
+func requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(apiKeys) == 0 && tokenVerifier == nil {
+			next(w, r)
+			return
+		}
+		caller, err := authenticate(r)
+		if errors.Is(err, errUnauthenticated) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="planner"`)
+			writeError(w, "An API key or bearer token is required.", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			requestLogger(r).Warn("rejected credentials", "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="planner", error="invalid_token"`)
+			writeError(w, "Invalid API key or bearer token.", http.StatusUnauthorized)
+			return
+		}
+		if scopeLevels[caller.scope] < scopeLevels[scope] {
+			writeError(w, fmt.Sprintf("The caller lacks the %s scope.", scope), http.StatusForbidden)
+			return
+		}
+		if caller.tenants != nil && !slices.Contains(caller.tenants, currentTenant(r).name) {
+			writeError(w, "The caller is not allowed to use this tenant.", http.StatusForbidden)
+			return
+		}
+		next(w, withPrincipal(r, caller))
+	}
+}
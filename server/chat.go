@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"task/planner"
+)
+
+// Chat providers whose incoming webhooks summaries can be posted to.
+const (
+	chatSlack = "slack"
+	chatTeams = "teams"
+)
+
+// ChatNotifyConfig posts plan summaries to a Slack or Microsoft Teams
+// incoming webhook. It is disabled when WebhookURL is empty.
+type ChatNotifyConfig struct {
+	// Provider is "slack" or "teams".
+	Provider   string `json:"provider" yaml:"provider"`
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+	// OnGeneration posts an overview of every generated plan.
+	OnGeneration bool `json:"on_generation" yaml:"on_generation"`
+	// Cron posts the day's combos from the newest plan whenever it matches,
+	// e.g. "0 7 * * 1-5" for weekday mornings. It is read in the time zone
+	// of the schedule section.
+	Cron string `json:"cron" yaml:"cron"`
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg ChatNotifyConfig) validate() error {
+	if cfg.WebhookURL == "" {
+		if cfg.OnGeneration || cfg.Cron != "" {
+			return errors.New("webhook_url is required for on_generation and cron")
+		}
+		return nil
+	}
+	if cfg.Provider != chatSlack && cfg.Provider != chatTeams {
+		return fmt.Errorf("unknown provider %q (expected slack or teams)", cfg.Provider)
+	}
+	if u, err := url.Parse(cfg.WebhookURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("webhook_url must be an absolute http or https URL")
+	}
+	if cfg.Cron != "" {
+		if _, err := parseCron(cfg.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chatMessage is a summary of one or more days of a plan, formatted for a
+// provider by payload.
+type chatMessage struct {
+	Title    string
+	Sections []chatSection
+}
+
+type chatSection struct {
+	Heading string
+	Lines   []string
+}
+
+// chatDaySection lists the combos of a day, whose calories are in unit.
+func chatDaySection(day planner.DailyMenu, unit string) chatSection {
+	unit = energyUnit(unit)
+	section := chatSection{Heading: fmt.Sprintf("%s (%d %s)", day.Day, day.TotalCalories, unit)}
+	for _, combo := range day.Combos {
+		names := make([]string, len(combo.Components))
+		for i, component := range combo.Components {
+			names[i] = component.ItemName
+		}
+		line := fmt.Sprintf("%s: %d %s, grade %s", strings.Join(names, " + "), combo.CalorieCount, unit, combo.HealthGrade)
+		if combo.Meal != "" {
+			line = combo.Meal + ": " + line
+		}
+		section.Lines = append(section.Lines, line)
+	}
+	return section
+}
+
+// payload returns the JSON body of msg for the provider's incoming webhook:
+// mrkdwn text for Slack, a MessageCard for Teams.
+func (msg chatMessage) payload(provider string) any {
+	if provider == chatTeams {
+		type section struct {
+			ActivityTitle string `json:"activityTitle"`
+			Text          string `json:"text"`
+		}
+		card := struct {
+			Type     string    `json:"@type"`
+			Context  string    `json:"@context"`
+			Summary  string    `json:"summary"`
+			Title    string    `json:"title"`
+			Sections []section `json:"sections"`
+		}{Type: "MessageCard", Context: "https://schema.org/extensions", Summary: msg.Title, Title: msg.Title}
+		for _, s := range msg.Sections {
+			card.Sections = append(card.Sections, section{ActivityTitle: s.Heading, Text: "- " + strings.Join(s.Lines, "\n- ")})
+		}
+		return card
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "*%s*\n", msg.Title)
+	for _, s := range msg.Sections {
+		fmt.Fprintf(&text, "\n*%s*\n", s.Heading)
+		for _, line := range s.Lines {
+			fmt.Fprintf(&text, "• %s\n", line)
+		}
+	}
+	return map[string]string{"text": text.String()}
+}
+
+// postChat posts msg to the configured incoming webhook.
+func postChat(cfg ChatNotifyConfig, msg chatMessage) error {
+	body, err := json.Marshal(msg.payload(cfg.Provider))
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(cfg.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", cfg.Provider, resp.Status)
+	}
+	return nil
+}
+
+// postPlanToChat posts an overview of a newly generated plan in the
+// background.
+func postPlanToChat(t *tenant, plan planner.MenuPlan, logger *slog.Logger) {
+	plan = planner.ReportedEnergy(plan)
+	msg := chatMessage{Title: fmt.Sprintf("New menu plan %s: %d days", plan.PlanID, len(plan.MenuPlan))}
+	if t.name != "" {
+		msg.Title = fmt.Sprintf("[%s] %s", t.name, msg.Title)
+	}
+	for _, day := range plan.MenuPlan {
+		msg.Sections = append(msg.Sections, chatDaySection(day, plan.EnergyUnit))
+	}
+	pendingNotifications.Add(1)
+	go func() {
+		defer pendingNotifications.Done()
+		if err := postChat(t.notify.Chat, msg); err != nil {
+			logger.Error("posting plan to chat failed", "plan_id", plan.PlanID, "error", err)
+		}
+	}()
+}
+
+// postTodayToChat posts the combos of today, the day dated now or, in plans
+// stored without dates, named after now's weekday, from the tenant's newest plan.
+func postTodayToChat(t *tenant, now time.Time) error {
+	plans, err := t.storage.ListPlans()
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		return errors.New("no plan has been generated yet")
+	}
+	plan := planner.ReportedEnergy(plans[0])
+	today := planner.PlanLocalizer(plan).DayName(now.Weekday().String())
+	date := now.Format(time.DateOnly)
+	for _, day := range plan.MenuPlan {
+		// Dated days must fall on today; undated ones repeat every week.
+		if day.Date != date && (day.Date != "" || day.Day != today) {
+			continue
+		}
+		msg := chatMessage{Title: "Today's menu: " + today, Sections: []chatSection{chatDaySection(day, plan.EnergyUnit)}}
+		if t.name != "" {
+			msg.Title = fmt.Sprintf("[%s] %s", t.name, msg.Title)
+		}
+		return postChat(t.notify.Chat, msg)
+	}
+	return fmt.Errorf("plan %s has no %s", plan.PlanID, today)
+}
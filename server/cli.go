@@ -0,0 +1,515 @@
+// Package server serves menu plans over HTTP and gRPC, storing menus,
+// plans and profiles for each tenant, and implements the planner command.
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"task/menu"
+	"task/planner"
+)
+
+const cliUsage = `Usage: planner <command> [flags]
+
+Commands:
+  serve     run the HTTP server, and the gRPC server when grpc_addr is set
+            (default when no command is given)
+  generate  generate a menu plan and print it as JSON
+  validate  check a menu file for problems
+  import    load a menu file (JSON or CSV) into the configured storage
+
+Run "planner <command> -h" for the flags of a command.
+`
+
+// RunCLI dispatches to the subcommand named by the first argument.
+func RunCLI(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args)
+	}
+	switch args[0] {
+	case "serve":
+		return runServe(args[1:])
+	case "generate":
+		return runGenerate(args[1:])
+	case "validate":
+		return runValidate(args[1:])
+	case "import":
+		return runImport(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(cliUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, cliUsage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// newFlagSet creates a flag set for a subcommand with the shared -config flag.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file")
+	return fs, configPath
+}
+
+// setup loads the configuration and initializes the tenants with their
+// storage and master menu, and the other shared state used by both the
+// server and the one-shot commands.
+func setup(configPath string) (Config, error) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return cfg, fmt.Errorf("error loading configuration: %w", err)
+	}
+	// Log records go to stderr, keeping stdout clean for command output.
+	slog.SetDefault(newLogger(cfg.Log, os.Stderr))
+	if tenants, err = openTenants(cfg); err != nil {
+		return cfg, err
+	}
+	nutritionService = newNutritionClient(cfg.Nutrition)
+	mailer = newSMTPMailer(cfg.SMTP)
+	generationTimeout = time.Duration(cfg.Server.GenerationTimeout)
+	apiKeys = cfg.Auth.APIKeys
+	tokenVerifier = newOIDCVerifier(cfg.Auth.OIDC)
+	generationLimiter = newRateLimiter(cfg.RateLimit)
+	tracer = newSpanExporter(cfg.Tracing)
+	planner.Metrics = generationMetrics{}
+	return cfg, nil
+}
+
+// lookupTenant returns the named tenant for a one-shot command; "" is the
+// tenant configured at the top level.
+func lookupTenant(name string) (*tenant, error) {
+	t, ok := tenants[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant %q", name)
+	}
+	return t, nil
+}
+
+// runServe starts the HTTP server.
+func runServe(args []string) error {
+	fs, configPath := newFlagSet("serve")
+	frontendDir := fs.String("frontend-dir", "", "serve the frontend from this directory, such as ./server/frontend, instead of the embedded copy")
+	pprofAddr := fs.String("pprof-addr", "", "serve pprof profiles on this admin address, such as localhost:6060 (default from config)")
+	fs.Parse(args)
+
+	cfg, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer closeTenants(tenants)
+	if *frontendDir != "" {
+		cfg.FrontendDir = *frontendDir
+	}
+	if *pprofAddr != "" {
+		cfg.PprofAddr = *pprofAddr
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	// Routes go on a mux of their own rather than http.DefaultServeMux, so
+	// serving twice in one process does not register them twice and nothing
+	// else registered on the default mux, such as net/http/pprof, is exposed.
+	mux := http.NewServeMux()
+	mux.Handle("GET /", frontendHandler(cfg.FrontendDir))
+	mux.HandleFunc("GET /healthz", healthzHandler)
+	mux.HandleFunc("GET /readyz", readyzHandler)
+	mux.HandleFunc("GET /openapi.json", openAPIHandler)
+	mux.HandleFunc("GET /generate-menu", requireScope(scopeGenerate, rateLimited(generateMenuHandler)))
+	mux.HandleFunc("POST /generate-menu", requireScope(scopeGenerate, rateLimited(generateMenuHandler)))
+	mux.HandleFunc("GET /generate-menu/ws", requireScope(scopeGenerate, rateLimited(generateMenuWebSocketHandler(cfg.CORS))))
+	mux.HandleFunc("GET /feasibility", requireScope(scopeRead, rateLimited(feasibilityHandler)))
+	mux.HandleFunc("POST /feasibility", requireScope(scopeRead, rateLimited(feasibilityHandler)))
+	mux.HandleFunc("GET /plans", requireScope(scopeRead, listPlansHandler))
+	mux.HandleFunc("GET /plans/{id}", requireScope(scopeRead, getPlanHandler))
+	mux.HandleFunc("POST /plans/{id}/regenerate", requireScope(scopeGenerate, rateLimited(regeneratePlanHandler)))
+	mux.HandleFunc("POST /plans/{id}/days/{day}/regenerate", requireScope(scopeGenerate, rateLimited(regenerateDayHandler)))
+	mux.HandleFunc("POST /plans/{id}/combos/{combo_id}/swap", requireScope(scopeGenerate, rateLimited(swapComboHandler)))
+	mux.HandleFunc("GET /plans/{id}/shopping-list", requireScope(scopeRead, shoppingListHandler))
+	mux.HandleFunc("GET /plans/{id}/ical", requireScope(scopeRead, icalHandler))
+	mux.HandleFunc("GET /plans/{id}/pdf", requireScope(scopeRead, pdfHandler))
+	mux.HandleFunc("GET /plans/{id}/html", requireScope(scopeRead, htmlHandler))
+	mux.HandleFunc("POST /feedback", requireScope(scopeGenerate, feedbackHandler))
+	mux.HandleFunc("GET /profiles", requireScope(scopeRead, listProfilesHandler))
+	mux.HandleFunc("GET /profiles/{name}", requireScope(scopeRead, getProfileHandler))
+	mux.HandleFunc("POST /profiles", requireScope(scopeAdmin, createProfileHandler))
+	mux.HandleFunc("PUT /profiles/{name}", requireScope(scopeAdmin, putProfileHandler))
+	mux.HandleFunc("DELETE /profiles/{name}", requireScope(scopeAdmin, deleteProfileHandler))
+	mux.HandleFunc("GET /webhooks", requireScope(scopeAdmin, listWebhooksHandler))
+	mux.HandleFunc("GET /webhooks/{id}", requireScope(scopeAdmin, getWebhookHandler))
+	mux.HandleFunc("POST /webhooks", requireScope(scopeAdmin, createWebhookHandler))
+	mux.HandleFunc("DELETE /webhooks/{id}", requireScope(scopeAdmin, deleteWebhookHandler))
+	mux.HandleFunc("GET /metrics", requireScope(scopeAdmin, metricsHandler))
+	mux.HandleFunc("GET /graphql", requireScope(scopeRead, graphQLHandler))
+	mux.HandleFunc("POST /graphql", requireScope(scopeRead, graphQLHandler))
+	mux.HandleFunc("GET /menus", requireScope(scopeRead, listMenuVersionsHandler))
+	mux.HandleFunc("GET /menus/{version}", requireScope(scopeRead, getMenuVersionHandler))
+	mux.HandleFunc("GET /menu-items", requireScope(scopeRead, listMenuItemsHandler))
+	mux.HandleFunc("GET /menu-items/{name}", requireScope(scopeRead, getMenuItemHandler))
+	mux.HandleFunc("POST /menu-items", requireScope(scopeAdmin, createMenuItemHandler))
+	mux.HandleFunc("POST /menu-items/import", requireScope(scopeAdmin, importMenuItemsHandler))
+	mux.HandleFunc("POST /menu-items:bulk", requireScope(scopeAdmin, bulkUpsertMenuItemsHandler))
+	mux.HandleFunc("POST /validate-menu", requireScope(scopeRead, validateMenuHandler))
+	mux.HandleFunc("PUT /menu-items/{name}", requireScope(scopeAdmin, updateMenuItemHandler))
+	mux.HandleFunc("DELETE /menu-items/{name}", requireScope(scopeAdmin, deleteMenuItemHandler))
+
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           withTracing(mux, withRequestLogging(withMetrics(mux, withCORS(cfg.CORS, withOpenAPIValidation(mux, withTenant(withRouteErrors(mux))))))),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	waitForJobs, err := startScheduler(ctx, cfg.Schedule)
+	if err != nil {
+		return fmt.Errorf("starting the scheduler: %w", err)
+	}
+	menuPaths, err := menuWatchPaths(cfg)
+	if err == nil {
+		err = watchMenuFiles(ctx, menuPaths)
+	}
+	if err != nil {
+		return fmt.Errorf("watching menu files: %w", err)
+	}
+	serveErr := make(chan error, 3)
+	var grpcServer *grpc.Server
+	if cfg.GRPCAddr != "" {
+		if grpcServer, err = newGRPCServer(cfg.Server); err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			return fmt.Errorf("listening for gRPC: %w", err)
+		}
+		go func() {
+			slog.Info("gRPC server listening", "addr", cfg.GRPCAddr)
+			serveErr <- grpcServer.Serve(listener)
+		}()
+	}
+	if cfg.PprofAddr != "" {
+		pprofServer := newPprofServer(cfg.PprofAddr)
+		listener, err := net.Listen("tcp", cfg.PprofAddr)
+		if err != nil {
+			return fmt.Errorf("listening for pprof: %w", err)
+		}
+		defer pprofServer.Close()
+		go func() {
+			slog.Info("pprof server listening", "addr", cfg.PprofAddr)
+			if err := pprofServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- err
+			}
+		}()
+	}
+	go func() {
+		tls := cfg.Server.TLSCertFile != ""
+		slog.Info("server listening", "addr", cfg.Addr, "tls", tls, "tenants", len(tenants))
+		if tls {
+			serveErr <- server.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+			return
+		}
+		serveErr <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	shuttingDown.Store(true)
+
+	// Stop accepting connections and let in-flight requests, such as a plan
+	// being generated, finish before the storage is closed.
+	slog.Info("shutting down, waiting for in-flight requests", "timeout", time.Duration(cfg.Server.ShutdownTimeout).String())
+	shutdownCtx := context.Background()
+	if cfg.Server.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, time.Duration(cfg.Server.ShutdownTimeout))
+		defer cancel()
+	}
+	grpcStopped := make(chan struct{})
+	if grpcServer != nil {
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+	} else {
+		close(grpcStopped)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+		return fmt.Errorf("shutting down gRPC: %w", shutdownCtx.Err())
+	}
+	waitForJobs()
+	if err := waitForNotifications(shutdownCtx); err != nil {
+		slog.Warn("shutting down before all notifications were sent", "error", err)
+	}
+	tracer.shutdown(shutdownCtx)
+	slog.Info("server stopped")
+	return nil
+}
+
+// runGenerate generates a single plan and writes it to stdout or a file.
+func runGenerate(args []string) error {
+	fs, configPath := newFlagSet("generate")
+	tenantName := fs.String("tenant", "", "tenant to generate the plan for (default the top-level settings)")
+	menuPath := fs.String("menu", "", "menu file (JSON or CSV) to use instead of the configured storage")
+	output := fs.String("o", "", "write the plan to this file instead of stdout")
+	days := fs.Int("days", 0, "number of days to plan (default from config)")
+	combosPerDay := fs.Int("combos-per-day", 0, "combos per day (default from config)")
+	minCalories := fs.Int("min-calories", -1, "minimum calories per combo (default from config)")
+	maxCalories := fs.Int("max-calories", -1, "maximum calories per combo (default from config)")
+	repeatWindow := fs.Int("repeat-window", -1, "days before a combo may repeat, 0 allows repeats (default from config)")
+	maxItemUses := fs.Int("max-item-uses", 0, "maximum times any item may appear in the plan (default no cap)")
+	maxTotalCalories := fs.Int("max-total-calories", 0, "maximum calories of the whole plan (default no cap)")
+	tolerance := fs.Float64("popularity-tolerance", -1, "maximum popularity spread within a combo (default from config)")
+	seed := fs.Int64("seed", 0, "random seed for a reproducible plan (default time-based)")
+	strategy := fs.String("strategy", "", "generation strategy: enumerate, sample, anneal or backtrack (default from config)")
+	selection := fs.String("selection", "", "random selection: uniform or popularity (default from config)")
+	temperature := fs.Float64("temperature", -1, "temperature of popularity selection, lower favours popular items (default from config)")
+	template := fs.String("template", "", "combo template, e.g. main+2 sides+drink, with ? marking optional roles as in main+side+drink+dessert? (default from config)")
+	optimize := fs.String("optimize", "", "optimization mode: popularity or cost (default random selection)")
+	dietaryTags := fs.String("dietary-tags", "", "comma-separated dietary tags every item must carry, e.g. vegetarian,gluten-free")
+	excludeAllergens := fs.String("exclude-allergens", "", "comma-separated allergens no item may contain, e.g. dairy,nuts")
+	maxComboPrice := fs.Float64("max-combo-price", 0, "maximum price of a combo (default no cap)")
+	maxTotalPrice := fs.Float64("max-total-price", 0, "maximum price of the whole plan (default no cap)")
+	tastePreferences := fs.String("taste-preferences", "", "comma-separated taste profile weights, e.g. spicy:2,sweet:0.5")
+	startDate := fs.String("start-date", "", "date of the plan's first day, YYYY-MM-DD (default the Monday of this week)")
+	lang := fs.String("lang", "", "language of day names and reasoning: en, es, fr or de (default en)")
+	rulesFile := fs.String("rules", "", "JSON file of constraint rules checked in addition to the configured ones")
+	units := fs.String("units", "", "energy unit of the calorie flags, the -menu file and the plan: kcal or kJ (default kcal)")
+	preset := fs.String("preset", "", "calorie and macro targets and combos per day for an audience: "+strings.Join(planner.PresetNames(), ", ")+"; other flags override it")
+	fs.Parse(args)
+
+	unit, err := menu.ParseEnergyUnit(*units)
+	if err != nil {
+		return fmt.Errorf("invalid -units: %w", err)
+	}
+
+	if _, err := setup(*configPath); err != nil {
+		return err
+	}
+	defer closeTenants(tenants)
+
+	t, err := lookupTenant(*tenantName)
+	if err != nil {
+		return err
+	}
+	items, index, version := t.menu.Snapshot()
+	if *menuPath != "" {
+		if items, err = menu.LoadFile(*menuPath); err != nil {
+			return err
+		}
+		menu.ItemsToKcal(items, unit)
+		if problems := menu.Validate(items); len(problems) > 0 {
+			return fmt.Errorf("refusing to generate from %s: %s", *menuPath, strings.Join(problems, "; "))
+		}
+		index, version = nil, 0
+	}
+
+	opts := t.defaults
+	if *preset != "" {
+		p, err := planner.LookupPreset(*preset)
+		if err != nil {
+			return fmt.Errorf("invalid -preset: %w", err)
+		}
+		p.Apply(&opts)
+	}
+	if *days > 0 {
+		opts.Days = *days
+	}
+	if *combosPerDay > 0 {
+		opts.CombosPerDay = *combosPerDay
+	}
+	if *minCalories >= 0 {
+		opts.MinCalories = menu.ToKcal(*minCalories, unit)
+	}
+	if *maxCalories >= 0 {
+		opts.MaxCalories = menu.ToKcal(*maxCalories, unit)
+	}
+	if *repeatWindow >= 0 {
+		opts.RepeatWindow = *repeatWindow
+	}
+	opts.MaxItemUses = *maxItemUses
+	opts.MaxTotalCalories = menu.ToKcal(*maxTotalCalories, unit)
+	if *tolerance >= 0 {
+		opts.PopularityTolerance = *tolerance
+	}
+	if *strategy != "" {
+		opts.Strategy = *strategy
+	}
+	if *selection != "" {
+		opts.Selection = *selection
+	}
+	if *temperature >= 0 {
+		opts.Temperature = *temperature
+	}
+	if *template != "" {
+		parsed, err := planner.ParseComboTemplate(*template)
+		if err != nil {
+			return fmt.Errorf("invalid -template: %w", err)
+		}
+		opts.Template = parsed
+	}
+	opts.Optimize = *optimize
+	opts.DietaryTags = planner.SplitList(*dietaryTags)
+	opts.ExcludeAllergens = planner.SplitList(*excludeAllergens)
+	opts.MaxComboPrice = *maxComboPrice
+	opts.MaxTotalPrice = *maxTotalPrice
+	opts.StartDate = *startDate
+	opts.Lang = strings.ToLower(*lang)
+	if *units != "" {
+		opts.Units = unit
+	}
+	if *tastePreferences != "" {
+		prefs, err := planner.ParseWeightList(*tastePreferences)
+		if err != nil {
+			return fmt.Errorf("invalid -taste-preferences: %w", err)
+		}
+		opts.TastePreferences = prefs
+	}
+	if *rulesFile != "" {
+		data, err := os.ReadFile(*rulesFile)
+		if err != nil {
+			return err
+		}
+		rules, err := planner.ParseRuleDefs(data)
+		if err != nil {
+			return fmt.Errorf("invalid -rules: %w", err)
+		}
+		opts.RuleDefs = append(slices.Clip(opts.RuleDefs), rules...)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.Seed = seed
+		}
+	})
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("invalid generation settings: %w", err)
+	}
+
+	plan, err := planner.GenerateWithIndex(context.Background(), items, opts, index)
+	if err != nil {
+		return err
+	}
+	plan.MenuVersion = version
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return encodeMenuPlanJSON(out, planner.ReportedEnergy(plan), "  ")
+}
+
+// runValidate checks a menu file and reports every problem found.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: planner validate <menu file>")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("validate needs exactly one menu file")
+	}
+
+	items, err := menu.LoadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	report := menu.Diagnose(items)
+	for _, problem := range report.Errors {
+		fmt.Println(problem)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Println("warning:", warning)
+	}
+	if !report.Valid {
+		return fmt.Errorf("%s has %d problem(s)", fs.Arg(0), len(report.Errors))
+	}
+	fmt.Printf("%s: %d items, no problems found\n", fs.Arg(0), len(items))
+	return nil
+}
+
+// runImport replaces the menu in the configured storage with a menu file.
+func runImport(args []string) error {
+	fs, configPath := newFlagSet("import")
+	tenantName := fs.String("tenant", "", "tenant whose menu is replaced (default the top-level settings)")
+	dedupe := fs.Bool("dedupe", false, "leave out items whose name duplicates an earlier one but for case, spacing or accents")
+	units := fs.String("units", "", "energy unit of the file's calories: kcal or kJ (default kcal)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: planner import [-config file] [-tenant name] [-dedupe] [-units unit] <menu file>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("import needs exactly one menu file")
+	}
+	unit, err := menu.ParseEnergyUnit(*units)
+	if err != nil {
+		return fmt.Errorf("invalid -units: %w", err)
+	}
+
+	items, err := menu.LoadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	menu.ItemsToKcal(items, unit)
+	if *dedupe {
+		var dropped []menu.Item
+		items, dropped = menu.Deduplicate(items)
+		for _, item := range dropped {
+			slog.Info("duplicate menu item left out of the import", "item_name", item.ItemName)
+		}
+	}
+	if problems := menu.Validate(items); len(problems) > 0 {
+		return fmt.Errorf("refusing to import %s: %s", fs.Arg(0), strings.Join(problems, "; "))
+	}
+
+	if _, err := setup(*configPath); err != nil {
+		return err
+	}
+	defer closeTenants(tenants)
+	t, err := lookupTenant(*tenantName)
+	if err != nil {
+		return err
+	}
+	if err := t.menu.Replace(items); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d items from %s\n", len(items), fs.Arg(0))
+	return nil
+}
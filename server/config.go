@@ -0,0 +1,624 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+
+	"task/planner"
+)
+
+// Duration is a time.Duration written as a Go duration string (e.g. "3s") in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config holds the service settings. Values are read from an optional YAML or
+// JSON file and can then be overridden by environment variables.
+type Config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string `json:"addr" yaml:"addr"`
+	// GRPCAddr is the address the gRPC API listens on; it is disabled when empty.
+	GRPCAddr string `json:"grpc_addr" yaml:"grpc_addr"`
+	// PprofAddr is the admin address serving net/http/pprof profiles; it is
+	// disabled when empty. The profiles are not authenticated.
+	PprofAddr string `json:"pprof_addr" yaml:"pprof_addr"`
+	// MenuPath is the master menu file (JSON or CSV) used to seed the storage.
+	MenuPath string `json:"menu_path" yaml:"menu_path"`
+	// WatchMenu reloads the master menu from MenuPath whenever the file
+	// changes while the server runs.
+	WatchMenu bool `json:"watch_menu" yaml:"watch_menu"`
+	// FrontendDir, when set, serves the static frontend assets from disk
+	// instead of the copy embedded in the binary, for development.
+	FrontendDir string `json:"frontend_dir" yaml:"frontend_dir"`
+
+	Server       ServerConfig         `json:"server" yaml:"server"`
+	Log          LogConfig            `json:"log" yaml:"log"`
+	Tracing      TracingConfig        `json:"tracing" yaml:"tracing"`
+	Generation   GenerationConfig     `json:"generation" yaml:"generation"`
+	Storage      StorageConfig        `json:"storage" yaml:"storage"`
+	Nutrition    NutritionConfig      `json:"nutrition" yaml:"nutrition"`
+	HealthRubric planner.HealthRubric `json:"health_rubric" yaml:"health_rubric"`
+	Feedback     FeedbackConfig       `json:"feedback" yaml:"feedback"`
+	Auth         AuthConfig           `json:"auth" yaml:"auth"`
+	RateLimit    RateLimitConfig      `json:"rate_limit" yaml:"rate_limit"`
+	CORS         CORSConfig           `json:"cors" yaml:"cors"`
+	Schedule     ScheduleConfig       `json:"schedule" yaml:"schedule"`
+	SMTP         SMTPConfig           `json:"smtp" yaml:"smtp"`
+	Notify       NotifyConfig         `json:"notify" yaml:"notify"`
+
+	// Tenants configures additional cafeterias served by the same deployment,
+	// selected per request with the tenant query parameter or the X-Tenant-ID
+	// header. Requests naming no tenant use the top-level settings.
+	Tenants map[string]TenantConfig `json:"tenants,omitempty" yaml:"tenants"`
+}
+
+// GenerationConfig holds the default generation settings for requests that do not override them.
+type GenerationConfig struct {
+	Days                int     `json:"days" yaml:"days"`
+	CombosPerDay        int     `json:"combos_per_day" yaml:"combos_per_day"`
+	MinCalories         int     `json:"min_calories" yaml:"min_calories"`
+	MaxCalories         int     `json:"max_calories" yaml:"max_calories"`
+	PopularityTolerance float64 `json:"popularity_tolerance" yaml:"popularity_tolerance"`
+	RepeatWindow        int     `json:"repeat_window" yaml:"repeat_window"`
+	// Template lists the categories of each combo, e.g. "main+2 sides+drink".
+	Template planner.ComboTemplate `json:"template" yaml:"template"`
+	// DayTemplates gives single days a template of their own, e.g. a dessert on Sundays.
+	DayTemplates map[string]planner.ComboTemplate `json:"day_templates" yaml:"day_templates"`
+	// MealSlots splits each day into named meals such as breakfast, lunch and dinner.
+	MealSlots []planner.MealSlot `json:"meal_slots" yaml:"meal_slots"`
+	Strategy  string             `json:"strategy" yaml:"strategy"`
+	// Selection and Temperature weigh random selection towards popular items.
+	Selection   string  `json:"selection" yaml:"selection"`
+	Temperature float64 `json:"temperature" yaml:"temperature"`
+	// ScoreWeights weighs popularity, diversity and cost when ranking each day's combos.
+	ScoreWeights planner.ScoreWeights `json:"score_weights" yaml:"score_weights"`
+	// Closures lists holidays and other dates with no or reduced service.
+	Closures []planner.Closure `json:"closures" yaml:"closures"`
+	// RulesFile is a JSON file of declarative constraint rules, such as
+	// [{"rule": "max_items_with_tag", "tag": "fried", "max": 1}], checked
+	// for every combo. It is read when the configuration is loaded.
+	RulesFile string `json:"rules_file" yaml:"rules_file"`
+
+	// rules are the rules read from RulesFile.
+	rules []planner.RuleDef
+}
+
+// loadRules reads the rules of RulesFile.
+func (g *GenerationConfig) loadRules() error {
+	g.rules = nil
+	if g.RulesFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(g.RulesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read rules file %s: %w", g.RulesFile, err)
+	}
+	rules, err := planner.ParseRuleDefs(data)
+	if err != nil {
+		return fmt.Errorf("invalid rules file %s: %w", g.RulesFile, err)
+	}
+	g.rules = rules
+	return nil
+}
+
+// ServerConfig tunes the HTTP server. TLS is enabled when both TLSCertFile
+// and TLSKeyFile are set.
+type ServerConfig struct {
+	TLSCertFile string `json:"tls_cert_file" yaml:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file" yaml:"tls_key_file"`
+	// ReadHeaderTimeout and ReadTimeout bound reading a request's headers and
+	// the whole request; WriteTimeout bounds handling it and writing the
+	// response, so it must leave room for generation. IdleTimeout closes
+	// keep-alive connections left unused. Zero disables a timeout.
+	ReadHeaderTimeout Duration `json:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	// MaxHeaderBytes caps the size of request headers.
+	MaxHeaderBytes int `json:"max_header_bytes" yaml:"max_header_bytes"`
+	// ShutdownTimeout is how long the server waits on SIGINT or SIGTERM for
+	// in-flight requests to finish before closing their connections. Zero
+	// waits for as long as they take.
+	ShutdownTimeout Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// GenerationTimeout bounds generating a plan; requests that run past it
+	// are answered with 504. Zero lets generation take as long as it needs.
+	GenerationTimeout Duration `json:"generation_timeout" yaml:"generation_timeout"`
+	// PlanCacheSize is how many seeded plans each tenant keeps to answer
+	// repeated requests without generating them again. Zero disables the cache.
+	PlanCacheSize int `json:"plan_cache_size" yaml:"plan_cache_size"`
+}
+
+// StorageConfig selects the storage backend: "memory", "sqlite" or "postgres".
+type StorageConfig struct {
+	Driver string `json:"driver" yaml:"driver"`
+	DSN    string `json:"dsn" yaml:"dsn"`
+}
+
+// NutritionConfig configures the optional external nutrition API. It is
+// disabled when URL is empty.
+type NutritionConfig struct {
+	URL     string   `json:"url" yaml:"url"`
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+	// Tolerance is the relative calorie difference above which a combo is flagged.
+	Tolerance float64 `json:"tolerance" yaml:"tolerance"`
+}
+
+// FeedbackConfig tunes how diner feedback from /feedback changes popularity scores.
+type FeedbackConfig struct {
+	// LearningRate is the fraction of the way a single rating or vote moves an
+	// item's popularity score towards the score it implies.
+	LearningRate float64 `json:"learning_rate" yaml:"learning_rate"`
+}
+
+// TenantConfig holds the settings of one tenant. It accepts menu_path,
+// storage, generation, health_rubric, feedback and notify like the top level
+// does; settings it leaves out are inherited from the top level.
+type TenantConfig struct {
+	// decode overlays the tenant's settings onto cfg.
+	decode func(cfg *Config) error
+}
+
+func (t *TenantConfig) UnmarshalYAML(node *yaml.Node) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	t.decode = func(cfg *Config) error {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	return nil
+}
+
+func (t *TenantConfig) UnmarshalJSON(data []byte) error {
+	data = append([]byte(nil), data...)
+	t.decode = func(cfg *Config) error {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(cfg)
+	}
+	return nil
+}
+
+// tenantNamePattern restricts tenant names to characters that are safe in
+// URLs, headers and file names.
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tenantConfig returns the settings of the named tenant: the top-level
+// settings with the tenant's overrides applied. A tenant using SQLite without
+// a DSN of its own gets a database file named after it, so tenants never
+// share a database by accident; a Postgres tenant must be given a DSN of its
+// own, which validateTenants checks.
+func (cfg Config) tenantConfig(name string) (Config, error) {
+	tc := cfg
+	tc.Tenants = nil
+	tc.Auth = AuthConfig{}
+	tc.CORS = CORSConfig{}
+	tc.Schedule = ScheduleConfig{}
+	// Decoding reuses slices, so give the tenant copies of its own.
+	tc.Generation.Template = slices.Clone(cfg.Generation.Template)
+	tc.Generation.DayTemplates = maps.Clone(cfg.Generation.DayTemplates)
+	tc.Generation.MealSlots = slices.Clone(cfg.Generation.MealSlots)
+	tc.Generation.Closures = slices.Clone(cfg.Generation.Closures)
+	tc.Notify.Email.To = slices.Clone(cfg.Notify.Email.To)
+	if decode := cfg.Tenants[name].decode; decode != nil {
+		if err := decode(&tc); err != nil {
+			return tc, fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	if tc.Generation.RulesFile != cfg.Generation.RulesFile {
+		if err := tc.Generation.loadRules(); err != nil {
+			return tc, fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	if tc.Addr != cfg.Addr || tc.GRPCAddr != cfg.GRPCAddr || tc.PprofAddr != cfg.PprofAddr || tc.Server != cfg.Server || tc.Log != cfg.Log || !reflect.DeepEqual(tc.Tracing, cfg.Tracing) || tc.FrontendDir != cfg.FrontendDir || tc.Nutrition != cfg.Nutrition || tc.SMTP != cfg.SMTP ||
+		!reflect.DeepEqual(tc.Auth, AuthConfig{}) || tc.RateLimit != cfg.RateLimit ||
+		!reflect.DeepEqual(tc.CORS, CORSConfig{}) || !reflect.DeepEqual(tc.Schedule, ScheduleConfig{}) || tc.Tenants != nil {
+		return tc, fmt.Errorf("tenant %s: addr, grpc_addr, pprof_addr, server, log, tracing, frontend_dir, nutrition, smtp, auth, rate_limit, cors, schedule and tenants can only be set at the top level", name)
+	}
+	tc.Auth, tc.CORS = cfg.Auth, cfg.CORS
+	if tc.Storage.Driver == "sqlite" && tc.Storage.DSN == cfg.Storage.DSN {
+		tc.Storage.DSN = fmt.Sprintf("./data/planner_%s.db", name)
+	}
+	return tc, nil
+}
+
+// defaultConfig returns the settings used when nothing is configured.
+func defaultConfig() Config {
+	generation := planner.DefaultOptions()
+	return Config{
+		Addr:      ":8080",
+		MenuPath:  "./data/master_menu.json",
+		WatchMenu: true,
+		Server: ServerConfig{
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(2 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			MaxHeaderBytes:    64 << 10,
+			ShutdownTimeout:   Duration(30 * time.Second),
+			GenerationTimeout: Duration(time.Minute),
+			PlanCacheSize:     256,
+		},
+		Log:     LogConfig{Format: logFormatJSON, Level: "info"},
+		Tracing: TracingConfig{ServiceName: "menu-planner", SampleRatio: 1},
+		Generation: GenerationConfig{
+			Days:                generation.Days,
+			CombosPerDay:        generation.CombosPerDay,
+			MinCalories:         generation.MinCalories,
+			MaxCalories:         generation.MaxCalories,
+			PopularityTolerance: generation.PopularityTolerance,
+			RepeatWindow:        generation.RepeatWindow,
+			Strategy:            generation.Strategy,
+			ScoreWeights:        generation.ScoreWeights,
+		},
+		Storage: StorageConfig{Driver: "memory"},
+		Nutrition: NutritionConfig{
+			Timeout:   Duration(3 * time.Second),
+			Tolerance: 0.05,
+		},
+		SMTP:         SMTPConfig{Port: 587, Timeout: Duration(10 * time.Second)},
+		HealthRubric: generation.HealthRubric,
+		Feedback:     FeedbackConfig{LearningRate: 0.1},
+		Auth: AuthConfig{
+			OIDC: OIDCConfig{RolesClaim: "roles", KeyRefresh: Duration(time.Hour)},
+		},
+		RateLimit: RateLimitConfig{Burst: 5},
+		CORS:      defaultCORSConfig,
+	}
+}
+
+// loadConfig builds the configuration from the defaults, the optional file at
+// path and the environment, in that order of precedence, and validates it.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Generation.loadRules(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the settings from a YAML (.yaml, .yml) or JSON file.
+// Settings missing from the file keep their current values.
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file %s: expected a .yaml, .yml or .json extension", path)
+	}
+	return nil
+}
+
+// applyEnv overrides settings from environment variables.
+func (cfg *Config) applyEnv() error {
+	strVars := []struct {
+		name   string
+		target *string
+	}{
+		{"ADDR", &cfg.Addr},
+		{"GRPC_ADDR", &cfg.GRPCAddr},
+		{"PPROF_ADDR", &cfg.PprofAddr},
+		{"MENU_PATH", &cfg.MenuPath},
+		{"FRONTEND_DIR", &cfg.FrontendDir},
+		{"TLS_CERT_FILE", &cfg.Server.TLSCertFile},
+		{"TLS_KEY_FILE", &cfg.Server.TLSKeyFile},
+		{"LOG_FORMAT", &cfg.Log.Format},
+		{"LOG_LEVEL", &cfg.Log.Level},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.Tracing.Endpoint},
+		{"OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName},
+		{"STORAGE_DRIVER", &cfg.Storage.Driver},
+		{"STORAGE_DSN", &cfg.Storage.DSN},
+		{"NUTRITION_API_URL", &cfg.Nutrition.URL},
+		{"STRATEGY", &cfg.Generation.Strategy},
+		{"RULES_FILE", &cfg.Generation.RulesFile},
+		{"OIDC_ISSUER", &cfg.Auth.OIDC.Issuer},
+		{"OIDC_JWKS_URL", &cfg.Auth.OIDC.JWKSURL},
+		{"OIDC_AUDIENCE", &cfg.Auth.OIDC.Audience},
+		{"SMTP_HOST", &cfg.SMTP.Host},
+		{"SMTP_USERNAME", &cfg.SMTP.Username},
+		{"SMTP_PASSWORD", &cfg.SMTP.Password},
+		{"SMTP_FROM", &cfg.SMTP.From},
+		{"NOTIFY_CHAT_PROVIDER", &cfg.Notify.Chat.Provider},
+		{"NOTIFY_CHAT_WEBHOOK_URL", &cfg.Notify.Chat.WebhookURL},
+	}
+	for _, v := range strVars {
+		if value, ok := os.LookupEnv(v.name); ok {
+			*v.target = value
+		}
+	}
+	if port, ok := os.LookupEnv("PORT"); ok && os.Getenv("ADDR") == "" {
+		cfg.Addr = ":" + port
+	}
+
+	intVars := []struct {
+		name   string
+		target *int
+	}{
+		{"DAYS", &cfg.Generation.Days},
+		{"COMBOS_PER_DAY", &cfg.Generation.CombosPerDay},
+		{"MIN_CALORIES", &cfg.Generation.MinCalories},
+		{"MAX_CALORIES", &cfg.Generation.MaxCalories},
+		{"REPEAT_WINDOW", &cfg.Generation.RepeatWindow},
+		{"RATE_LIMIT_BURST", &cfg.RateLimit.Burst},
+		{"SMTP_PORT", &cfg.SMTP.Port},
+		{"PLAN_CACHE_SIZE", &cfg.Server.PlanCacheSize},
+	}
+	for _, v := range intVars {
+		if raw, ok := os.LookupEnv(v.name); ok {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: must be an integer", v.name, raw)
+			}
+			*v.target = value
+		}
+	}
+
+	floatVars := []struct {
+		name   string
+		target *float64
+	}{
+		{"POPULARITY_TOLERANCE", &cfg.Generation.PopularityTolerance},
+		{"NUTRITION_API_TOLERANCE", &cfg.Nutrition.Tolerance},
+		{"FEEDBACK_LEARNING_RATE", &cfg.Feedback.LearningRate},
+		{"RATE_LIMIT_PER_MINUTE", &cfg.RateLimit.RequestsPerMinute},
+	}
+	for _, v := range floatVars {
+		if raw, ok := os.LookupEnv(v.name); ok {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: must be a number", v.name, raw)
+			}
+			*v.target = value
+		}
+	}
+
+	if raw, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		cfg.CORS.AllowedOrigins = planner.SplitList(raw)
+	}
+	if raw, ok := os.LookupEnv("NOTIFY_EMAIL_TO"); ok {
+		cfg.Notify.Email.To = planner.SplitList(raw)
+	}
+
+	if raw, ok := os.LookupEnv("API_KEYS"); ok {
+		keys, err := parseAPIKeys(raw)
+		if err != nil {
+			return fmt.Errorf("invalid API_KEYS: %w", err)
+		}
+		cfg.Auth.APIKeys = keys
+	}
+
+	if raw, ok := os.LookupEnv("NUTRITION_API_TIMEOUT"); ok {
+		if err := cfg.Nutrition.Timeout.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("invalid NUTRITION_API_TIMEOUT %q: %w", raw, err)
+		}
+	}
+	if raw, ok := os.LookupEnv("GENERATION_TIMEOUT"); ok {
+		if err := cfg.Server.GenerationTimeout.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("invalid GENERATION_TIMEOUT %q: %w", raw, err)
+		}
+	}
+	return nil
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg Config) validate() error {
+	if cfg.Addr == "" {
+		return errors.New("addr must not be empty")
+	}
+	if cfg.MenuPath == "" {
+		return errors.New("menu_path must not be empty")
+	}
+	if cfg.GRPCAddr != "" && cfg.GRPCAddr == cfg.Addr {
+		return errors.New("grpc_addr must differ from addr")
+	}
+	if cfg.PprofAddr != "" && (cfg.PprofAddr == cfg.Addr || cfg.PprofAddr == cfg.GRPCAddr) {
+		return errors.New("pprof_addr must differ from addr and grpc_addr")
+	}
+	if (cfg.Server.TLSCertFile == "") != (cfg.Server.TLSKeyFile == "") {
+		return errors.New("server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	if cfg.Server.ReadHeaderTimeout < 0 || cfg.Server.ReadTimeout < 0 || cfg.Server.WriteTimeout < 0 || cfg.Server.IdleTimeout < 0 || cfg.Server.ShutdownTimeout < 0 || cfg.Server.GenerationTimeout < 0 {
+		return errors.New("server timeouts must not be negative")
+	}
+	if cfg.Server.MaxHeaderBytes < 0 {
+		return errors.New("server.max_header_bytes must not be negative")
+	}
+	if cfg.Server.PlanCacheSize < 0 {
+		return errors.New("server.plan_cache_size must not be negative")
+	}
+	if err := cfg.Log.validate(); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	if err := cfg.Tracing.validate(); err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	if err := cfg.generationOptions().Validate(); err != nil {
+		return fmt.Errorf("generation: %w", err)
+	}
+	switch cfg.Storage.Driver {
+	case "memory", "sqlite":
+	case "postgres":
+		if cfg.Storage.DSN == "" {
+			return errors.New("storage.dsn is required for the postgres storage driver")
+		}
+	default:
+		return fmt.Errorf("unknown storage.driver %q (expected memory, sqlite or postgres)", cfg.Storage.Driver)
+	}
+	if cfg.Nutrition.Timeout <= 0 {
+		return errors.New("nutrition.timeout must be positive")
+	}
+	if cfg.Nutrition.Tolerance < 0 {
+		return errors.New("nutrition.tolerance must not be negative")
+	}
+	if cfg.Feedback.LearningRate <= 0 || cfg.Feedback.LearningRate > 1 {
+		return errors.New("feedback.learning_rate must be greater than 0 and at most 1")
+	}
+	if err := cfg.Auth.validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	if err := cfg.RateLimit.validate(); err != nil {
+		return fmt.Errorf("rate_limit: %w", err)
+	}
+	if err := cfg.CORS.validate(); err != nil {
+		return fmt.Errorf("cors: %w", err)
+	}
+	if err := cfg.SMTP.validate(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := cfg.Notify.validate(cfg.SMTP); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	if err := cfg.Schedule.validate(cfg.Tenants); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	return cfg.validateTenants()
+}
+
+// validateTenants checks the settings of every tenant and that no two
+// tenants share a database. The tables have no tenant column, so tenants on
+// one Postgres server need a database or schema of their own.
+func (cfg Config) validateTenants() error {
+	databases := map[string]string{}
+	if cfg.Storage.Driver != "memory" {
+		databases[cfg.Storage.database()] = "the top level"
+	}
+	names := make([]string, 0, len(cfg.Tenants))
+	for name := range cfg.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !tenantNamePattern.MatchString(name) {
+			return fmt.Errorf("tenant name %q may only contain letters, digits, '-' and '_'", name)
+		}
+		tc, err := cfg.tenantConfig(name)
+		if err != nil {
+			return err
+		}
+		if err := tc.validate(); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+		if tc.Storage.Driver == "memory" {
+			continue
+		}
+		database := tc.Storage.database()
+		if other, ok := databases[database]; ok {
+			if tc.Storage.Driver == "postgres" {
+				return fmt.Errorf("tenant %s: storage is already used by %s; give the tenant a dsn with a database or search_path of its own", name, other)
+			}
+			return fmt.Errorf("tenant %s: storage is already used by %s", name, other)
+		}
+		databases[database] = "tenant " + name
+	}
+	return nil
+}
+
+// database identifies the database the storage settings point at, so that
+// two spellings of one database, such as a relative and an absolute SQLite
+// path or a Postgres URL and its key=value form, are told apart from two
+// databases. A Postgres database is its host, port, name and search_path.
+func (s StorageConfig) database() string {
+	switch s.Driver {
+	case "sqlite":
+		path, _, _ := strings.Cut(strings.TrimPrefix(cmp.Or(s.DSN, defaultSQLitePath), "file:"), "?")
+		if abs, err := filepath.Abs(path); err == nil {
+			return s.Driver + ":" + abs
+		}
+	case "postgres":
+		dsn := s.DSN
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			var err error
+			if dsn, err = pq.ParseURL(dsn); err != nil {
+				break
+			}
+		}
+		params := map[string]string{"host": "localhost", "port": "5432"}
+		for _, field := range strings.Fields(dsn) {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				params[key] = strings.Trim(value, "'")
+			}
+		}
+		return fmt.Sprintf("%s:%s:%s/%s?search_path=%s", s.Driver, params["host"], params["port"], cmp.Or(params["dbname"], params["user"]), params["search_path"])
+	}
+	return s.Driver + ":" + s.DSN
+}
+
+// generationOptions converts the configured generation defaults into GenerationOptions.
+func (cfg Config) generationOptions() planner.GenerationOptions {
+	return planner.GenerationOptions{
+		Days:                cfg.Generation.Days,
+		CombosPerDay:        cfg.Generation.CombosPerDay,
+		MinCalories:         cfg.Generation.MinCalories,
+		MaxCalories:         cfg.Generation.MaxCalories,
+		PopularityTolerance: cfg.Generation.PopularityTolerance,
+		RepeatWindow:        cfg.Generation.RepeatWindow,
+		MealSlots:           cfg.Generation.MealSlots,
+		Template:            cfg.Generation.Template,
+		DayTemplates:        cfg.Generation.DayTemplates,
+		Strategy:            cfg.Generation.Strategy,
+		Selection:           cfg.Generation.Selection,
+		Temperature:         cfg.Generation.Temperature,
+		ScoreWeights:        cfg.Generation.ScoreWeights,
+		Closures:            cfg.Generation.Closures,
+		RuleDefs:            cfg.Generation.rules,
+		HealthRubric:        cfg.HealthRubric,
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tenantTestConfig loads the defaults overlaid with the YAML settings in
+// body, the way loadConfig does for a config file.
+func tenantTestConfig(t *testing.T, body string) Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	if err := cfg.loadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidateTenantDatabases(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "postgres tenant inherits the top-level dsn",
+			config: `
+storage: {driver: postgres, dsn: "postgres://planner@db/planner"}
+tenants:
+  acme: {}
+`,
+			wantErr: "tenant acme: storage is already used by the top level; give the tenant a dsn",
+		},
+		{
+			name: "postgres tenants share a database spelled differently",
+			config: `
+storage: {driver: memory}
+tenants:
+  acme: {storage: {driver: postgres, dsn: "postgres://planner@db/planner"}}
+  globex: {storage: {driver: postgres, dsn: "host=db port=5432 user=planner dbname=planner sslmode=disable"}}
+`,
+			wantErr: "tenant globex: storage is already used by tenant acme",
+		},
+		{
+			name: "postgres tenants with a search_path each",
+			config: `
+storage: {driver: postgres, dsn: "postgres://planner@db/planner"}
+tenants:
+  acme: {storage: {dsn: "postgres://planner@db/planner?search_path=acme"}}
+  globex: {storage: {dsn: "postgres://planner@db/planner?search_path=globex"}}
+`,
+		},
+		{
+			name: "postgres tenants with a database each",
+			config: `
+storage: {driver: memory}
+tenants:
+  acme: {storage: {driver: postgres, dsn: "postgres://planner@db/acme"}}
+  globex: {storage: {driver: postgres, dsn: "postgres://planner@db/globex"}}
+`,
+		},
+		{
+			name: "sqlite tenants default to a file each",
+			config: `
+storage: {driver: sqlite}
+tenants:
+  acme: {}
+  globex: {}
+`,
+		},
+		{
+			name: "sqlite tenants share a file spelled differently",
+			config: `
+storage: {driver: memory}
+tenants:
+  acme: {storage: {driver: sqlite, dsn: "./data/shared.db"}}
+  globex: {storage: {driver: sqlite, dsn: "file:data/shared.db?_busy_timeout=5000"}}
+`,
+			wantErr: "tenant globex: storage is already used by tenant acme",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tenantTestConfig(t, tt.config).validateTenants()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validateTenants: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validateTenants = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenantsOnOneDriverAreIsolated(t *testing.T) {
+	dir := t.TempDir()
+	cfg := tenantTestConfig(t, `
+menu_path: ../data/master_menu.json
+storage: {driver: sqlite, dsn: "`+filepath.Join(dir, "planner.db")+`"}
+tenants:
+  acme: {storage: {dsn: "`+filepath.Join(dir, "acme.db")+`"}}
+  globex: {storage: {dsn: "`+filepath.Join(dir, "globex.db")+`"}}
+`)
+	if err := cfg.validateTenants(); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := openTenants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeTenants(opened)
+
+	acme, globex := opened["acme"].storage, opened["globex"].storage
+	items, _, err := acme.LoadMenu()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acme.SaveMenu(items[:1]); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := globex.LoadMenu()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(items) {
+		t.Errorf("globex menu has %d items after acme saved 1, want its own %d", len(got), len(items))
+	}
+}
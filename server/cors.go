@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lets browser frontends on other origins call the API. It is
+// disabled when AllowedOrigins is empty.
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as "https://menu.example.com"; "*"
+	// allows every origin.
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods" yaml:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers" yaml:"allowed_headers"`
+	// MaxAge is how long browsers may cache the answer to a preflight request.
+	MaxAge Duration `json:"max_age" yaml:"max_age"`
+}
+
+// defaultCORSConfig allows the methods and headers the API uses, from no origin.
+var defaultCORSConfig = CORSConfig{
+	AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+	AllowedHeaders: []string{"Content-Type", "Accept", "Authorization", "If-None-Match", "X-API-Key", tenantHeader, requestIDHeader, traceparentHeader},
+	MaxAge:         Duration(10 * time.Minute),
+}
+
+// corsExposedHeaders are response headers browsers may show to scripts.
+var corsExposedHeaders = []string{"Content-Disposition", "ETag", "Retry-After", requestIDHeader}
+
+// validate reports the first setting that cannot be used.
+func (cfg CORSConfig) validate() error {
+	for _, origin := range cfg.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return errors.New("allowed_origins must be \"*\" or start with http:// or https://")
+		}
+	}
+	if cfg.MaxAge < 0 {
+		return errors.New("max_age must not be negative")
+	}
+	return nil
+}
+
+// allowsOrigin reports whether requests from origin may be answered.
+func (cfg CORSConfig) allowsOrigin(origin string) bool {
+	return slices.ContainsFunc(cfg.AllowedOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// withCORS wraps next with CORS handling for cfg: responses to allowed
+// origins carry the Access-Control-Allow-Origin header, and preflight
+// requests are answered directly without reaching next.
+func withCORS(cfg CORSConfig, next http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !cfg.allowsOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+		if cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(time.Duration(cfg.MaxAge).Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/planner"
+)
+
+// SMTPConfig configures the mail server plans are emailed through. STARTTLS
+// is used whenever the server offers it.
+type SMTPConfig struct {
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	// From is the sender address, e.g. "Cafeteria <menu@example.com>".
+	From    string   `json:"from" yaml:"from"`
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+}
+
+// EmailNotifyConfig emails every generated plan, rendered as an HTML table,
+// to the To addresses. It is disabled when To is empty.
+type EmailNotifyConfig struct {
+	To []string `json:"to" yaml:"to"`
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg SMTPConfig) validate() error {
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return fmt.Errorf("port %d must be between 1 and 65535", cfg.Port)
+	}
+	if cfg.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if cfg.From != "" {
+		if _, err := mail.ParseAddress(cfg.From); err != nil {
+			return fmt.Errorf("invalid from address %q: %w", cfg.From, err)
+		}
+	}
+	return nil
+}
+
+// smtpMailer sends HTML email through the configured SMTP server.
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+// mailer sends plan emails; it is nil when no SMTP server is configured.
+var mailer *smtpMailer
+
+// newSMTPMailer returns a mailer for cfg, or nil when no host is configured.
+func newSMTPMailer(cfg SMTPConfig) *smtpMailer {
+	if cfg.Host == "" {
+		return nil
+	}
+	return &smtpMailer{cfg: cfg}
+}
+
+// emailPlan emails the plan to the tenant's recipients in the background.
+func emailPlan(t *tenant, plan planner.MenuPlan, logger *slog.Logger) {
+	if len(t.notify.Email.To) == 0 || mailer == nil {
+		return
+	}
+	logger = logger.With("plan_id", plan.PlanID)
+	var body bytes.Buffer
+	if err := writePlanHTML(&body, plan); err != nil {
+		logger.Error("rendering plan email failed", "error", err)
+		return
+	}
+	subject := fmt.Sprintf("Menu plan %s: %d days", plan.PlanID, len(plan.MenuPlan))
+	if t.name != "" {
+		subject = fmt.Sprintf("[%s] %s", t.name, subject)
+	}
+	pendingNotifications.Add(1)
+	go func() {
+		defer pendingNotifications.Done()
+		if err := mailer.send(t.notify.Email.To, subject, body.Bytes()); err != nil {
+			logger.Error("emailing plan failed", "error", err)
+			return
+		}
+		logger.Info("plan emailed", "recipients", len(t.notify.Email.To))
+	}()
+}
+
+// send delivers an HTML message to the recipients.
+func (m *smtpMailer) send(to []string, subject string, html []byte) error {
+	timeout := time.Duration(m.cfg.Timeout)
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port)), timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+	from, _ := mail.ParseAddress(m.cfg.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		addr, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("recipient %s: %w", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(htmlMessage(m.cfg.From, to, subject, html)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// htmlMessage formats an HTML email with its headers.
+func htmlMessage(from string, to []string, subject string, html []byte) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	qp.Write(html)
+	qp.Close()
+	return msg.Bytes()
+}
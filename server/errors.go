@@ -0,0 +1,189 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"task/menu"
+	"task/planner"
+)
+
+// errorCodes name the kind of failure of each status the API answers with,
+// so clients can branch on the code rather than parse the message.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_request",
+	http.StatusUnauthorized:        "unauthenticated",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusMethodNotAllowed:    "method_not_allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusUnprocessableEntity: "infeasible_constraints",
+	http.StatusUpgradeRequired:     "upgrade_required",
+	http.StatusTooManyRequests:     "rate_limited",
+	http.StatusInternalServerError: "internal",
+	http.StatusServiceUnavailable:  "unavailable",
+	http.StatusGatewayTimeout:      "timeout",
+}
+
+// errorResponse is the JSON body of every error response:
+//
+//	{"error": {"code": "invalid_request", "message": "...", "details": [...]}}
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// apiError describes why a request failed.
+type apiError struct {
+	// Code is a stable name for the kind of failure, such as not_found.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details lists the individual problems behind the failure, if any.
+	Details []errorDetail `json:"details,omitempty"`
+}
+
+// errorDetail is one problem behind a failed request.
+type errorDetail struct {
+	// Field is the request parameter or body field at fault, if any.
+	Field string `json:"field,omitempty"`
+	// Reason is a stable name for the problem, such as no_candidates.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// newAPIError returns the error answered with status and message.
+func newAPIError(status int, message string) apiError {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "error"
+	}
+	return apiError{Code: code, Message: message}
+}
+
+// writeError answers the request with status and an error body carrying
+// message. It takes the arguments of http.Error.
+func writeError(w http.ResponseWriter, message string, status int) {
+	writeAPIError(w, status, newAPIError(status, message))
+}
+
+// withRouteErrors serves requests through mux, answering those it has no
+// route for, or whose route does not allow their method, with the JSON error
+// body rather than the mux's plain-text one. The Allow header of a 405 is
+// kept.
+func withRouteErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := &routeErrorRecorder{header: make(http.Header)}
+		h.ServeHTTP(rec, r)
+		if rec.status != http.StatusMethodNotAllowed {
+			writeError(w, fmt.Sprintf("no route for %s", r.URL.Path), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Allow", rec.header.Get("Allow"))
+		writeError(w, fmt.Sprintf("method %s is not allowed for %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+	})
+}
+
+// routeErrorRecorder keeps the status and headers of the mux's own 404 and
+// 405 responses and drops their bodies.
+type routeErrorRecorder struct {
+	header http.Header
+	status int
+}
+
+func (rec *routeErrorRecorder) Header() http.Header         { return rec.header }
+func (rec *routeErrorRecorder) WriteHeader(status int)      { rec.status = status }
+func (rec *routeErrorRecorder) Write(b []byte) (int, error) { return len(b), nil }
+
+// writeAPIError answers the request with status and err as the error body.
+func writeAPIError(w http.ResponseWriter, status int, err apiError) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, status, errorResponse{Error: err})
+}
+
+// infeasibleError is returned by menuGeneration.run for a plan that got no
+// combo at all, because nothing on the menu satisfies its constraints.
+type infeasibleError struct {
+	warnings []planner.PlanWarning
+}
+
+func (e infeasibleError) Error() string {
+	return "No combo on the menu satisfies the constraints."
+}
+
+// details lists the shortfall of each meal of the plan.
+func (e infeasibleError) details() []errorDetail {
+	details := make([]errorDetail, len(e.warnings))
+	for i, w := range e.warnings {
+		details[i] = errorDetail{Reason: w.Reason, Message: w.Message}
+	}
+	return details
+}
+
+// invalidMenuError lists the errors that make menu items unusable, one per
+// field at fault, as found by menu.Diagnose or menu.CheckItem.
+type invalidMenuError struct {
+	// field is the request field holding the items, if any; single is set
+	// for the problems of a single item.
+	field    string
+	single   bool
+	problems []menu.Diagnostic
+}
+
+func (e invalidMenuError) Error() string {
+	messages := make([]string, len(e.problems))
+	for i, d := range e.problems {
+		messages[i] = d.String()
+	}
+	return "invalid " + e.noun() + ": " + strings.Join(messages, "; ")
+}
+
+// noun names what is invalid.
+func (e invalidMenuError) noun() string {
+	if e.single {
+		return "menu item"
+	}
+	return "menu"
+}
+
+// details names the field of each problem, such as menu_items[2].calories.
+func (e invalidMenuError) details() []errorDetail {
+	details := make([]errorDetail, len(e.problems))
+	for i, d := range e.problems {
+		field := d.Field
+		if d.Item > 0 {
+			field = fmt.Sprintf("%s[%d]", e.field, d.Item-1)
+			if d.Field != "" {
+				field += "." + d.Field
+			}
+		}
+		details[i] = errorDetail{Field: field, Message: d.String()}
+	}
+	return details
+}
+
+// checkMenu returns an invalidMenuError naming field when items has errors.
+func checkMenu(field string, items []menu.Item) error {
+	if report := menu.Diagnose(items); !report.Valid {
+		return invalidMenuError{field: field, problems: report.Errors}
+	}
+	return nil
+}
+
+// writeBadRequest answers 400 for err, listing the fields at fault when it
+// is an invalidMenuError.
+func writeBadRequest(w http.ResponseWriter, err error) {
+	apiErr := newAPIError(http.StatusBadRequest, err.Error())
+	var invalid invalidMenuError
+	if errors.As(err, &invalid) {
+		apiErr.Message = fmt.Sprintf("The %s has %d problem(s); see details.", invalid.noun(), len(invalid.problems))
+		apiErr.Details = invalid.details()
+	}
+	writeAPIError(w, http.StatusBadRequest, apiErr)
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRouteErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /plans/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := withRouteErrors(mux)
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code, allow  string
+	}{
+		{http.MethodGet, "/plans/abc", http.StatusOK, "", ""},
+		{http.MethodDelete, "/plans/abc", http.StatusMethodNotAllowed, "method_not_allowed", "GET, HEAD"},
+		{http.MethodGet, "/nope", http.StatusNotFound, "not_found", ""},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s answered %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+			continue
+		}
+		if allow := rec.Header().Get("Allow"); allow != tc.allow {
+			t.Errorf("%s %s has Allow %q, want %q", tc.method, tc.path, allow, tc.allow)
+		}
+		if tc.code == "" {
+			continue
+		}
+		var body errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Errorf("%s %s: decoding error body: %v", tc.method, tc.path, err)
+		} else if body.Error.Code != tc.code {
+			t.Errorf("%s %s has error code %q, want %q", tc.method, tc.path, body.Error.Code, tc.code)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package server
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+// Supported values for the format query parameter on /generate-menu.
+const (
+	formatJSON = "json"
+	formatZip  = "zip"
+	formatCSV  = "csv"
+	// formatMarkdown renders a Markdown table per day for wikis and chat.
+	formatMarkdown = "markdown"
+)
+
+// Supported values for the entry_format query parameter used with format=zip.
+const (
+	entryFormatJSON = "json"
+	entryFormatCSV  = "csv"
+)
+
+// validateOutputFormat checks the format and entry_format query parameters,
+// returning the normalized values.
+func validateOutputFormat(format, entryFormat string) (string, string, error) {
+	if format == "" {
+		format = formatJSON
+	}
+	if entryFormat == "" {
+		entryFormat = entryFormatJSON
+	}
+	switch format {
+	case formatJSON, formatZip, formatCSV, formatMarkdown:
+	case "md":
+		format = formatMarkdown
+	default:
+		return "", "", fmt.Errorf("unsupported format %q (expected json, csv, markdown or zip)", format)
+	}
+	switch entryFormat {
+	case entryFormatJSON, entryFormatCSV:
+	default:
+		return "", "", fmt.Errorf("unsupported entry_format %q (expected json or csv)", entryFormat)
+	}
+	return format, entryFormat, nil
+}
+
+// requestedOutputFormat returns the format and entry_format a request asks
+// for. Without a format query parameter, an Accept header naming CSV or
+// Markdown selects that format.
+func requestedOutputFormat(r *http.Request) (string, string, error) {
+	format := r.URL.Query().Get("format")
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if format != "" {
+			break
+		}
+		accepted = strings.TrimSpace(accepted)
+		if menu.IsCSVContentType(accepted) {
+			format = formatCSV
+		} else if mediaType, _, err := mime.ParseMediaType(accepted); err == nil && mediaType == "text/markdown" {
+			format = formatMarkdown
+		}
+	}
+	return validateOutputFormat(format, r.URL.Query().Get("entry_format"))
+}
+
+// writeMenuPlan writes the plan to the response in the requested format,
+// with its calories in the unit its options ask for.
+func writeMenuPlan(w http.ResponseWriter, plan planner.MenuPlan, format, entryFormat string) error {
+	plan = planner.ReportedEnergy(plan)
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="menu_plan.csv"`)
+		return writeMenuPlanCSV(w, plan)
+	case formatMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		return writeMenuPlanMarkdown(w, plan)
+	case formatZip:
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="menu_plan.zip"`)
+		return writeMenuPlanZip(w, plan, entryFormat)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		return encodeMenuPlanJSON(w, plan, "")
+	}
+}
+
+// energyUnit returns the label of a plan's EnergyUnit, which is empty for kcal.
+func energyUnit(unit string) string {
+	return cmp.Or(unit, menu.UnitKcal)
+}
+
+// encodeMenuPlanJSON writes plan to w as json.Encoder would, indenting
+// nested values by indent when it is not empty. The days are encoded and
+// written one at a time, so the encoded form of a month-long plan is never
+// held in memory as a whole alongside it.
+func encodeMenuPlanJSON(w io.Writer, plan planner.MenuPlan, indent string) error {
+	head, tail, err := splitMenuPlanJSON(plan, indent)
+	if err != nil {
+		return err
+	}
+	days := plan.MenuPlan
+	bw := bufio.NewWriter(w)
+	bw.Write(head)
+	if days == nil {
+		bw.WriteString("null")
+	} else {
+		bw.WriteString("[")
+		for j, day := range days {
+			if j > 0 {
+				bw.WriteString(",")
+			}
+			var data []byte
+			if indent == "" {
+				data, err = json.Marshal(day)
+			} else {
+				bw.WriteString("\n" + indent + indent)
+				data, err = json.MarshalIndent(day, indent+indent, indent)
+			}
+			if err != nil {
+				return err
+			}
+			bw.Write(data)
+		}
+		if indent != "" && len(days) > 0 {
+			bw.WriteString("\n" + indent)
+		}
+		bw.WriteString("]")
+	}
+	bw.Write(tail)
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// splitMenuPlanJSON encodes plan without its days and returns the encoding
+// split around the value of menu_plan: head ends with the menu_plan key and
+// tail starts after its value.
+func splitMenuPlanJSON(plan planner.MenuPlan, indent string) (head, tail []byte, err error) {
+	plan.MenuPlan = nil
+	var data []byte
+	marker := []byte(`"menu_plan":null`)
+	if indent == "" {
+		data, err = json.Marshal(plan)
+	} else {
+		data, err = json.MarshalIndent(plan, "", indent)
+		marker = []byte(`"menu_plan": null`)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// The key cannot occur elsewhere: quotes inside strings are escaped.
+	i := bytes.Index(data, marker)
+	if i < 0 {
+		return nil, nil, errors.New("encoding menu plan: menu_plan field not found")
+	}
+	return data[:i+len(marker)-len("null")], data[i+len(marker):], nil
+}
+
+// planStreamWriter writes a plan as compact JSON while it is generated:
+// each day as soon as it is done, then the plan's other fields, which are
+// only known once every day is. The days therefore come first in the
+// document rather than in field order.
+type planStreamWriter struct {
+	w    *bufio.Writer
+	days int
+}
+
+func newPlanStreamWriter(w io.Writer) *planStreamWriter {
+	return &planStreamWriter{w: bufio.NewWriter(w)}
+}
+
+// writeDay writes the next day of the plan.
+func (pw *planStreamWriter) writeDay(day planner.DailyMenu) error {
+	data, err := json.Marshal(day)
+	if err != nil {
+		return err
+	}
+	if pw.days == 0 {
+		pw.w.WriteString(`{"menu_plan":[`)
+	} else {
+		pw.w.WriteString(",")
+	}
+	pw.days++
+	_, err = pw.w.Write(data)
+	return err
+}
+
+// finish writes the fields of plan other than its days, which were written
+// by writeDay, and ends the document.
+func (pw *planStreamWriter) finish(plan planner.MenuPlan) error {
+	head, tail, err := splitMenuPlanJSON(plan, "")
+	if err != nil {
+		return err
+	}
+	if pw.days == 0 {
+		pw.w.WriteString(`{"menu_plan":[`)
+	}
+	pw.w.WriteString("]")
+	// head is the fields before menu_plan, from "{" to the menu_plan key.
+	if fields := bytes.TrimSuffix(head[1:len(head)-len(`"menu_plan":`)], []byte(",")); len(fields) > 0 {
+		pw.w.WriteString(",")
+		pw.w.Write(fields)
+	}
+	pw.w.Write(tail)
+	pw.w.WriteString("\n")
+	return pw.w.Flush()
+}
+
+// writeMenuPlanZip packages one file per day of the plan into a zip archive.
+// Entries are named by position and day, e.g. "01_monday.json".
+func writeMenuPlanZip(w io.Writer, plan planner.MenuPlan, entryFormat string) error {
+	zw := zip.NewWriter(w)
+	modified := time.Now()
+	for i, day := range plan.MenuPlan {
+		name := fmt.Sprintf("%02d_%s.%s", i+1, strings.ToLower(day.Day), entryFormat)
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return fmt.Errorf("failed to create zip entry %s: %w", name, err)
+		}
+		if entryFormat == entryFormatCSV {
+			err = writeDailyMenuCSV(entry, day)
+		} else {
+			enc := json.NewEncoder(entry)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(day)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write zip entry %s: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// menuCSVHeader names the columns written by writeDailyMenuCSV and writeMenuPlanCSV.
+var menuCSVHeader = []string{"day", "date", "meal", "combo_id", "main", "side", "drink", "items", "calorie_count", "popularity_score", "health_grade", "price", "reasoning"}
+
+// writeDailyMenuCSV writes the combos of a single day as CSV rows with a header.
+func writeDailyMenuCSV(w io.Writer, day planner.DailyMenu) error {
+	cw := csv.NewWriter(w)
+	cw.Write(menuCSVHeader)
+	writeComboRows(cw, day)
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMenuPlanCSV flattens the whole plan into one CSV table, one row per
+// combo, under a single header.
+func writeMenuPlanCSV(w io.Writer, plan planner.MenuPlan) error {
+	cw := csv.NewWriter(w)
+	cw.Write(menuCSVHeader)
+	for _, day := range plan.MenuPlan {
+		writeComboRows(cw, day)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeComboRows writes one CSV row per combo of day.
+func writeComboRows(cw *csv.Writer, day planner.DailyMenu) {
+	for _, combo := range day.Combos {
+		cw.Write([]string{
+			day.Day,
+			day.Date,
+			combo.Meal,
+			combo.ComboID,
+			combo.Main,
+			combo.Side,
+			combo.Drink,
+			comboItemNames(combo),
+			strconv.Itoa(combo.CalorieCount),
+			strconv.FormatFloat(combo.PopularityAvg, 'f', 2, 64),
+			combo.HealthGrade,
+			strconv.FormatFloat(combo.Price, 'f', 2, 64),
+			combo.Reasoning,
+		})
+	}
+}
+
+// dayLabel names a day of a plan by its weekday and, when it has one, its date.
+func dayLabel(day planner.DailyMenu) string {
+	if day.Date == "" {
+		return day.Day
+	}
+	return day.Day + ", " + day.Date
+}
+
+// closureNote describes the service of a day falling on closure c.
+func closureNote(c planner.Closure) string {
+	note := "Closed"
+	if c.Combos > 0 {
+		noun := "combos"
+		if c.Combos == 1 {
+			noun = "combo"
+		}
+		note = fmt.Sprintf("Reduced service, %d %s per meal", c.Combos, noun)
+	}
+	if c.Reason != "" {
+		note += ": " + c.Reason
+	}
+	return note + "."
+}
+
+// comboItemNames lists every item of a combo, separated by semicolons.
+func comboItemNames(combo planner.Combo) string {
+	names := make([]string, len(combo.Components))
+	for i, component := range combo.Components {
+		names[i] = component.ItemName
+		if component.Portion != "" {
+			names[i] += " (" + component.Portion + ")"
+		}
+	}
+	return strings.Join(names, ";")
+}
+
+// markdownEscaper keeps cell text from breaking a Markdown table row.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// writeMenuPlanMarkdown writes the plan as a heading and Markdown table per day.
+func writeMenuPlanMarkdown(w io.Writer, plan planner.MenuPlan) error {
+	var b strings.Builder
+	b.WriteString("# Menu plan")
+	if plan.PlanID != "" {
+		b.WriteString(" " + plan.PlanID)
+	}
+	fmt.Fprintf(&b, "\n\n%d days, %d %s in total (%.0f a day on average)", len(plan.MenuPlan), plan.TotalCalories, energyUnit(plan.EnergyUnit), plan.Nutrition.DailyAverage.Calories)
+	if plan.TotalPrice > 0 {
+		fmt.Fprintf(&b, ", %.2f total price", plan.TotalPrice)
+	}
+	b.WriteString(".\n")
+	for _, day := range plan.MenuPlan {
+		fmt.Fprintf(&b, "\n## %s\n\n", dayLabel(day))
+		if c := day.Closure; c != nil {
+			b.WriteString(closureNote(*c) + "\n")
+			if len(day.Combos) == 0 {
+				continue
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("| Combo | Meal | Items | Calories | Popularity | Grade | Price |\n")
+		b.WriteString("| --- | --- | --- | ---: | ---: | :---: | ---: |\n")
+		for _, combo := range day.Combos {
+			items := strings.Join(strings.Split(comboItemNames(combo), ";"), ", ")
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %.2f | %s | %.2f |\n",
+				markdownEscaper.Replace(combo.ComboID), markdownEscaper.Replace(combo.Meal), markdownEscaper.Replace(items),
+				combo.CalorieCount, combo.PopularityAvg, combo.HealthGrade, combo.Price)
+		}
+		fmt.Fprintf(&b, "\n**Total:** %d %s, %.2f\n", day.TotalCalories, energyUnit(plan.EnergyUnit), day.TotalPrice)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"task/planner"
+)
+
+func TestWriteMenuPlanZip(t *testing.T) {
+	var plan planner.MenuPlan
+	for i, name := range []string{"Monday", "Tuesday", "Wednesday"} {
+		plan.MenuPlan = append(plan.MenuPlan, planner.DailyMenu{
+			Day:  name,
+			Date: fmt.Sprintf("2026-10-%02d", 12+i),
+			Combos: []planner.Combo{{
+				ComboID:      fmt.Sprintf("combo_%d", i+1),
+				Main:         "Dal",
+				Side:         "Rice",
+				Drink:        "Lassi",
+				CalorieCount: 650,
+				HealthGrade:  "B",
+				Components: []planner.ComboComponent{
+					{Category: "main", ItemName: "Dal"},
+					{Category: "side", ItemName: "Rice"},
+					{Category: "drink", ItemName: "Lassi"},
+				},
+			}},
+		})
+	}
+
+	for _, entryFormat := range []string{entryFormatJSON, entryFormatCSV} {
+		t.Run(entryFormat, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeMenuPlanZip(&buf, plan, entryFormat); err != nil {
+				t.Fatal(err)
+			}
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("reading zip: %v", err)
+			}
+			if len(zr.File) != len(plan.MenuPlan) {
+				t.Fatalf("zip has %d entries, want one per day (%d)", len(zr.File), len(plan.MenuPlan))
+			}
+			for i, f := range zr.File {
+				day := plan.MenuPlan[i]
+				want := []string{"01_monday", "02_tuesday", "03_wednesday"}[i] + "." + entryFormat
+				if f.Name != want {
+					t.Errorf("entry %d is named %q, want %q", i, f.Name, want)
+				}
+				r, err := f.Open()
+				if err != nil {
+					t.Fatalf("opening %s: %v", f.Name, err)
+				}
+				if entryFormat == entryFormatJSON {
+					var decoded planner.DailyMenu
+					if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+						t.Fatalf("decoding %s: %v", f.Name, err)
+					}
+					if decoded.Day != day.Day || len(decoded.Combos) != len(day.Combos) {
+						t.Errorf("%s holds %s with %d combos, want %s with %d", f.Name, decoded.Day, len(decoded.Combos), day.Day, len(day.Combos))
+					}
+				} else {
+					rows, err := csv.NewReader(r).ReadAll()
+					if err != nil {
+						t.Fatalf("decoding %s: %v", f.Name, err)
+					}
+					if len(rows) != 1+len(day.Combos) || !slices.Equal(rows[0], menuCSVHeader) {
+						t.Fatalf("%s has %d rows starting with %v, want the header and %d combos", f.Name, len(rows), rows[0], len(day.Combos))
+					}
+					if rows[1][0] != day.Day {
+						t.Errorf("%s row is for %s, want %s", f.Name, rows[1][0], day.Day)
+					}
+				}
+				r.Close()
+			}
+		})
+	}
+}
+
+func TestPlanStreamWriterMatchesEncoder(t *testing.T) {
+	plan := planner.MenuPlan{
+		Seed:          7,
+		CalorieWindow: planner.CalorieWindow{MinCalories: 550, MaxCalories: 800},
+		TotalCalories: 1300,
+		Warnings:      []planner.PlanWarning{{Day: 2, DayName: "Tuesday", Generated: 1, Expected: 3, Reason: "no_candidates"}},
+	}
+	for _, name := range []string{"Monday", "Tuesday"} {
+		plan.MenuPlan = append(plan.MenuPlan, planner.DailyMenu{Day: name, Combos: []planner.Combo{{ComboID: "combo_1", Main: "Dal", CalorieCount: 650}}})
+	}
+
+	var encoded, streamed bytes.Buffer
+	if err := encodeMenuPlanJSON(&encoded, plan, ""); err != nil {
+		t.Fatal(err)
+	}
+	pw := newPlanStreamWriter(&streamed)
+	for _, day := range plan.MenuPlan {
+		if err := pw.writeDay(day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary := plan
+	summary.MenuPlan = nil
+	if err := pw.finish(summary); err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got map[string]any
+	if err := json.Unmarshal(encoded.Bytes(), &want); err != nil {
+		t.Fatalf("decoding encoded plan: %v", err)
+	}
+	if err := json.Unmarshal(streamed.Bytes(), &got); err != nil {
+		t.Fatalf("decoding streamed plan %s: %v", streamed.Bytes(), err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streamed plan\n%s\ndiffers from encoded plan\n%s", streamed.Bytes(), encoded.Bytes())
+	}
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"task/planner"
+)
+
+// feasibilityHandler handles GET and POST /feasibility. It takes the same
+// settings as /generate-menu and reports whether the plan they describe can
+// be generated in full, without generating or storing it.
+func feasibilityHandler(w http.ResponseWriter, r *http.Request) {
+	gen, ok := prepareGeneration(w, r)
+	if !ok {
+		return
+	}
+	report := planner.AnalyzeFeasibility(r.Context(), gen.items, gen.opts, gen.index)
+	if !report.Feasible {
+		requestLogger(r).Info("plan is not feasible", "problems", len(report.Problems))
+	}
+	writeJSON(w, http.StatusOK, report)
+}
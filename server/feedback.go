@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"task/menu"
+	"task/planner"
+)
+
+// Thumbs given to a whole combo in a ComboVote.
+const (
+	voteUp   = "up"
+	voteDown = "down"
+)
+
+var errInvalidFeedback = errors.New("invalid feedback")
+
+// Feedback is a batch of diner reactions posted to /feedback.
+type Feedback struct {
+	Ratings []ItemRating `json:"ratings"`
+	Votes   []ComboVote  `json:"votes"`
+}
+
+// ItemRating rates a single menu item from 1 (disliked) to 5 stars.
+type ItemRating struct {
+	ItemName string `json:"item_name"`
+	Rating   int    `json:"rating"`
+}
+
+// ComboVote gives a thumbs up or down to a combo of a stored plan, which
+// counts as feedback on each of its items.
+type ComboVote struct {
+	PlanID  string `json:"plan_id"`
+	ComboID string `json:"combo_id"`
+	Vote    string `json:"vote"`
+}
+
+// PopularityChange reports how feedback moved an item's popularity score.
+type PopularityChange struct {
+	ItemName        string  `json:"item_name"`
+	PreviousScore   float64 `json:"previous_score"`
+	PopularityScore float64 `json:"popularity_score"`
+}
+
+// popularityTarget is the popularity score a piece of feedback implies for an item.
+type popularityTarget struct {
+	itemName string
+	score    float64
+}
+
+// targets converts the feedback into popularity targets, in the order given:
+// a rating of 1 to 5 stars maps linearly onto 0 to 1, and a thumbs up or
+// down to 1 or 0 for every item of the combo. Combos are looked up in the
+// plans kept by store.
+func (f Feedback) targets(store Storage) ([]popularityTarget, error) {
+	var targets []popularityTarget
+	for i, rating := range f.Ratings {
+		if rating.ItemName == "" {
+			return nil, fmt.Errorf("%w: rating %d: item_name is required", errInvalidFeedback, i+1)
+		}
+		if rating.Rating < 1 || rating.Rating > 5 {
+			return nil, fmt.Errorf("%w: rating %d: rating must be between 1 and 5", errInvalidFeedback, i+1)
+		}
+		targets = append(targets, popularityTarget{rating.ItemName, float64(rating.Rating-1) / 4})
+	}
+	for i, vote := range f.Votes {
+		var score float64
+		switch vote.Vote {
+		case voteUp:
+			score = 1
+		case voteDown:
+			score = 0
+		default:
+			return nil, fmt.Errorf("%w: vote %d: vote must be %q or %q", errInvalidFeedback, i+1, voteUp, voteDown)
+		}
+		plan, err := store.GetPlan(vote.PlanID)
+		if err != nil {
+			return nil, fmt.Errorf("vote %d: %w", i+1, err)
+		}
+		d, c, err := planner.FindCombo(plan, vote.ComboID)
+		if err != nil {
+			return nil, fmt.Errorf("vote %d: %w", i+1, err)
+		}
+		for _, component := range plan.MenuPlan[d].Combos[c].Components {
+			targets = append(targets, popularityTarget{component.ItemName, score})
+		}
+	}
+	return targets, nil
+}
+
+// adjustPopularity moves the popularity score of each target's item the
+// given fraction of the way towards the target score, one target after the
+// other, and returns the change of every item touched.
+func adjustPopularity(items []menu.Item, targets []popularityTarget, rate float64) ([]menu.Item, []PopularityChange, error) {
+	items = append([]menu.Item(nil), items...)
+	positions := make(map[string]int, len(items))
+	for i, item := range items {
+		positions[item.ItemName] = i
+	}
+	var changes []PopularityChange
+	changed := make(map[string]int) // item name -> position in changes
+	for _, target := range targets {
+		i, ok := positions[target.itemName]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q", errItemNotFound, target.itemName)
+		}
+		c, ok := changed[target.itemName]
+		if !ok {
+			c = len(changes)
+			changed[target.itemName] = c
+			changes = append(changes, PopularityChange{ItemName: target.itemName, PreviousScore: items[i].PopularityScore})
+		}
+		score := items[i].PopularityScore + rate*(target.score-items[i].PopularityScore)
+		items[i].PopularityScore = math.Round(min(max(score, 0), 1)*1e4) / 1e4
+		changes[c].PopularityScore = items[i].PopularityScore
+	}
+	return items, changes, nil
+}
+
+// feedbackHandler handles POST /feedback, folding item ratings and combo
+// votes into the stored popularity scores so that future plans favour what
+// diners liked.
+func feedbackHandler(w http.ResponseWriter, r *http.Request) {
+	var feedback Feedback
+	if err := json.NewDecoder(r.Body).Decode(&feedback); err != nil {
+		writeError(w, fmt.Sprintf("%v: %v", errInvalidFeedback, err), http.StatusBadRequest)
+		return
+	}
+	if len(feedback.Ratings) == 0 && len(feedback.Votes) == 0 {
+		writeError(w, "Feedback must contain at least one rating or vote.", http.StatusBadRequest)
+		return
+	}
+	t := currentTenant(r)
+	targets, err := feedback.targets(t.storage)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidFeedback):
+			writeError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, errPlanNotFound), errors.Is(err, planner.ErrComboNotFound):
+			writeError(w, err.Error(), http.StatusNotFound)
+		default:
+			requestLogger(r).Error("resolving feedback failed", "error", err)
+			writeError(w, "Unable to load the plans the feedback refers to.", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	changes, err := t.menu.AdjustPopularity(targets, t.learningRate)
+	if err != nil {
+		if !errors.Is(err, errItemNotFound) {
+			requestLogger(r).Error("applying feedback failed", "error", err)
+		}
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, changes)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"cmp"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// embeddedFrontend holds the static frontend assets, so the binary serves
+// them from any working directory.
+//
+//go:embed frontend
+var embeddedFrontend embed.FS
+
+// frontendHandler serves the frontend assets from dir, for editing them
+// without rebuilding, or from the copy embedded in the binary when dir is
+// empty. Paths with no asset behind them get the JSON not-found error of
+// the API.
+func frontendHandler(dir string) http.Handler {
+	assets := os.DirFS(dir)
+	if dir == "" {
+		var err error
+		if assets, err = fs.Sub(embeddedFrontend, "frontend"); err != nil {
+			panic(err)
+		}
+	}
+	files := http.FileServer(http.FS(assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if _, err := fs.Stat(assets, cmp.Or(name, ".")); errors.Is(err, fs.ErrNotExist) {
+			writeError(w, fmt.Sprintf("no route for %s", r.URL.Path), http.StatusNotFound)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
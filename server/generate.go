@@ -0,0 +1,408 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+// generateMenuRequest is the optional JSON body accepted by POST /generate-menu.
+type generateMenuRequest struct {
+	// PreferenceWeights maps item names to selection weights for this request only.
+	// Items without an entry have a weight of 1; catalog popularity is not affected.
+	PreferenceWeights map[string]float64 `json:"preference_weights"`
+	// MenuItems, when present, replaces the master menu on disk for this request.
+	MenuItems []menu.Item `json:"menu_items"`
+	// Seed, when set, overrides the seed query parameter.
+	Seed *int64 `json:"seed"`
+	// PopularityTolerance, when set, overrides the popularity_tolerance query parameter.
+	PopularityTolerance *float64 `json:"popularity_tolerance"`
+	// DietaryTags are required of every item in the plan, in addition to the dietary_tags query parameter.
+	DietaryTags []string `json:"dietary_tags"`
+	// DayDietaryTags maps day names to tags required only on that day, e.g. {"Thursday": ["vegetarian"]}.
+	DayDietaryTags map[string][]string `json:"day_dietary_tags"`
+	// ExcludeAllergens are allergens no item in the plan may contain, in addition to the exclude_allergens query parameter.
+	ExcludeAllergens []string `json:"exclude_allergens"`
+	// ComboMacros and DayMacros bound the macros of each combo and of each day's combos combined.
+	ComboMacros *planner.MacroTargets `json:"combo_macros"`
+	DayMacros   *planner.MacroTargets `json:"day_macros"`
+	// TastePreferences maps taste profiles to selection weights, e.g. {"spicy": 2.0, "sweet": 0.5}.
+	TastePreferences map[string]float64 `json:"taste_preferences"`
+	// ScoreWeights, when set, overrides the configured weights used to rank combos.
+	ScoreWeights *planner.ScoreWeights `json:"score_weights"`
+	// ItemUseLimits caps how often individual items may appear in the plan, overriding max_item_uses for them.
+	ItemUseLimits map[string]int `json:"item_use_limits"`
+	// Template, when set, overrides the combo template, e.g. "main+2 sides+drink".
+	Template planner.ComboTemplate `json:"template"`
+	// DayTemplates, when set, overrides the combo template of single days, e.g. {"Sunday": "main+side+drink+dessert"}.
+	DayTemplates map[string]planner.ComboTemplate `json:"day_templates"`
+	// MealSlots, when set, overrides the configured meal slots; each day is then filled meal by meal.
+	MealSlots []planner.MealSlot `json:"meal_slots"`
+	// CalorieSchedule gives each day its own calorie window, starting with the first day of the plan.
+	CalorieSchedule []planner.CalorieWindow `json:"calorie_schedule"`
+	// Closures, when set, overrides the configured closure dates.
+	Closures []planner.Closure `json:"closures"`
+	// Rules are declarative constraint rules checked in addition to the configured ones.
+	Rules []planner.RuleDef `json:"rules"`
+}
+
+// menuGeneration is a generation request whose settings have been read and
+// validated, ready to run.
+type menuGeneration struct {
+	tenant          *tenant
+	opts            planner.GenerationOptions
+	items           []menu.Item
+	index           *planner.ComboIndex
+	menuVersion     int
+	verifyNutrition bool
+}
+
+// generateMenuHandler is the HTTP handler for menu generation requests.
+// With stream=sse it delivers the plan day by day as Server-Sent Events,
+// and with stream=json it writes each day as it is generated; see
+// streamGenerationJSON.
+// Seeded plans are cached by tenant: a repeated request gets the plan
+// generated the first time, or 304 when its If-None-Match lists the ETag.
+func generateMenuHandler(w http.ResponseWriter, r *http.Request) {
+	gen, ok := prepareGeneration(w, r)
+	if !ok {
+		return
+	}
+	stream := r.URL.Query().Get("stream")
+	switch stream {
+	case "", streamJSON:
+	case streamSSE:
+		streamGenerationSSE(w, r, gen)
+		return
+	default:
+		writeError(w, fmt.Sprintf("Unknown stream %q; expected %q or %q.", stream, streamSSE, streamJSON), http.StatusBadRequest)
+		return
+	}
+	format, entryFormat, err := requestedOutputFormat(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if stream == streamJSON {
+		switch {
+		case format != formatJSON:
+			writeError(w, fmt.Sprintf("stream=%s only writes format=json.", streamJSON), http.StatusBadRequest)
+		case gen.verifyNutrition:
+			writeError(w, fmt.Sprintf("verify_nutrition needs the whole plan and cannot be used with stream=%s.", streamJSON), http.StatusBadRequest)
+		default:
+			streamGenerationJSON(w, r, gen)
+		}
+		return
+	}
+	key, cacheable := gen.cacheKey()
+	menuPlan, cached := planner.MenuPlan{}, false
+	if cacheable {
+		menuPlan, cached = gen.tenant.plans.get(key)
+	}
+	if cached {
+		planCacheLookups.add(1, "hit")
+		requestLogger(r).Info("plan served from cache", "plan_id", menuPlan.PlanID)
+	} else {
+		if cacheable {
+			planCacheLookups.add(1, "miss")
+		}
+		menuPlan, err = gen.run(r.Context())
+		if err != nil {
+			generationFailed(w, r, err)
+			return
+		}
+		if cacheable {
+			gen.tenant.plans.put(key, menuPlan)
+		}
+	}
+	if cacheable {
+		// Seeded plans are repeatable, so clients may revalidate them.
+		etag := planETag(menuPlan, format, entryFormat)
+		w.Header().Set("ETag", etag)
+		if cached && etagMatches(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	if err := writeMenuPlan(w, menuPlan, format, entryFormat); err != nil {
+		requestLogger(r).Error("writing menu plan failed", "error", err)
+	}
+}
+
+// prepareGeneration reads the settings of a generation request. They come
+// from query parameters (see planner.ParseOptions); POST additionally
+// accepts a generateMenuRequest body, or a CSV menu when the Content-Type is
+// text/csv. The profile query parameter applies a stored preference profile
+// on top of them, and menu_version generates from an earlier version of the
+// master menu. It writes an error response and reports false when the
+// settings cannot be used.
+func prepareGeneration(w http.ResponseWriter, r *http.Request) (menuGeneration, bool) {
+	var req generateMenuRequest
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if menu.IsCSVContentType(r.Header.Get("Content-Type")) {
+			// A CSV body is a menu upload; other settings come from the query string.
+			items, err := menu.ParseCSV(r.Body)
+			if err != nil {
+				writeError(w, fmt.Sprintf("Invalid CSV menu: %v", err), http.StatusBadRequest)
+				return menuGeneration{}, false
+			}
+			req.MenuItems = append([]menu.Item{}, items...)
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return menuGeneration{}, false
+	}
+
+	t := currentTenant(r)
+	var profile *Profile
+	if name := r.URL.Query().Get("profile"); name != "" {
+		stored, err := t.storage.GetProfile(name)
+		if err != nil {
+			profileStoreError(w, r, fmt.Errorf("%w: %q", err, name))
+			return menuGeneration{}, false
+		}
+		profile = &stored
+	}
+
+	opts, err := planner.ParseOptions(t.defaults, r.URL.Query())
+	if err == nil {
+		if opts.Lang == "" {
+			opts.Lang = planner.NegotiateLanguage(r.Header.Get("Accept-Language"))
+		}
+		w.Header().Add("Vary", "Accept-Language")
+		// The calories of the body are in the requested units too.
+		menu.ItemsToKcal(req.MenuItems, opts.Units)
+		planner.ScheduleToKcal(req.CalorieSchedule, opts.Units)
+		planner.MealSlotsToKcal(req.MealSlots, opts.Units)
+		opts.PreferenceWeights = req.PreferenceWeights
+		if req.Seed != nil {
+			opts.Seed = req.Seed
+		}
+		if req.PopularityTolerance != nil {
+			opts.PopularityTolerance = *req.PopularityTolerance
+		}
+		opts.DietaryTags = append(opts.DietaryTags, req.DietaryTags...)
+		opts.DayDietaryTags = req.DayDietaryTags
+		opts.ExcludeAllergens = append(opts.ExcludeAllergens, req.ExcludeAllergens...)
+		if req.ComboMacros != nil {
+			opts.ComboMacros = *req.ComboMacros
+		}
+		if req.DayMacros != nil {
+			opts.DayMacros = *req.DayMacros
+		}
+		if req.TastePreferences != nil {
+			opts.TastePreferences = req.TastePreferences
+		}
+		if req.ScoreWeights != nil {
+			opts.ScoreWeights = *req.ScoreWeights
+		}
+		if req.ItemUseLimits != nil {
+			opts.ItemUseLimits = req.ItemUseLimits
+		}
+		if len(req.Template) > 0 {
+			opts.Template = req.Template
+		}
+		if req.DayTemplates != nil {
+			opts.DayTemplates = req.DayTemplates
+		}
+		if req.MealSlots != nil {
+			opts.MealSlots = req.MealSlots
+		}
+		if req.CalorieSchedule != nil {
+			opts.CalorieSchedule = req.CalorieSchedule
+		}
+		if req.Closures != nil {
+			opts.Closures = req.Closures
+		}
+		if len(req.Rules) > 0 {
+			opts.RuleDefs = append(slices.Clip(opts.RuleDefs), req.Rules...)
+		}
+		if profile != nil {
+			profile.apply(&opts, r.URL.Query())
+		}
+		err = opts.Validate()
+	}
+	if err != nil {
+		writeError(w, fmt.Sprintf("Invalid generation settings: %v", err), http.StatusBadRequest)
+		return menuGeneration{}, false
+	}
+
+	verifyNutrition := false
+	if raw := r.URL.Query().Get("verify_nutrition"); raw != "" {
+		verifyNutrition, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, fmt.Sprintf("Invalid verify_nutrition value %q", raw), http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+	}
+	if raw := r.URL.Query().Get("debug"); raw != "" {
+		debug, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, fmt.Sprintf("Invalid debug value %q", raw), http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+		if debug {
+			opts.Debug = &planner.GenerationDebug{Slots: []planner.SlotStats{}}
+		}
+	}
+	if verifyNutrition && nutritionService == nil {
+		writeError(w, "Nutrition verification is not configured (set nutrition.url or NUTRITION_API_URL)", http.StatusBadRequest)
+		return menuGeneration{}, false
+	}
+
+	items := req.MenuItems
+	var index *planner.ComboIndex
+	version := 0
+	switch rawVersion := r.URL.Query().Get("menu_version"); {
+	case items != nil:
+		if len(items) == 0 {
+			writeError(w, "menu_items in the request body must not be empty.", http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+		if rawVersion != "" {
+			writeError(w, "menu_version cannot be combined with menu_items in the request body.", http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+		if err := checkMenu("menu_items", items); err != nil {
+			writeBadRequest(w, err)
+			return menuGeneration{}, false
+		}
+	case rawVersion != "":
+		v, ok := loadMenuVersion(w, r, rawVersion)
+		if !ok {
+			return menuGeneration{}, false
+		}
+		if len(v.Items) == 0 {
+			writeError(w, fmt.Sprintf("Menu version %d is empty.", v.Version), http.StatusBadRequest)
+			return menuGeneration{}, false
+		}
+		items, version = v.Items, v.Version
+	default:
+		items, index, version = t.menuSnapshot(r.Context())
+		if len(items) == 0 {
+			writeError(w, "Master menu is empty.", http.StatusInternalServerError)
+			return menuGeneration{}, false
+		}
+	}
+
+	attachRequest(&opts, r)
+	return menuGeneration{tenant: t, opts: opts, items: items, index: index, menuVersion: version, verifyNutrition: verifyNutrition}, true
+}
+
+// generationTimeout bounds the generation of a plan; zero means no limit.
+// It is set from server.generation_timeout.
+var generationTimeout time.Duration
+
+// generationContext returns ctx bounded by generationTimeout.
+func generationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if generationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, generationTimeout)
+}
+
+// generationError logs why a generation returned err and returns the status
+// and error to answer with: 422 when nothing on the menu satisfies the
+// constraints, 504 when it ran past generation_timeout and 500 for anything
+// else, such as the plan not being stored. The status is 0 when the client
+// went away and there is no one to answer.
+func generationError(r *http.Request, err error) (int, apiError) {
+	var infeasible infeasibleError
+	switch {
+	case errors.As(err, &infeasible):
+		requestLogger(r).Info("no combo satisfies the constraints", "warnings", len(infeasible.warnings))
+		apiErr := newAPIError(http.StatusUnprocessableEntity, infeasible.Error())
+		apiErr.Details = infeasible.details()
+		return http.StatusUnprocessableEntity, apiErr
+	case errors.Is(err, planner.ErrNoAlternative):
+		return http.StatusUnprocessableEntity, newAPIError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		requestLogger(r).Warn("generation timed out", "timeout", generationTimeout.String())
+		return http.StatusGatewayTimeout, newAPIError(http.StatusGatewayTimeout, fmt.Sprintf("Generation did not finish within %s.", generationTimeout))
+	case errors.Is(err, context.Canceled):
+		requestLogger(r).Info("generation abandoned by the client")
+		return 0, apiError{}
+	default:
+		requestLogger(r).Error("menu plan generation failed", "error", err)
+		return http.StatusInternalServerError, newAPIError(http.StatusInternalServerError, "Unable to generate the plan.")
+	}
+}
+
+// generationFailed writes the response for a generation that returned err;
+// see generationError.
+func generationFailed(w http.ResponseWriter, r *http.Request, err error) {
+	if status, apiErr := generationError(r, err); status != 0 {
+		writeAPIError(w, status, apiErr)
+	}
+}
+
+// run generates the plan, verifies its nutrition when asked to and stores
+// it. A plan that got no combo at all is not stored; run returns an
+// infeasibleError instead. Generation stops when ctx is done or generationTimeout passes.
+func (gen menuGeneration) run(ctx context.Context) (planner.MenuPlan, error) {
+	ctx, cancel := generationContext(ctx)
+	defer cancel()
+	menuPlan, err := planner.GenerateWithIndex(ctx, gen.items, gen.opts, gen.index)
+	if err != nil {
+		return menuPlan, err
+	}
+	if len(menuPlan.Warnings) > 0 && !hasCombos(menuPlan) {
+		return menuPlan, infeasibleError{warnings: menuPlan.Warnings}
+	}
+	if gen.verifyNutrition {
+		verifyPlanNutrition(&menuPlan, gen.items, nutritionService)
+	}
+	menuPlan.PlanID = newPlanID()
+	menuPlan.MenuVersion = gen.menuVersion
+	createdAt := time.Now().UTC()
+	menuPlan.CreatedAt = &createdAt
+	stored := menuPlan
+	stored.Debug = nil
+	if err := gen.tenant.storage.SavePlan(stored); err != nil {
+		return menuPlan, fmt.Errorf("saving menu plan: %w", err)
+	}
+	logger := gen.opts.Logger
+	if logger == nil {
+		logger = contextLogger(ctx)
+	}
+	notifyPlanGenerated(gen.tenant, stored, logger)
+	return menuPlan, nil
+}
+
+// hasCombos reports whether any day of plan has a combo.
+func hasCombos(plan planner.MenuPlan) bool {
+	for _, day := range plan.MenuPlan {
+		if len(day.Combos) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON encodes v as the JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("writing JSON response failed", "error", err)
+	}
+}
+
+// nutritionService is the optional external nutrition API used by verify_nutrition=true.
+var nutritionService *nutritionClient
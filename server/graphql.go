@@ -0,0 +1,314 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"task/menu"
+	"task/planner"
+)
+
+// The GraphQL schema mirrors the JSON API: types and fields carry the same
+// names as the JSON objects, so a query selects a subset of what the REST
+// endpoints return. 64-bit seeds are strings, as GraphQL integers are 32-bit.
+var graphQLSchema = func() graphql.Schema {
+	field := func(t graphql.Output) *graphql.Field { return &graphql.Field{Type: t} }
+	list := func(t graphql.Type) graphql.Output { return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t))) }
+	str, integer, float := graphql.NewNonNull(graphql.String), graphql.NewNonNull(graphql.Int), graphql.NewNonNull(graphql.Float)
+	seed := func(get func(source any) *int64) *graphql.Field {
+		return &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) {
+			if s := get(p.Source); s != nil {
+				return strconv.FormatInt(*s, 10), nil
+			}
+			return nil, nil
+		}}
+	}
+
+	portion := graphql.NewObject(graphql.ObjectConfig{Name: "Portion", Fields: graphql.Fields{
+		"size":     field(str),
+		"calories": field(integer),
+		"price":    field(float),
+	}})
+	menuItem := graphql.NewObject(graphql.ObjectConfig{Name: "MenuItem", Fields: graphql.Fields{
+		"item_name":        field(str),
+		"category":         field(str),
+		"categories":       field(list(graphql.String)),
+		"calories":         field(integer),
+		"taste_profile":    field(str),
+		"popularity_score": field(float),
+		"protein_g":        field(float),
+		"sodium_mg":        field(float),
+		"sugar_g":          field(float),
+		"carbs_g":          field(float),
+		"fat_g":            field(float),
+		"price":            field(float),
+		"available_from":   field(graphql.String),
+		"available_until":  field(graphql.String),
+		"served_on":        field(list(graphql.String)),
+		"stock":            field(graphql.Int),
+		"meals":            field(list(graphql.String)),
+		"dietary_tags":     field(list(graphql.String)),
+		"allergens":        field(list(graphql.String)),
+		"portions":         field(list(portion)),
+	}})
+	macros := graphql.NewObject(graphql.ObjectConfig{Name: "Macros", Fields: graphql.Fields{
+		"protein_g": field(float),
+		"carbs_g":   field(float),
+		"fat_g":     field(float),
+	}})
+	calorieWindow := graphql.NewObject(graphql.ObjectConfig{Name: "CalorieWindow", Fields: graphql.Fields{
+		"min_calories": field(integer),
+		"max_calories": field(integer),
+	}})
+	diversity := graphql.NewObject(graphql.ObjectConfig{Name: "DiversityStats", Fields: graphql.Fields{
+		"distinct_items": field(integer),
+		"total_slots":    field(integer),
+		"item_variety":   field(float),
+		"taste_entropy":  field(float),
+	}})
+	component := graphql.NewObject(graphql.ObjectConfig{Name: "ComboComponent", Fields: graphql.Fields{
+		"category":  field(str),
+		"item_name": field(str),
+		"portion":   field(graphql.String),
+	}})
+	nutritionCheck := graphql.NewObject(graphql.ObjectConfig{Name: "NutritionCheck", Fields: graphql.Fields{
+		"verified_calories": field(integer),
+		"discrepancy":       field(graphql.NewNonNull(graphql.Boolean)),
+		"source":            field(str),
+	}})
+	combo := graphql.NewObject(graphql.ObjectConfig{Name: "Combo", Fields: graphql.Fields{
+		"combo_id":         field(str),
+		"main":             field(graphql.String),
+		"side":             field(graphql.String),
+		"drink":            field(graphql.String),
+		"components":       field(list(component)),
+		"calorie_count":    field(integer),
+		"popularity_score": field(float),
+		"reasoning":        field(str),
+		"health_grade":     field(str),
+		"macros":           field(graphql.NewNonNull(macros)),
+		"price":            field(float),
+		"meal":             field(graphql.String),
+		"score":            field(float),
+		"nutrition_check":  field(nutritionCheck),
+	}})
+	mealMenu := graphql.NewObject(graphql.ObjectConfig{Name: "MealMenu", Fields: graphql.Fields{
+		"name":         field(str),
+		"min_calories": field(integer),
+		"max_calories": field(integer),
+		"combo_ids":    field(list(graphql.String)),
+	}})
+	closure := graphql.NewObject(graphql.ObjectConfig{Name: "Closure", Fields: graphql.Fields{
+		"date":   field(str),
+		"combos": field(integer),
+		"reason": field(str),
+	}})
+	dailyMenu := graphql.NewObject(graphql.ObjectConfig{Name: "DailyMenu", Fields: graphql.Fields{
+		"day":            field(str),
+		"date":           field(graphql.String),
+		"combos":         field(list(combo)),
+		"closure":        field(closure),
+		"macros":         field(graphql.NewNonNull(macros)),
+		"total_price":    field(float),
+		"total_calories": field(integer),
+		"diversity":      field(graphql.NewNonNull(diversity)),
+		"meals":          field(list(mealMenu)),
+		"seed":           seed(func(source any) *int64 { return source.(planner.DailyMenu).Seed }),
+	}})
+	excludedItem := graphql.NewObject(graphql.ObjectConfig{Name: "ExcludedItem", Fields: graphql.Fields{
+		"item_name": field(str),
+		"allergens": field(list(graphql.String)),
+	}})
+	planWarning := graphql.NewObject(graphql.ObjectConfig{Name: "PlanWarning", Fields: graphql.Fields{
+		"day":       field(integer),
+		"day_name":  field(str),
+		"meal":      field(str),
+		"generated": field(integer),
+		"expected":  field(integer),
+		"reason":    field(str),
+		"message":   field(str),
+	}})
+	nutritionTotals := graphql.NewObject(graphql.ObjectConfig{Name: "NutritionTotals", Fields: graphql.Fields{
+		"calories": field(float),
+		"macros":   field(macros),
+	}})
+	dayNutrition := graphql.NewObject(graphql.ObjectConfig{Name: "DayNutrition", Fields: graphql.Fields{
+		"day":      field(str),
+		"calories": field(float),
+		"macros":   field(macros),
+	}})
+	nutritionSummary := graphql.NewObject(graphql.ObjectConfig{Name: "NutritionSummary", Fields: graphql.Fields{
+		"days":            field(list(dayNutrition)),
+		"total":           field(graphql.NewNonNull(nutritionTotals)),
+		"daily_average":   field(graphql.NewNonNull(nutritionTotals)),
+		"weekly_averages": field(list(nutritionTotals)),
+	}})
+	menuPlan := graphql.NewObject(graphql.ObjectConfig{Name: "MenuPlan", Fields: graphql.Fields{
+		"plan_id": field(str),
+		"created_at": &graphql.Field{Type: graphql.String, Description: "RFC 3339 time the plan was created.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if created := p.Source.(planner.MenuPlan).CreatedAt; created != nil {
+					return created.Format(time.RFC3339Nano), nil
+				}
+				return nil, nil
+			}},
+		"seed":           seed(func(source any) *int64 { s := source.(planner.MenuPlan).Seed; return &s }),
+		"menu_version":   field(integer),
+		"calorie_window": field(graphql.NewNonNull(calorieWindow)),
+		"menu_plan":      field(list(dailyMenu)),
+		"total_price":    field(float),
+		"total_calories": field(integer),
+		"diversity":      field(graphql.NewNonNull(diversity)),
+		"nutrition":      field(graphql.NewNonNull(nutritionSummary)),
+		"excluded_items": field(list(excludedItem)),
+		"warnings":       field(list(planWarning)),
+	}})
+	planPage := graphql.NewObject(graphql.ObjectConfig{Name: "PlanPage", Fields: graphql.Fields{
+		"plans":  field(list(menuPlan)),
+		"total":  field(integer),
+		"limit":  field(integer),
+		"offset": field(integer),
+	}})
+
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"menu_items": &graphql.Field{
+			Type:        list(menuItem),
+			Description: "The master menu, optionally only one category.",
+			Args:        graphql.FieldConfigArgument{"category": {Type: graphql.String}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				items := contextTenant(p.Context).menu.List()
+				category, _ := p.Args["category"].(string)
+				if category == "" {
+					return items, nil
+				}
+				matching := []menu.Item{}
+				for _, item := range items {
+					if item.InCategory(category) {
+						matching = append(matching, item)
+					}
+				}
+				return matching, nil
+			},
+		},
+		"menu_item": &graphql.Field{
+			Type: menuItem,
+			Args: graphql.FieldConfigArgument{"name": {Type: str}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				item, err := contextTenant(p.Context).menu.Get(p.Args["name"].(string))
+				if err != nil {
+					return nil, nil
+				}
+				return item, nil
+			},
+		},
+		"plan": &graphql.Field{
+			Type: menuPlan,
+			Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				id := p.Args["id"].(string)
+				plan, err := contextTenant(p.Context).storage.GetPlan(id)
+				if errors.Is(err, errPlanNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					contextLogger(p.Context).Error("loading plan failed", "plan_id", id, "error", err)
+					return nil, errors.New("unable to load the plan")
+				}
+				return plan, nil
+			},
+		},
+		"plans": &graphql.Field{
+			Type:        graphql.NewNonNull(planPage),
+			Description: "Stored plans, newest first, filtered like GET /plans.",
+			Args: graphql.FieldConfigArgument{
+				"from":         {Type: graphql.String, Description: "RFC 3339 time or YYYY-MM-DD date."},
+				"to":           {Type: graphql.String, Description: "RFC 3339 time or YYYY-MM-DD date; a date includes that whole day."},
+				"min_calories": {Type: graphql.Int},
+				"max_calories": {Type: graphql.Int},
+				"days":         {Type: graphql.Int},
+				"limit":        {Type: graphql.Int},
+				"offset":       {Type: graphql.Int},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				query := url.Values{}
+				for name, value := range p.Args {
+					query.Set(name, fmt.Sprint(value))
+				}
+				filter, err := parsePlanFilter(query)
+				if err != nil {
+					return nil, fmt.Errorf("invalid plan filter: %w", err)
+				}
+				plans, err := contextTenant(p.Context).storage.ListPlans()
+				if err != nil {
+					contextLogger(p.Context).Error("listing plans failed", "error", err)
+					return nil, errors.New("unable to list plans")
+				}
+				matching, total := filter.page(plans)
+				if matching == nil {
+					matching = []planner.MenuPlan{}
+				}
+				return map[string]any{"plans": matching, "total": total, "limit": filter.Limit, "offset": filter.Offset}, nil
+			},
+		},
+	}})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		panic(fmt.Sprintf("GraphQL schema: %v", err))
+	}
+	return schema
+}()
+
+// graphQLRequest is a GraphQL query sent as a POST body, or as the query,
+// operationName and variables query parameters of a GET request.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLHandler handles GET and POST /graphql, running a query against
+// the menu items and plans of the request's tenant.
+func graphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if raw := query.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				writeError(w, fmt.Sprintf("Invalid variables: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		writeError(w, "A GraphQL query is required.", http.StatusBadRequest)
+		return
+	}
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,412 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"task/menu"
+	"task/planner"
+	"task/plannerpb"
+)
+
+// grpcScopes is the scope each RPC of the MenuPlanner service requires.
+var grpcScopes = map[string]string{
+	plannerpb.MenuPlanner_GenerateMenu_FullMethodName:  scopeGenerate,
+	plannerpb.MenuPlanner_GetPlan_FullMethodName:       scopeRead,
+	plannerpb.MenuPlanner_ListMenuItems_FullMethodName: scopeRead,
+}
+
+// newGRPCServer returns a gRPC server for the MenuPlanner service, serving
+// TLS with the HTTP server's certificate when one is configured.
+func newGRPCServer(cfg ServerConfig) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(grpcInterceptor)}
+	if cfg.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	server := grpc.NewServer(opts...)
+	plannerpb.RegisterMenuPlannerServer(server, plannerService{})
+	return server, nil
+}
+
+// grpcInterceptor does for every RPC what the HTTP middleware does for
+// requests: it resolves the tenant named by the x-tenant-id metadata,
+// authenticates and authorizes the caller, traces and logs the call, and
+// holds generation to the rate limit.
+func grpcInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenantName := firstMetadata(md, strings.ToLower(tenantHeader))
+	id := firstMetadata(md, strings.ToLower(requestIDHeader))
+	if !requestIDPattern.MatchString(id) {
+		id = newPlanID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestIDHeader), id))
+	logger := slog.Default().With("request_id", id, "tenant", tenantName)
+	s := startServerSpan(firstMetadata(md, traceparentHeader))
+	if s != nil {
+		s.name = strings.TrimPrefix(info.FullMethod, "/")
+		s.set("rpc.system", "grpc")
+		s.set("rpc.method", info.FullMethod)
+		ctx = context.WithValue(ctx, spanContextKey{}, s)
+		logger = logger.With("trace_id", hex.EncodeToString(s.traceID[:]))
+	}
+	ctx = context.WithValue(ctx, loggerContextKey{}, logger)
+
+	resp, err := func() (any, error) {
+		t, ok := tenants[tenantName]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown tenant %q", tenantName)
+		}
+		ctx = context.WithValue(ctx, tenantContextKey{}, t)
+		caller, err := authorizeRPC(ctx, md, grpcScopes[info.FullMethod], tenantName)
+		if err != nil {
+			return nil, err
+		}
+		if err := limitRPC(ctx, info.FullMethod, caller); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}()
+
+	code := status.Code(err)
+	s.set("rpc.grpc.status_code", int(code))
+	if code == codes.Internal || code == codes.Unknown {
+		s.fail(code.String())
+	}
+	s.end()
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	logger.Log(ctx, level, "rpc",
+		"method", info.FullMethod,
+		"code", code.String(),
+		"duration_ms", durationMillis(time.Since(start)),
+	)
+	return resp, err
+}
+
+// authorizeRPC checks, when API keys or OIDC are configured, that the caller
+// presenting the x-api-key or authorization metadata holds scope for the
+// named tenant, as requireScope does for HTTP requests.
+func authorizeRPC(ctx context.Context, md metadata.MD, scope, tenantName string) (principal, error) {
+	if len(apiKeys) == 0 && tokenVerifier == nil {
+		return principal{}, nil
+	}
+	presented := firstMetadata(md, "x-api-key")
+	if presented == "" {
+		if token, ok := strings.CutPrefix(firstMetadata(md, "authorization"), "Bearer "); ok {
+			presented = strings.TrimSpace(token)
+		}
+	}
+	caller, err := authenticateCredential(ctx, presented)
+	if errors.Is(err, errUnauthenticated) {
+		return caller, status.Error(codes.Unauthenticated, "An API key or bearer token is required.")
+	}
+	if err != nil {
+		contextLogger(ctx).Warn("rejected credentials", "error", err)
+		return caller, status.Error(codes.Unauthenticated, "Invalid API key or bearer token.")
+	}
+	if scopeLevels[caller.scope] < scopeLevels[scope] {
+		return caller, status.Errorf(codes.PermissionDenied, "The caller lacks the %s scope.", scope)
+	}
+	if caller.tenants != nil && !slices.Contains(caller.tenants, tenantName) {
+		return caller, status.Error(codes.PermissionDenied, "The caller is not allowed to use this tenant.")
+	}
+	return caller, nil
+}
+
+// rateLimitedRPCs lists the RPCs held to the generation rate limit, like
+// the HTTP routes wrapped in rateLimited.
+var rateLimitedRPCs = map[string]bool{
+	plannerpb.MenuPlanner_GenerateMenu_FullMethodName: true,
+}
+
+// limitRPC takes a token from the caller's bucket of the generation limiter
+// when method is rate limited, sharing the bucket the caller's HTTP requests
+// draw from. Once the bucket is empty it answers ResourceExhausted with a
+// retry-after header, as rateLimited answers 429 with Retry-After.
+func limitRPC(ctx context.Context, method string, caller principal) error {
+	if generationLimiter == nil || !rateLimitedRPCs[method] {
+		return nil
+	}
+	ok, wait := generationLimiter.allow(rpcClientID(ctx, caller), time.Now())
+	if ok {
+		return nil
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+	return status.Errorf(codes.ResourceExhausted, "Too many generation requests; try again in %ds.", seconds)
+}
+
+// rpcClientID identifies the client of an RPC for rate limiting, like clientID.
+func rpcClientID(ctx context.Context, caller principal) string {
+	if caller.name != "" {
+		return "caller:" + caller.name
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:"
+}
+
+// firstMetadata returns the first value of the metadata key, or "".
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// plannerService implements the MenuPlanner service on the same tenants,
+// storage and generator as the HTTP handlers.
+type plannerService struct {
+	plannerpb.UnimplementedMenuPlannerServer
+}
+
+// GenerateMenu generates and stores a plan like GET /generate-menu.
+func (plannerService) GenerateMenu(ctx context.Context, req *plannerpb.GenerateMenuRequest) (*plannerpb.MenuPlan, error) {
+	t := contextTenant(ctx)
+	query := generateMenuQuery(req)
+	opts, err := planner.ParseOptions(t.defaults, query)
+	if err == nil && req.GetProfile() != "" {
+		profile, perr := t.storage.GetProfile(req.GetProfile())
+		if errors.Is(perr, errProfileNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%v: %q", perr, req.GetProfile())
+		}
+		if perr != nil {
+			contextLogger(ctx).Error("accessing profiles failed", "error", perr)
+			return nil, status.Error(codes.Internal, "Unable to access the stored profiles.")
+		}
+		profile.apply(&opts, query)
+	}
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid generation settings: %v", err)
+	}
+
+	items, index, version := t.menuSnapshot(ctx)
+	if len(items) == 0 {
+		return nil, status.Error(codes.Internal, "Master menu is empty.")
+	}
+	attachContext(&opts, ctx)
+	plan, err := menuGeneration{tenant: t, opts: opts, items: items, index: index, menuVersion: version}.run(ctx)
+	var infeasible infeasibleError
+	switch {
+	case errors.As(err, &infeasible):
+		return nil, status.Error(codes.FailedPrecondition, infeasible.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, status.Errorf(codes.DeadlineExceeded, "Generation did not finish within %s.", generationTimeout)
+	case errors.Is(err, context.Canceled):
+		return nil, status.Error(codes.Canceled, "Generation was abandoned.")
+	case err != nil:
+		contextLogger(ctx).Error("menu plan generation failed", "error", err)
+		return nil, status.Error(codes.Internal, "Unable to generate the plan.")
+	}
+	return toProtoPlan(plan), nil
+}
+
+// GetPlan returns a stored plan like GET /plans/{id}.
+func (plannerService) GetPlan(ctx context.Context, req *plannerpb.GetPlanRequest) (*plannerpb.MenuPlan, error) {
+	plan, err := contextTenant(ctx).storage.GetPlan(req.GetPlanId())
+	if errors.Is(err, errPlanNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		contextLogger(ctx).Error("loading plan failed", "plan_id", req.GetPlanId(), "error", err)
+		return nil, status.Error(codes.Internal, "Unable to load the plan.")
+	}
+	return toProtoPlan(plan), nil
+}
+
+// ListMenuItems returns the whole master menu, which GET /menu-items lists
+// page by page.
+func (plannerService) ListMenuItems(ctx context.Context, req *plannerpb.ListMenuItemsRequest) (*plannerpb.ListMenuItemsResponse, error) {
+	items := contextTenant(ctx).menu.List()
+	resp := &plannerpb.ListMenuItemsResponse{Items: make([]*plannerpb.MenuItem, len(items))}
+	for i, item := range items {
+		resp.Items[i] = toProtoMenuItem(item)
+	}
+	return resp, nil
+}
+
+// generateMenuQuery converts a GenerateMenu request into the /generate-menu
+// query parameters it stands for, so both APIs parse settings the same way.
+func generateMenuQuery(req *plannerpb.GenerateMenuRequest) url.Values {
+	query := url.Values{}
+	ints := []struct {
+		name  string
+		value *int32
+	}{
+		{"days", req.Days},
+		{"combos_per_day", req.CombosPerDay},
+		{"min_calories", req.MinCalories},
+		{"max_calories", req.MaxCalories},
+		{"max_total_calories", req.MaxTotalCalories},
+		{"repeat_window", req.RepeatWindow},
+		{"max_item_uses", req.MaxItemUses},
+	}
+	for _, p := range ints {
+		if p.value != nil {
+			query.Set(p.name, strconv.Itoa(int(*p.value)))
+		}
+	}
+	floats := []struct {
+		name  string
+		value *float64
+	}{
+		{"popularity_tolerance", req.PopularityTolerance},
+		{"max_combo_price", req.MaxComboPrice},
+		{"max_total_price", req.MaxTotalPrice},
+	}
+	for _, p := range floats {
+		if p.value != nil {
+			query.Set(p.name, strconv.FormatFloat(*p.value, 'g', -1, 64))
+		}
+	}
+	if req.Seed != nil {
+		query.Set("seed", strconv.FormatInt(*req.Seed, 10))
+	}
+	for name, value := range map[string]string{
+		"strategy":          req.GetStrategy(),
+		"template":          req.GetTemplate(),
+		"optimize":          req.GetOptimize(),
+		"dietary_tags":      strings.Join(req.GetDietaryTags(), ","),
+		"exclude_allergens": strings.Join(req.GetExcludeAllergens(), ","),
+		"exclude_items":     strings.Join(req.GetExcludeItems(), ","),
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if prefs := req.GetTastePreferences(); len(prefs) > 0 {
+		pairs := make([]string, 0, len(prefs))
+		for taste, weight := range prefs {
+			pairs = append(pairs, taste+":"+strconv.FormatFloat(weight, 'g', -1, 64))
+		}
+		sort.Strings(pairs)
+		query.Set("taste_preferences", strings.Join(pairs, ","))
+	}
+	return query
+}
+
+func toProtoMenuItem(item menu.Item) *plannerpb.MenuItem {
+	pb := &plannerpb.MenuItem{
+		ItemName:        item.ItemName,
+		Category:        item.Category,
+		Calories:        int32(item.Calories),
+		TasteProfile:    item.TasteProfile,
+		PopularityScore: item.PopularityScore,
+		ProteinG:        item.ProteinGrams,
+		SodiumMg:        item.SodiumMg,
+		SugarG:          item.SugarGrams,
+		CarbsG:          item.CarbsGrams,
+		FatG:            item.FatGrams,
+		Price:           item.Price,
+		Meals:           item.Meals,
+		DietaryTags:     item.DietaryTags,
+		Allergens:       item.Allergens,
+	}
+	for _, p := range item.Portions {
+		pb.Portions = append(pb.Portions, &plannerpb.Portion{Size: p.Size, Calories: int32(p.Calories), Price: p.Price})
+	}
+	return pb
+}
+
+func toProtoMacros(m planner.Macros) *plannerpb.Macros {
+	return &plannerpb.Macros{ProteinG: m.ProteinGrams, CarbsG: m.CarbsGrams, FatG: m.FatGrams}
+}
+
+func toProtoDiversity(d planner.DiversityStats) *plannerpb.DiversityStats {
+	return &plannerpb.DiversityStats{
+		DistinctItems: int32(d.DistinctItems),
+		TotalSlots:    int32(d.TotalSlots),
+		ItemVariety:   d.ItemVariety,
+		TasteEntropy:  d.TasteEntropy,
+	}
+}
+
+func toProtoPlan(plan planner.MenuPlan) *plannerpb.MenuPlan {
+	pb := &plannerpb.MenuPlan{
+		PlanId: plan.PlanID,
+		Seed:   plan.Seed,
+		CalorieWindow: &plannerpb.CalorieWindow{
+			MinCalories: int32(plan.CalorieWindow.MinCalories),
+			MaxCalories: int32(plan.CalorieWindow.MaxCalories),
+		},
+		TotalPrice:    plan.TotalPrice,
+		TotalCalories: int32(plan.TotalCalories),
+		Diversity:     toProtoDiversity(plan.Diversity),
+	}
+	if plan.CreatedAt != nil {
+		pb.CreatedAt = timestamppb.New(*plan.CreatedAt)
+	}
+	for _, excluded := range plan.ExcludedItems {
+		pb.ExcludedItems = append(pb.ExcludedItems, &plannerpb.ExcludedItem{ItemName: excluded.ItemName, Allergens: excluded.Allergens})
+	}
+	for _, day := range plan.MenuPlan {
+		pbDay := &plannerpb.DailyMenu{
+			Day:           day.Day,
+			Macros:        toProtoMacros(day.Macros),
+			TotalPrice:    day.TotalPrice,
+			TotalCalories: int32(day.TotalCalories),
+			Diversity:     toProtoDiversity(day.Diversity),
+			Seed:          day.Seed,
+		}
+		for _, meal := range day.Meals {
+			pbDay.Meals = append(pbDay.Meals, &plannerpb.MealMenu{
+				Name:        meal.Name,
+				MinCalories: int32(meal.MinCalories),
+				MaxCalories: int32(meal.MaxCalories),
+				ComboIds:    meal.ComboIDs,
+			})
+		}
+		for _, combo := range day.Combos {
+			pbCombo := &plannerpb.Combo{
+				ComboId:         combo.ComboID,
+				CalorieCount:    int32(combo.CalorieCount),
+				PopularityScore: combo.PopularityAvg,
+				Reasoning:       combo.Reasoning,
+				HealthGrade:     combo.HealthGrade,
+				Macros:          toProtoMacros(combo.Macros),
+				Price:           combo.Price,
+				Meal:            combo.Meal,
+				Score:           combo.Score,
+			}
+			for _, c := range combo.Components {
+				pbCombo.Components = append(pbCombo.Components, &plannerpb.ComboComponent{Category: c.Category, ItemName: c.ItemName, Portion: c.Portion})
+			}
+			pbDay.Combos = append(pbDay.Combos, pbCombo)
+		}
+		pb.MenuPlan = append(pb.MenuPlan, pbDay)
+	}
+	return pb
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"task/plannerpb"
+)
+
+func TestGRPCGenerationIsRateLimited(t *testing.T) {
+	useTestTenant(t, StorageConfig{Driver: "memory"})
+	savedKeys, savedLimiter, savedLogger := apiKeys, generationLimiter, slog.Default()
+	t.Cleanup(func() {
+		apiKeys, generationLimiter = savedKeys, savedLimiter
+		slog.SetDefault(savedLogger)
+	})
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	apiKeys = []APIKey{
+		{Name: "alice", Key: "alice-key", Scope: scopeGenerate},
+		{Name: "bob", Key: "bob-key", Scope: scopeGenerate},
+	}
+	generationLimiter = newRateLimiter(RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+
+	call := func(key, method string) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", key))
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, err := grpcInterceptor(ctx, nil, info, func(context.Context, any) (any, error) { return nil, nil })
+		return status.Code(err)
+	}
+	generate := plannerpb.MenuPlanner_GenerateMenu_FullMethodName
+	if code := call("alice-key", generate); code != codes.OK {
+		t.Fatalf("first GenerateMenu by alice answered %s, want OK", code)
+	}
+	if code := call("alice-key", generate); code != codes.ResourceExhausted {
+		t.Errorf("second GenerateMenu by alice answered %s, want ResourceExhausted", code)
+	}
+	if code := call("alice-key", plannerpb.MenuPlanner_GetPlan_FullMethodName); code != codes.OK {
+		t.Errorf("GetPlan by alice answered %s, want OK: only generation is limited", code)
+	}
+	if code := call("bob-key", generate); code != codes.OK {
+		t.Errorf("GenerateMenu by bob answered %s, want OK: each caller has a bucket of its own", code)
+	}
+
+	// Alice's HTTP requests draw from the bucket her RPC emptied.
+	handler := rateLimited(func(w http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	handler(rec, withPrincipal(httptest.NewRequest(http.MethodGet, "/generate-menu", nil), principal{name: "alice", scope: scopeGenerate}))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("HTTP generation by alice after her RPC answered %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
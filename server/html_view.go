@@ -0,0 +1,105 @@
+package server
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+
+	"task/planner"
+)
+
+// planHTMLTemplate renders a plan as a standalone weekly table: one column
+// per day and one row per combo slot.
+var planHTMLTemplate = template.Must(template.New("plan").Funcs(template.FuncMap{
+	"slot": func(day planner.DailyMenu, i int) *planner.Combo {
+		if i < len(day.Combos) {
+			return &day.Combos[i]
+		}
+		return nil
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1.0"/>
+  <title>Weekly Meal Plan {{.Plan.PlanID}}</title>
+  <style>
+    body { font-family: Arial, sans-serif; background-color: #f4f6f9; color: #333; margin: 0; padding: 20px; }
+    h1 { text-align: center; color: #2c3e50; }
+    .summary { text-align: center; color: #555; margin-bottom: 20px; }
+    table { border-collapse: collapse; width: 100%; background-color: #fff; box-shadow: 0 2px 6px rgba(0,0,0,0.1); }
+    th { background-color: #3498db; color: #fff; padding: 10px; }
+    th small { display: block; font-weight: normal; }
+    td { border: 1px solid #e1e5ea; padding: 10px; vertical-align: top; font-size: 14px; }
+    .meal { font-weight: bold; color: #2980b9; }
+    .items { margin: 4px 0; padding-left: 18px; }
+    .details { color: #666; font-size: 12px; }
+    @media print { body { background: #fff; padding: 0; } table { box-shadow: none; } }
+  </style>
+</head>
+<body>
+  <h1>Weekly Meal Plan</h1>
+  <p class="summary">Plan {{.Plan.PlanID}} &middot; {{len .Plan.MenuPlan}} days &middot; {{.Plan.TotalCalories}} {{$.Unit}}{{if .Plan.TotalPrice}} &middot; {{printf "%.2f" .Plan.TotalPrice}} total{{end}}</p>
+  <table>
+    <thead>
+      <tr>
+        {{- range .Plan.MenuPlan}}
+        <th>{{.Day}}{{if .Date}}<small>{{.Date}}</small>{{end}}<small>{{.TotalCalories}} {{$.Unit}}</small>
+          {{- with .Closure}}<small>{{if .Combos}}Reduced service{{else}}Closed{{end}}{{with .Reason}}: {{.}}{{end}}</small>{{end}}</th>
+        {{- end}}
+      </tr>
+    </thead>
+    <tbody>
+      {{- range $i := .Slots}}
+      <tr>
+        {{- range $.Plan.MenuPlan}}
+        <td>
+          {{- with slot . $i}}
+          {{- if .Meal}}<div class="meal">{{.Meal}}</div>{{end}}
+          <ul class="items">
+            {{- range .Components}}
+            <li>{{.ItemName}}{{if .Portion}} ({{.Portion}}){{end}}</li>
+            {{- end}}
+          </ul>
+          <div class="details">{{.CalorieCount}} {{$.Unit}} &middot; popularity {{printf "%.2f" .PopularityAvg}} &middot; grade {{.HealthGrade}}{{if .Price}} &middot; {{printf "%.2f" .Price}}{{end}}</div>
+          {{- end}}
+        </td>
+        {{- end}}
+      </tr>
+      {{- end}}
+    </tbody>
+  </table>
+</body>
+</html>
+`))
+
+// htmlHandler handles GET /plans/{id}/html, rendering the plan as a page
+// that needs no separate frontend.
+func htmlHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := writePlanHTML(w, plan); err != nil {
+		requestLogger(r).Error("rendering plan HTML failed", "error", err)
+	}
+}
+
+// writePlanHTML renders the plan with planHTMLTemplate.
+func writePlanHTML(w io.Writer, plan planner.MenuPlan) error {
+	slots := 0
+	for _, day := range plan.MenuPlan {
+		slots = max(slots, len(day.Combos))
+	}
+	plan = planner.ReportedEnergy(plan)
+	data := struct {
+		Plan  planner.MenuPlan
+		Unit  string
+		Slots []int
+	}{Plan: plan, Unit: energyUnit(plan.EnergyUnit), Slots: make([]int, slots)}
+	for i := range data.Slots {
+		data.Slots[i] = i
+	}
+	return planHTMLTemplate.Execute(w, data)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"task/planner"
+)
+
+// icalDateFormat is the iCalendar DATE value format.
+const icalDateFormat = "20060102"
+
+// icalEscaper escapes TEXT values as required by RFC 5545.
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// writeICalLine writes a content line, folding it after 75 octets as RFC 5545 requires.
+func writeICalLine(w io.Writer, line string) {
+	for len(line) > 75 {
+		cut := 75
+		// Never split a multi-byte UTF-8 sequence.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		fmt.Fprintf(w, "%s\r\n", line[:cut])
+		line = " " + line[cut:]
+	}
+	fmt.Fprintf(w, "%s\r\n", line)
+}
+
+// comboDescription summarizes a combo, whose calories are in unit, for
+// calendar and other text renderings.
+func comboDescription(combo planner.Combo, unit string) string {
+	var b strings.Builder
+	for _, component := range combo.Components {
+		fmt.Fprintf(&b, "%s: %s", strings.ToUpper(component.Category[:1])+component.Category[1:], component.ItemName)
+		if component.Portion != "" {
+			fmt.Fprintf(&b, " (%s)", component.Portion)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Calories: %d %s\n", combo.CalorieCount, energyUnit(unit))
+	fmt.Fprintf(&b, "Popularity: %.2f\n", combo.PopularityAvg)
+	fmt.Fprintf(&b, "Health grade: %s\n", combo.HealthGrade)
+	if combo.Price > 0 {
+		fmt.Fprintf(&b, "Price: %.2f\n", combo.Price)
+	}
+	b.WriteString(combo.Reasoning)
+	return b.String()
+}
+
+// writeMenuPlanICal renders every combo of the plan as an all-day VEVENT on
+// the date of its day, counting the first day of the plan as start.
+func writeMenuPlanICal(w io.Writer, plan planner.MenuPlan, start time.Time) {
+	plan = planner.ReportedEnergy(plan)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	writeICalLine(w, "BEGIN:VCALENDAR")
+	writeICalLine(w, "VERSION:2.0")
+	writeICalLine(w, "PRODID:-//Menu Planner//Menu Plan//EN")
+	writeICalLine(w, "CALSCALE:GREGORIAN")
+	writeICalLine(w, "X-WR-CALNAME:"+icalEscaper.Replace("Menu plan "+plan.PlanID))
+	for i, day := range plan.MenuPlan {
+		date := start.AddDate(0, 0, i)
+		for _, combo := range day.Combos {
+			summary := strings.Join(strings.Split(comboItemNames(combo), ";"), ", ")
+			if combo.Meal != "" {
+				summary = combo.Meal + ": " + summary
+			}
+			writeICalLine(w, "BEGIN:VEVENT")
+			writeICalLine(w, fmt.Sprintf("UID:%s-%s@menu-planner", plan.PlanID, combo.ComboID))
+			writeICalLine(w, "DTSTAMP:"+stamp)
+			writeICalLine(w, "DTSTART;VALUE=DATE:"+date.Format(icalDateFormat))
+			writeICalLine(w, "DTEND;VALUE=DATE:"+date.AddDate(0, 0, 1).Format(icalDateFormat))
+			writeICalLine(w, "SUMMARY:"+icalEscaper.Replace(summary))
+			writeICalLine(w, "DESCRIPTION:"+icalEscaper.Replace(comboDescription(combo, plan.EnergyUnit)))
+			writeICalLine(w, "TRANSP:TRANSPARENT")
+			writeICalLine(w, "END:VEVENT")
+		}
+	}
+	writeICalLine(w, "END:VCALENDAR")
+}
+
+// planStartDate returns the date of the plan's first day, which plans
+// stored before days were dated do not have.
+func planStartDate(plan planner.MenuPlan) (time.Time, bool) {
+	if len(plan.MenuPlan) == 0 {
+		return time.Time{}, false
+	}
+	start, err := time.Parse(time.DateOnly, plan.MenuPlan[0].Date)
+	return start, err == nil
+}
+
+// icalHandler handles GET /plans/{id}/ical. The optional start query
+// parameter (YYYY-MM-DD) dates the first day of the plan; it defaults to the
+// plan's own start date or, for plans without one, the Monday of the
+// current week.
+func icalHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	start, dated := planStartDate(plan)
+	if !dated {
+		start = planner.StartOfWeek(time.Now())
+	}
+	if raw := r.URL.Query().Get("start"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			writeError(w, fmt.Sprintf("Invalid start value %q: expected YYYY-MM-DD", raw), http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="menu_plan_%s.ics"`, plan.PlanID))
+	writeMenuPlanICal(w, plan, start)
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"task/planner"
+)
+
+// Log output formats.
+const (
+	logFormatJSON = "json"
+	logFormatText = "text"
+)
+
+// LogConfig selects how log records are written to stderr.
+type LogConfig struct {
+	// Format is logFormatJSON, for log pipelines, or logFormatText.
+	Format string `json:"format" yaml:"format"`
+	// Level is the least severe level written: debug, info, warn or error.
+	// Per-slot search details are logged at debug.
+	Level string `json:"level" yaml:"level"`
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg LogConfig) validate() error {
+	if cfg.Format != logFormatJSON && cfg.Format != logFormatText {
+		return fmt.Errorf("format must be %q or %q, got %q", logFormatJSON, logFormatText, cfg.Format)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("level must be debug, info, warn or error, got %q", cfg.Level)
+	}
+	return nil
+}
+
+// newLogger returns a logger writing to w as configured by cfg, which must be valid.
+func newLogger(cfg LogConfig, w io.Writer) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == logFormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// requestIDHeader carries the ID of a request. An ID sent by the client or a
+// proxy is kept so log records can be correlated across services.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern accepts client-supplied request IDs that are safe to log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// probePaths are polled by orchestrators and scrapers; their successful
+// requests are logged at debug to keep the log readable.
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+type loggerContextKey struct{}
+
+// statusRecorder remembers the status and size of a response for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// withRequestLogging assigns every request an ID, echoed in the X-Request-ID
+// response header, makes a logger carrying the ID and tenant available to
+// handlers through requestLogger, and logs each request once it completes.
+func withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newPlanID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		logger := slog.Default().With("request_id", id, "tenant", requestTenantName(r))
+		if s := spanFromContext(r.Context()); s != nil {
+			logger = logger.With("trace_id", hex.EncodeToString(s.traceID[:]))
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), loggerContextKey{}, logger)))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if probePaths[r.URL.Path] && rec.status < http.StatusInternalServerError {
+			level = slog.LevelDebug
+		} else if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", durationMillis(time.Since(start)),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// requestLogger returns the logger of a request passed through
+// withRequestLogging, or the default logger.
+func requestLogger(r *http.Request) *slog.Logger {
+	return contextLogger(r.Context())
+}
+
+// contextLogger returns the logger carried by ctx, or the default logger.
+func contextLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// durationMillis converts d to fractional milliseconds for log records.
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// attachRequest makes generation under opts log and trace under the request r.
+func attachRequest(opts *planner.GenerationOptions, r *http.Request) {
+	attachContext(opts, r.Context())
+}
+
+// attachContext makes generation under opts log and trace under the logger
+// and span in ctx.
+func attachContext(opts *planner.GenerationOptions, ctx context.Context) {
+	opts.Logger = contextLogger(ctx)
+	if s := spanFromContext(ctx); s != nil {
+		opts.Span = generationSpan{s}
+	}
+}
@@ -0,0 +1,451 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"task/menu"
+	"task/planner"
+)
+
+var (
+	errItemNotFound = errors.New("menu item not found")
+	errItemExists   = errors.New("menu item already exists")
+)
+
+// menuStore is the in-memory master menu used for generation. It is safe for
+// concurrent use and keeps items in insertion order. Every change is written
+// through to the backing Storage before it becomes visible.
+type menuStore struct {
+	mu    sync.Mutex
+	items []menu.Item
+	// version is the menu version items were saved as.
+	version int
+	storage Storage
+	// shared is set when other instances may change the storage, so the
+	// cached items are reloaded before every operation.
+	shared bool
+	// index is the combo index of items, built lazily and dropped whenever items change.
+	index *planner.ComboIndex
+}
+
+// newMenuStore creates a store holding a copy of items, saved as version,
+// persisting changes to storage.
+func newMenuStore(items []menu.Item, version int, storage Storage) *menuStore {
+	return &menuStore{
+		items:   append([]menu.Item(nil), items...),
+		version: version,
+		storage: storage,
+		shared:  isSharedStorage(storage),
+	}
+}
+
+// refresh reloads the items from a shared storage. On failure the cached
+// items are kept. The caller must hold the lock.
+func (s *menuStore) refresh() {
+	if !s.shared {
+		return
+	}
+	items, version, err := s.storage.LoadMenu()
+	if err != nil {
+		slog.Warn("using cached menu, reloading from storage failed", "error", err)
+		return
+	}
+	if version != s.version {
+		s.items, s.version = items, version
+		s.index = nil
+	}
+}
+
+// commit persists items as a new version and makes them the current menu.
+// The caller must hold the write lock.
+func (s *menuStore) commit(items []menu.Item) error {
+	version, err := s.storage.SaveMenu(items)
+	if err != nil {
+		return fmt.Errorf("failed to persist menu: %w", err)
+	}
+	s.items, s.version = items, version
+	s.index = nil
+	return nil
+}
+
+// List returns a snapshot of all menu items.
+func (s *menuStore) List() []menu.Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	return append([]menu.Item(nil), s.items...)
+}
+
+// Snapshot returns all menu items together with their combo index and menu
+// version, building the index if the menu changed since it was last used.
+func (s *menuStore) Snapshot() ([]menu.Item, *planner.ComboIndex, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	if s.index == nil {
+		s.index = planner.NewComboIndex(s.items)
+	}
+	return append([]menu.Item(nil), s.items...), s.index, s.version
+}
+
+// indexOf returns the position of the named item, or -1. The caller must hold the lock.
+func (s *menuStore) indexOf(name string) int {
+	for i, item := range s.items {
+		if item.ItemName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the item with the given name.
+func (s *menuStore) Get(name string) (menu.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	i := s.indexOf(name)
+	if i < 0 {
+		return menu.Item{}, errItemNotFound
+	}
+	return s.items[i], nil
+}
+
+// Create adds a new item; names must be unique.
+func (s *menuStore) Create(item menu.Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	if s.indexOf(item.ItemName) >= 0 {
+		return errItemExists
+	}
+	items := append(append([]menu.Item(nil), s.items...), item)
+	return s.commit(items)
+}
+
+// Update replaces the named item. The item may be renamed as long as the new
+// name is not already taken.
+func (s *menuStore) Update(name string, item menu.Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	i := s.indexOf(name)
+	if i < 0 {
+		return errItemNotFound
+	}
+	if item.ItemName != name && s.indexOf(item.ItemName) >= 0 {
+		return errItemExists
+	}
+	items := append([]menu.Item(nil), s.items...)
+	items[i] = item
+	return s.commit(items)
+}
+
+// Delete removes the named item.
+func (s *menuStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	i := s.indexOf(name)
+	if i < 0 {
+		return errItemNotFound
+	}
+	items := append(append([]menu.Item(nil), s.items[:i]...), s.items[i+1:]...)
+	return s.commit(items)
+}
+
+// AdjustPopularity moves the popularity scores of the targeted items by the
+// given learning rate and returns the change of every item touched. No score
+// changes when a targeted item does not exist.
+func (s *menuStore) AdjustPopularity(targets []popularityTarget, rate float64) ([]PopularityChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	items, changes, err := adjustPopularity(s.items, targets, rate)
+	if err != nil {
+		return nil, err
+	}
+	return changes, s.commit(items)
+}
+
+// Upsert creates or replaces each of items by name, in one write to the
+// storage. It reports for each item whether it was created rather than
+// replaced; new items are added in order at the end of the menu.
+func (s *menuStore) Upsert(items []menu.Item) ([]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	updated := append([]menu.Item(nil), s.items...)
+	positions := make(map[string]int, len(updated))
+	for i, item := range updated {
+		positions[item.ItemName] = i
+	}
+	created := make([]bool, len(items))
+	for i, item := range items {
+		if j, ok := positions[item.ItemName]; ok {
+			updated[j] = item
+			continue
+		}
+		positions[item.ItemName] = len(updated)
+		updated = append(updated, item)
+		created[i] = true
+	}
+	return created, s.commit(updated)
+}
+
+// Replace swaps the whole menu for items.
+func (s *menuStore) Replace(items []menu.Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(append([]menu.Item(nil), items...))
+}
+
+// decodeMenuItem reads a menu item from the request body and checks required fields.
+func decodeMenuItem(r *http.Request) (menu.Item, error) {
+	var item menu.Item
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		return item, fmt.Errorf("invalid menu item: %w", err)
+	}
+	return item, checkMenuItem(&item)
+}
+
+// checkMenuItem trims the item's name and checks its fields, returning an
+// invalidMenuError listing each field at fault.
+func checkMenuItem(item *menu.Item) error {
+	item.ItemName = strings.TrimSpace(item.ItemName)
+	if problems := menu.CheckItem(*item); len(problems) > 0 {
+		return invalidMenuError{single: true, problems: problems}
+	}
+	return nil
+}
+
+// menuStoreErrorStatus maps store errors to HTTP status codes.
+func menuStoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errItemExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// listMenuItemsHandler handles GET /menu-items, listing the master menu
+// items that match the filter query parameters one page at a time, in the
+// order they ask for (see parseMenuListing).
+func listMenuItemsHandler(w http.ResponseWriter, r *http.Request) {
+	listing, err := parseMenuListing(r.URL.Query())
+	if err != nil {
+		writeError(w, fmt.Sprintf("Invalid menu listing: %v", err), http.StatusBadRequest)
+		return
+	}
+	page, err := listing.page(currentTenant(r).menu.List())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// getMenuItemHandler handles GET /menu-items/{name}.
+func getMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, err := currentTenant(r).menu.Get(r.PathValue("name"))
+	if err != nil {
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// createMenuItemHandler handles POST /menu-items.
+func createMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, err := decodeMenuItem(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if err := currentTenant(r).menu.Create(item); err != nil {
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// updateMenuItemHandler handles PUT /menu-items/{name}.
+func updateMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, err := decodeMenuItem(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if err := currentTenant(r).menu.Update(r.PathValue("name"), item); err != nil {
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// importMenuItemsHandler handles POST /menu-items/import, replacing the whole
+// menu with the uploaded items. The body is parsed as CSV when the Content-Type
+// is text/csv and as a JSON array of items otherwise. With dedupe=true, items
+// whose name duplicates an earlier one but for case, spacing or accents are
+// left out rather than rejected. With units=kJ, calories are given in
+// kilojoules and stored converted to kcal.
+func importMenuItemsHandler(w http.ResponseWriter, r *http.Request) {
+	unit, err := menu.ParseEnergyUnit(r.URL.Query().Get("units"))
+	if err != nil {
+		writeError(w, fmt.Sprintf("Invalid units value: %v", err), http.StatusBadRequest)
+		return
+	}
+	dedupe := false
+	if raw := r.URL.Query().Get("dedupe"); raw != "" {
+		if dedupe, err = strconv.ParseBool(raw); err != nil {
+			writeError(w, fmt.Sprintf("Invalid dedupe value %q", raw), http.StatusBadRequest)
+			return
+		}
+	}
+	items, err := menu.Parse(r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	menu.ItemsToKcal(items, unit)
+	if dedupe {
+		var dropped []menu.Item
+		items, dropped = menu.Deduplicate(items)
+		for _, item := range dropped {
+			requestLogger(r).Info("duplicate menu item left out of the import", "item_name", item.ItemName)
+		}
+	}
+	if len(items) == 0 {
+		writeError(w, "Imported menu must not be empty.", http.StatusBadRequest)
+		return
+	}
+	for i := range items {
+		items[i].ItemName = strings.TrimSpace(items[i].ItemName)
+	}
+	if err := checkMenu("", items); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if err := currentTenant(r).menu.Replace(items); err != nil {
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Statuses of the items of a bulk upsert.
+const (
+	bulkCreated = "created"
+	bulkUpdated = "updated"
+	bulkFailed  = "failed"
+)
+
+// BulkItemResult is the outcome of one item of a bulk upsert.
+type BulkItemResult struct {
+	// Item is the 1-based position of the item in the request.
+	Item     int    `json:"item"`
+	ItemName string `json:"item_name,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkUpsertResult is the response of POST /menu-items:bulk.
+type BulkUpsertResult struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Results []BulkItemResult `json:"results"`
+}
+
+// bulkUpsertMenuItemsHandler handles POST /menu-items:bulk, creating or
+// replacing by name every item of a JSON array. Items that cannot be read,
+// lack required fields or repeat an earlier name of the request fail on
+// their own; the others are applied together.
+func bulkUpsertMenuItemsHandler(w http.ResponseWriter, r *http.Request) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		writeError(w, fmt.Sprintf("Invalid request body: expected a JSON array of menu items: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(raw) == 0 {
+		writeError(w, "The request must contain at least one menu item.", http.StatusBadRequest)
+		return
+	}
+
+	result := BulkUpsertResult{Results: make([]BulkItemResult, len(raw))}
+	var items []menu.Item
+	var applied []int
+	seen := make(map[string]int, len(raw))
+	for i, data := range raw {
+		res := &result.Results[i]
+		res.Item = i + 1
+		var item menu.Item
+		err := json.Unmarshal(data, &item)
+		if err != nil {
+			err = fmt.Errorf("invalid menu item: %w", err)
+		} else if err = checkMenuItem(&item); err == nil {
+			if first, ok := seen[item.ItemName]; ok {
+				err = fmt.Errorf("item_name repeats item %d", first)
+			}
+		}
+		res.ItemName = item.ItemName
+		if err != nil {
+			res.Status, res.Error = bulkFailed, err.Error()
+			result.Failed++
+			continue
+		}
+		seen[item.ItemName] = i + 1
+		items = append(items, item)
+		applied = append(applied, i)
+	}
+
+	if len(items) > 0 {
+		created, err := currentTenant(r).menu.Upsert(items)
+		if err != nil {
+			requestLogger(r).Error("bulk upsert of menu items failed", "error", err)
+			writeError(w, err.Error(), menuStoreErrorStatus(err))
+			return
+		}
+		for j, i := range applied {
+			if created[j] {
+				result.Results[i].Status = bulkCreated
+				result.Created++
+			} else {
+				result.Results[i].Status = bulkUpdated
+				result.Updated++
+			}
+		}
+	}
+	requestLogger(r).Info("menu items upserted", "created", result.Created, "updated", result.Updated, "failed", result.Failed)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// deleteMenuItemHandler handles DELETE /menu-items/{name}.
+func deleteMenuItemHandler(w http.ResponseWriter, r *http.Request) {
+	if err := currentTenant(r).menu.Delete(r.PathValue("name")); err != nil {
+		writeError(w, err.Error(), menuStoreErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateMenuHandler handles POST /validate-menu, checking an uploaded menu
+// without storing it. The body is parsed as CSV when the Content-Type is
+// text/csv and as a JSON array of items otherwise.
+func validateMenuHandler(w http.ResponseWriter, r *http.Request) {
+	items, err := menu.Parse(r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, menu.Diagnose(items))
+}
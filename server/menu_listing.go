@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"task/menu"
+)
+
+// Page sizes of the menu item listing.
+const (
+	defaultMenuPageSize = 100
+	maxMenuPageSize     = 1000
+)
+
+// menuSortKeys are the orders GET /menu-items can list items in, by the
+// value each sorts on. Ties, and the name order, are broken by item name.
+var menuSortKeys = map[string]func(menu.Item) float64{
+	"name":       func(menu.Item) float64 { return 0 },
+	"popularity": func(item menu.Item) float64 { return item.PopularityScore },
+	"calories":   func(item menu.Item) float64 { return float64(item.Calories) },
+}
+
+// MenuItemPage is one page of the menu item listing.
+type MenuItemPage struct {
+	Items []menu.Item `json:"items"`
+	// Total counts every item matching the filter, across all pages.
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	// NextCursor fetches the page after this one; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// MenuListing selects the items listed by GET /menu-items and the page of
+// them returned. Zero filter fields do not filter.
+type MenuListing struct {
+	Category string
+	// Taste matches the taste profile, ignoring case.
+	Taste                    string
+	MinCalories, MaxCalories int
+	// Query matches item names containing it, ignoring case.
+	Query string
+	// Sort is "", which keeps the menu order, or a key of menuSortKeys.
+	Sort       string
+	Descending bool
+	Limit      int
+	Offset     int
+	// After, when set, starts the page after the item a cursor points at,
+	// instead of at Offset.
+	After *menuCursor
+}
+
+// menuCursor marks the last item of a page. It carries the value the item
+// was sorted on, so the next page starts in the right place even when the
+// item has since changed or been deleted.
+type menuCursor struct {
+	Sort  string  `json:"s,omitempty"`
+	Name  string  `json:"n"`
+	Value float64 `json:"v,omitempty"`
+}
+
+// encode returns the cursor as an opaque query parameter value.
+func (c menuCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseMenuListing reads a MenuListing from the query parameters category,
+// taste, min_calories, max_calories, q, sort (a key of menuSortKeys,
+// prefixed with "-" for descending order), limit, and offset or cursor.
+func parseMenuListing(query url.Values) (MenuListing, error) {
+	listing := MenuListing{
+		Category: query.Get("category"),
+		Taste:    query.Get("taste"),
+		Query:    strings.TrimSpace(query.Get("q")),
+		Limit:    defaultMenuPageSize,
+	}
+	if raw := query.Get("sort"); raw != "" {
+		listing.Sort, listing.Descending = strings.TrimPrefix(raw, "-"), strings.HasPrefix(raw, "-")
+		if menuSortKeys[listing.Sort] == nil {
+			return listing, fmt.Errorf("invalid sort %q: expected name, popularity or calories, optionally prefixed with -", raw)
+		}
+	}
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{
+		{"min_calories", &listing.MinCalories},
+		{"max_calories", &listing.MaxCalories},
+		{"limit", &listing.Limit},
+		{"offset", &listing.Offset},
+	} {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return listing, fmt.Errorf("invalid %s %q: must be a non-negative integer", p.name, raw)
+		}
+		*p.target = value
+	}
+	if listing.Limit < 1 || listing.Limit > maxMenuPageSize {
+		return listing, fmt.Errorf("limit must be between 1 and %d", maxMenuPageSize)
+	}
+	if listing.MaxCalories > 0 && listing.MinCalories > listing.MaxCalories {
+		return listing, errors.New("min_calories must not exceed max_calories")
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		if query.Has("offset") {
+			return listing, errors.New("offset and cursor cannot be combined")
+		}
+		var c menuCursor
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &c)
+		}
+		if err != nil || c.Name == "" {
+			return listing, fmt.Errorf("invalid cursor %q", raw)
+		}
+		if c.Sort != query.Get("sort") {
+			return listing, errors.New("cursor belongs to a listing with a different sort")
+		}
+		listing.After = &c
+	}
+	return listing, nil
+}
+
+// matches reports whether item passes the filter.
+func (l MenuListing) matches(item menu.Item) bool {
+	if l.Category != "" && !item.InCategory(l.Category) {
+		return false
+	}
+	if l.Taste != "" && !strings.EqualFold(item.TasteProfile, l.Taste) {
+		return false
+	}
+	if l.MinCalories > 0 && item.Calories < l.MinCalories {
+		return false
+	}
+	if l.MaxCalories > 0 && item.Calories > l.MaxCalories {
+		return false
+	}
+	return l.Query == "" || strings.Contains(strings.ToLower(item.ItemName), strings.ToLower(l.Query))
+}
+
+// compare orders two items given by the value they sort on and their name,
+// returning a negative number when the first comes first.
+func (l MenuListing) compare(aValue float64, aName string, bValue float64, bName string) int {
+	c := 0
+	switch {
+	case aValue < bValue:
+		c = -1
+	case aValue > bValue:
+		c = 1
+	default:
+		c = strings.Compare(aName, bName)
+	}
+	if l.Descending {
+		return -c
+	}
+	return c
+}
+
+// sortParam returns the sort query parameter the listing was read from.
+func (l MenuListing) sortParam() string {
+	if l.Descending {
+		return "-" + l.Sort
+	}
+	return l.Sort
+}
+
+// page filters and sorts items and returns the page the listing selects.
+func (l MenuListing) page(items []menu.Item) (MenuItemPage, error) {
+	matching := []menu.Item{}
+	for _, item := range items {
+		if l.matches(item) {
+			matching = append(matching, item)
+		}
+	}
+	items = matching
+	key := menuSortKeys[l.Sort]
+	if key != nil {
+		sort.Slice(items, func(i, j int) bool {
+			return l.compare(key(items[i]), items[i].ItemName, key(items[j]), items[j].ItemName) < 0
+		})
+	}
+
+	start := l.Offset
+	switch {
+	case l.After == nil:
+	case key == nil:
+		// The menu order has no value to resume from; the item must still be there.
+		start = -1
+		for i, item := range items {
+			if item.ItemName == l.After.Name {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return MenuItemPage{}, fmt.Errorf("the cursor's item %q is no longer listed; list again without cursor", l.After.Name)
+		}
+	default:
+		start = sort.Search(len(items), func(i int) bool {
+			return l.compare(key(items[i]), items[i].ItemName, l.After.Value, l.After.Name) > 0
+		})
+	}
+	page := MenuItemPage{Total: len(items), Limit: l.Limit, Offset: start}
+	start = min(start, len(items))
+	end := min(start+l.Limit, len(items))
+	page.Items = append([]menu.Item{}, items[start:end]...)
+	if end < len(items) {
+		last := items[end-1]
+		c := menuCursor{Sort: l.sortParam(), Name: last.ItemName}
+		if key != nil {
+			c.Value = key(last)
+		}
+		page.NextCursor = c.encode()
+	}
+	return page, nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"task/menu"
+)
+
+// MenuVersion is an immutable snapshot of the master menu. Every change to
+// the menu is kept as a new version, numbered from 1, and plans record the
+// version they were generated from so they can be reproduced after edits.
+type MenuVersion struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	ItemCount int       `json:"item_count"`
+	// Items is left out of the version listing.
+	Items []menu.Item `json:"items,omitempty"`
+}
+
+// parseMenuVersion reads a menu version number.
+func parseMenuVersion(raw string) (int, error) {
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid menu version %q: must be a positive integer", raw)
+	}
+	return version, nil
+}
+
+// loadMenuVersion loads the menu version named by raw, writing an error
+// response and reporting false when it cannot be loaded.
+func loadMenuVersion(w http.ResponseWriter, r *http.Request, raw string) (MenuVersion, bool) {
+	version, err := parseMenuVersion(raw)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return MenuVersion{}, false
+	}
+	v, err := currentTenant(r).storage.GetMenuVersion(version)
+	if errors.Is(err, errMenuVersionNotFound) {
+		writeError(w, fmt.Sprintf("%v: %d", err, version), http.StatusNotFound)
+		return MenuVersion{}, false
+	}
+	if err != nil {
+		requestLogger(r).Error("loading menu version failed", "version", version, "error", err)
+		writeError(w, "Unable to load the menu version.", http.StatusInternalServerError)
+		return MenuVersion{}, false
+	}
+	return v, true
+}
+
+// listMenuVersionsHandler handles GET /menus, listing every version of the
+// master menu without its items, newest first.
+func listMenuVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	versions, err := currentTenant(r).storage.ListMenuVersions()
+	if err != nil {
+		requestLogger(r).Error("listing menu versions failed", "error", err)
+		writeError(w, "Unable to list menu versions.", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+// getMenuVersionHandler handles GET /menus/{version}, returning a version of
+// the master menu with its items.
+func getMenuVersionHandler(w http.ResponseWriter, r *http.Request) {
+	v, ok := loadMenuVersion(w, r, r.PathValue("version"))
+	if !ok {
+		return
+	}
+	if v.Items == nil {
+		v.Items = []menu.Item{}
+	}
+	writeJSON(w, http.StatusOK, v)
+}
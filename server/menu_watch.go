@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"task/menu"
+)
+
+// menuReloadDelay lets a burst of writes to the menu file settle before it
+// is read, so a file saved in several steps is only loaded once, complete.
+const menuReloadDelay = 200 * time.Millisecond
+
+// menuWatchPaths returns the menu file of every tenant that has watch_menu
+// set, by tenant name.
+func menuWatchPaths(cfg Config) (map[string]string, error) {
+	paths := map[string]string{}
+	for name := range tenants {
+		tc := cfg
+		if name != "" {
+			var err error
+			if tc, err = cfg.tenantConfig(name); err != nil {
+				return nil, err
+			}
+		}
+		if tc.WatchMenu {
+			paths[name] = filepath.Clean(tc.MenuPath)
+		}
+	}
+	return paths, nil
+}
+
+// watchMenuFiles reloads the master menu of a tenant whenever its file in
+// paths changes, until ctx is done. A file that cannot be parsed or fails
+// validation is ignored and the current menu kept, so a half-finished edit
+// never reaches generation.
+func watchMenuFiles(ctx context.Context, paths map[string]string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// Directories are watched rather than files: editors often save by
+	// renaming a new file over the old one, which ends a watch on the file.
+	watched := map[string]bool{}
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	go func() {
+		defer watcher.Close()
+		pending := map[string]bool{}
+		timer := time.NewTimer(menuReloadDelay)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-watcher.Events:
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				for name, path := range paths {
+					if filepath.Clean(event.Name) == path {
+						pending[name] = true
+						timer.Reset(menuReloadDelay)
+					}
+				}
+			case err := <-watcher.Errors:
+				slog.Error("watching menu files failed", "error", err)
+			case <-timer.C:
+				for name := range pending {
+					reloadMenuFile(tenants[name], paths[name])
+				}
+				clear(pending)
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadMenuFile replaces the tenant's master menu with the items in path.
+func reloadMenuFile(t *tenant, path string) {
+	logger := slog.With("tenant", t.name, "path", path)
+	items, err := menu.LoadFile(path)
+	if err == nil && len(items) == 0 {
+		err = errors.New("menu is empty")
+	}
+	if err == nil {
+		if problems := menu.Validate(items); len(problems) > 0 {
+			err = errors.New(strings.Join(problems, "; "))
+		}
+	}
+	if err == nil {
+		err = t.menu.Replace(items)
+	}
+	if err != nil {
+		logger.Error("menu file not reloaded; keeping the current menu", "error", err)
+		return
+	}
+	logger.Info("menu file reloaded", "menu_items", len(items))
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricFamily is a Prometheus counter, gauge or histogram with a fixed set
+// of labels, written in the text exposition format by metricsHandler.
+type metricFamily struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	// buckets are the upper bounds of a histogram's buckets, ascending.
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*metricSeries
+}
+
+// metricSeries is the value of a metric family for one set of label values.
+type metricSeries struct {
+	labelValues []string
+	// value is the value of a counter or gauge.
+	value float64
+	// bucketCounts, count and sum describe a histogram's observations;
+	// bucketCounts are not cumulative.
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// metricFamilies lists every metric in the order they are written.
+var metricFamilies []*metricFamily
+
+func newMetric(name, help, kind string, buckets []float64, labels []string) *metricFamily {
+	m := &metricFamily{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*metricSeries)}
+	metricFamilies = append(metricFamilies, m)
+	return m
+}
+
+func newCounter(name, help string, labels ...string) *metricFamily {
+	return newMetric(name, help, "counter", nil, labels)
+}
+
+func newGauge(name, help string, labels ...string) *metricFamily {
+	return newMetric(name, help, "gauge", nil, labels)
+}
+
+func newHistogram(name, help string, buckets []float64, labels ...string) *metricFamily {
+	return newMetric(name, help, "histogram", buckets, labels)
+}
+
+// durationBuckets suit request and generation latencies, in seconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	httpRequests = newCounter("planner_http_requests_total",
+		"HTTP requests served, by method, route and status code.", "method", "route", "status")
+	httpRequestDuration = newHistogram("planner_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route.", durationBuckets, "route")
+	generationDuration = newHistogram("planner_generation_duration_seconds",
+		"Time taken to generate a whole plan, by strategy.", durationBuckets, "strategy")
+	comboAttempts = newHistogram("planner_combo_attempts",
+		"Combos tried for each plan slot that was filled, by strategy.",
+		[]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}, "strategy")
+	infeasibleSlots = newCounter("planner_infeasible_slots_total",
+		"Plan slots left empty because no valid combo was found, by strategy.", "strategy")
+	planCacheLookups = newCounter("planner_plan_cache_lookups_total",
+		"Lookups of seeded plans in the plan cache, by result (hit or miss).", "result")
+	menuSize = newGauge("planner_menu_items",
+		"Items on the master menu, by tenant.", "tenant")
+)
+
+// generationMetrics records plan generation in the planner_generation_*,
+// planner_combo_attempts and planner_infeasible_slots_total metrics.
+type generationMetrics struct{}
+
+func (generationMetrics) ObserveAttempts(strategy string, attempts int) {
+	comboAttempts.observe(float64(attempts), strategy)
+}
+
+func (generationMetrics) AddInfeasibleSlot(strategy string) {
+	infeasibleSlots.add(1, strategy)
+}
+
+func (generationMetrics) ObserveDuration(strategy string, elapsed time.Duration) {
+	generationDuration.observe(elapsed.Seconds(), strategy)
+}
+
+// seriesFor returns the series for labelValues, creating it on first use.
+// The caller must hold the lock.
+func (m *metricFamily) seriesFor(labelValues []string) *metricSeries {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: labelValues}
+		if m.kind == "histogram" {
+			s.bucketCounts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// add adds v to a counter or gauge.
+func (m *metricFamily) add(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seriesFor(labelValues).value += v
+}
+
+// set sets a gauge to v.
+func (m *metricFamily) set(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seriesFor(labelValues).value = v
+}
+
+// observe records v in a histogram.
+func (m *metricFamily) observe(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.seriesFor(labelValues)
+	if i := sort.SearchFloat64s(m.buckets, v); i < len(m.buckets) {
+		s.bucketCounts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// write writes the family in the Prometheus text exposition format.
+func (m *metricFamily) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", m.name, m.labelSet(s.labelValues, "", 0), formatMetricValue(s.value))
+			continue
+		}
+		cumulative := uint64(0)
+		for i, bound := range m.buckets {
+			cumulative += s.bucketCounts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, m.labelSet(s.labelValues, "le", bound), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, m.labelSet(s.labelValues, "le", math.Inf(1)), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", m.name, m.labelSet(s.labelValues, "", 0), formatMetricValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", m.name, m.labelSet(s.labelValues, "", 0), s.count)
+	}
+}
+
+// labelSet formats the labels of a series, followed by the extra label when
+// it is not empty, as {name="value",...}.
+func (m *metricFamily) labelSet(values []string, extra string, extraValue float64) string {
+	var pairs []string
+	for i, name := range m.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(values[i])))
+	}
+	if extra != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra, formatMetricValue(extraValue)))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelValueEscaper escapes label values as the exposition format requires.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatMetricValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// withMetrics wraps next, counting and timing requests by the mux route that
+// serves them, so that the label values stay bounded.
+func withMetrics(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		httpRequests.add(1, r.Method, route, strconv.Itoa(rec.status))
+		httpRequestDuration.observe(time.Since(start).Seconds(), route)
+	})
+}
+
+// metricsHandler handles GET /metrics in the Prometheus text format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	for name, t := range tenants {
+		items, _, _ := t.menu.Snapshot()
+		menuSize.set(float64(len(items)), name)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, m := range metricFamilies {
+		m.write(w)
+	}
+}
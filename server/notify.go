@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"sync"
+
+	"task/planner"
+)
+
+// NotifyConfig selects who is told about newly generated plans besides the
+// tenant's webhooks.
+type NotifyConfig struct {
+	Email EmailNotifyConfig `json:"email" yaml:"email"`
+	Chat  ChatNotifyConfig  `json:"chat" yaml:"chat"`
+}
+
+// validate reports the first setting that cannot be used with smtp.
+func (cfg NotifyConfig) validate(smtpCfg SMTPConfig) error {
+	if err := cfg.Chat.validate(); err != nil {
+		return fmt.Errorf("chat: %w", err)
+	}
+	if len(cfg.Email.To) == 0 {
+		return nil
+	}
+	if smtpCfg.Host == "" || smtpCfg.From == "" {
+		return errors.New("email requires smtp.host and smtp.from")
+	}
+	for _, to := range cfg.Email.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid email.to address %q: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// pendingNotifications tracks notifications in flight, so shutdown can wait
+// for them.
+var pendingNotifications sync.WaitGroup
+
+// notifyPlanGenerated tells the tenant's webhooks, email recipients and chat
+// channel about a newly generated plan in the background. Failures are
+// logged, never returned: a receiver that is down does not fail the
+// generation.
+func notifyPlanGenerated(t *tenant, plan planner.MenuPlan, logger *slog.Logger) {
+	postPlanToWebhooks(t, plan, logger)
+	emailPlan(t, plan, logger)
+	if t.notify.Chat.OnGeneration {
+		postPlanToChat(t, plan, logger)
+	}
+}
+
+// waitForNotifications waits until notifications in flight have been sent
+// or ctx is done.
+func waitForNotifications(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pendingNotifications.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
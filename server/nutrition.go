@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+// Sources reported in NutritionCheck.Source.
+const (
+	nutritionSourceExternal = "external" // every item was verified by the nutrition service
+	nutritionSourcePartial  = "partial"  // some items fell back to catalog values
+	nutritionSourceCatalog  = "catalog"  // the nutrition service could not verify any item
+)
+
+// nutritionLookupWorkers bounds the number of concurrent nutrition API requests
+// made while verifying a plan.
+const nutritionLookupWorkers = 8
+
+// nutritionClient queries an external HTTP nutrition API for item calories.
+// The service is expected to answer GET <baseURL>?item=<name> with a JSON
+// body such as {"calories": 520}.
+type nutritionClient struct {
+	baseURL    string
+	httpClient *http.Client
+	// tolerance is the relative difference between catalog and verified
+	// calories above which a combo is flagged.
+	tolerance float64
+}
+
+// newNutritionClient builds a client from the nutrition settings. It returns
+// nil when no URL is configured.
+func newNutritionClient(cfg NutritionConfig) *nutritionClient {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &nutritionClient{
+		baseURL:    cfg.URL,
+		httpClient: &http.Client{Timeout: time.Duration(cfg.Timeout)},
+		tolerance:  cfg.Tolerance,
+	}
+}
+
+// errNutritionUnreachable marks lookup failures where the nutrition service
+// could not be reached at all, as opposed to answering with an error.
+var errNutritionUnreachable = errors.New("nutrition API unreachable")
+
+// lookupCalories fetches the calories of a single item from the nutrition service.
+func (c *nutritionClient) lookupCalories(ctx context.Context, itemName string) (int, error) {
+	reqURL, err := url.Parse(c.baseURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid nutrition API URL %s: %w", c.baseURL, err)
+	}
+	query := reqURL.Query()
+	query.Set("item", itemName)
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("invalid nutrition API request for %s: %w", itemName, err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: request for %s failed: %w", errNutritionUnreachable, itemName, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("nutrition API returned %s for %s", resp.Status, itemName)
+	}
+
+	var body struct {
+		Calories *int `json:"calories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode nutrition API response for %s: %w", itemName, err)
+	}
+	if body.Calories == nil {
+		return 0, fmt.Errorf("nutrition API response for %s has no calories", itemName)
+	}
+	return *body.Calories, nil
+}
+
+// portionCalories scales calories of the regular portion of item to the
+// named portion size. Unknown sizes keep the regular calories.
+func portionCalories(item menu.Item, size string, calories int) int {
+	if size == "" || item.Calories <= 0 {
+		return calories
+	}
+	for _, p := range item.Portions {
+		if p.Size == size {
+			return int(math.Round(float64(calories) * float64(p.Calories) / float64(item.Calories)))
+		}
+	}
+	return calories
+}
+
+// lookupPlanCalories looks up every distinct item of the plan once, with up to
+// nutritionLookupWorkers requests in flight, and returns the calories of the
+// items the service verified. Once the service proves unreachable the
+// remaining lookups are abandoned, so a dead service costs one timeout per
+// worker rather than one per item.
+func lookupPlanCalories(plan *planner.MenuPlan, client *nutritionClient) map[string]int {
+	var names []string
+	seen := make(map[string]bool)
+	for _, day := range plan.MenuPlan {
+		for _, combo := range day.Combos {
+			for _, component := range combo.Components {
+				if !seen[component.ItemName] {
+					seen[component.ItemName] = true
+					names = append(names, component.ItemName)
+				}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	verified := make(map[string]int, len(names))
+	next := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < min(nutritionLookupWorkers, len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				calories, err := client.lookupCalories(ctx, name)
+				if errors.Is(err, context.Canceled) {
+					continue
+				}
+				if err != nil {
+					slog.Warn("falling back to catalog calories", "item_name", name, "error", err)
+					if errors.Is(err, errNutritionUnreachable) {
+						cancel()
+					}
+					continue
+				}
+				mu.Lock()
+				verified[name] = calories
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		next <- name
+	}
+	close(next)
+	wg.Wait()
+	return verified
+}
+
+// verifyPlanNutrition cross-checks every combo in the plan against the
+// nutrition service and attaches a NutritionCheck to each one. Items the
+// service cannot verify fall back to their catalog calories.
+func verifyPlanNutrition(plan *planner.MenuPlan, items []menu.Item, client *nutritionClient) {
+	catalog := make(map[string]menu.Item, len(items))
+	for _, item := range items {
+		catalog[item.ItemName] = item
+	}
+
+	verified := lookupPlanCalories(plan, client)
+	lookup := func(name string) (int, bool) {
+		if calories, ok := verified[name]; ok {
+			return calories, true
+		}
+		return catalog[name].Calories, false
+	}
+
+	for d := range plan.MenuPlan {
+		for c := range plan.MenuPlan[d].Combos {
+			combo := &plan.MenuPlan[d].Combos[c]
+			total, verifiedCount := 0, 0
+			for _, component := range combo.Components {
+				calories, ok := lookup(component.ItemName)
+				calories = portionCalories(catalog[component.ItemName], component.Portion, calories)
+				total += calories
+				if ok {
+					verifiedCount++
+				}
+			}
+
+			source := nutritionSourcePartial
+			switch verifiedCount {
+			case len(combo.Components):
+				source = nutritionSourceExternal
+			case 0:
+				source = nutritionSourceCatalog
+			}
+
+			discrepancy := false
+			if combo.CalorieCount > 0 {
+				diff := math.Abs(float64(total-combo.CalorieCount)) / float64(combo.CalorieCount)
+				discrepancy = diff > client.tolerance
+			}
+
+			combo.NutritionCheck = &planner.NutritionCheck{
+				VerifiedCalories: total,
+				Discrepancy:      discrepancy,
+				Source:           source,
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+// nutritionTestPlan returns a plan of the given number of days, each serving
+// a 650 kcal combo of its own Dal, Rice and Lassi, and the catalog it was
+// built from.
+func nutritionTestPlan(days int) (planner.MenuPlan, []menu.Item) {
+	var plan planner.MenuPlan
+	var items []menu.Item
+	for d := 1; d <= days; d++ {
+		dayItems := []menu.Item{
+			{ItemName: fmt.Sprintf("Dal %d", d), Category: "main", Calories: 450},
+			{ItemName: fmt.Sprintf("Rice %d", d), Category: "side", Calories: 120},
+			{ItemName: fmt.Sprintf("Lassi %d", d), Category: "drink", Calories: 80},
+		}
+		combo := planner.Combo{ComboID: "combo_1", Main: dayItems[0].ItemName, Side: dayItems[1].ItemName, Drink: dayItems[2].ItemName, CalorieCount: 650}
+		for _, item := range dayItems {
+			combo.Components = append(combo.Components, planner.ComboComponent{Category: item.Category, ItemName: item.ItemName})
+		}
+		plan.MenuPlan = append(plan.MenuPlan, planner.DailyMenu{Day: fmt.Sprintf("Day %d", d), Combos: []planner.Combo{combo}})
+		items = append(items, dayItems...)
+	}
+	return plan, items
+}
+
+func TestVerifyPlanNutrition(t *testing.T) {
+	// The service reports Dal 100 kcal richer than the catalog.
+	adjusted := map[string]int{"Dal 1": 550, "Rice 1": 120, "Lassi 1": 80}
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		calories, ok := adjusted[r.URL.Query().Get("item")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]int{"calories": calories})
+	}))
+	defer srv.Close()
+
+	plan, items := nutritionTestPlan(1)
+	// Serve the same combo twice, so its items are needed twice.
+	plan.MenuPlan = append(plan.MenuPlan, plan.MenuPlan[0])
+	plan.MenuPlan[1].Day = "Day 2"
+	plan.MenuPlan[1].Combos = append([]planner.Combo(nil), plan.MenuPlan[0].Combos...)
+	client := newNutritionClient(NutritionConfig{URL: srv.URL, Timeout: Duration(time.Second), Tolerance: 0.1})
+	verifyPlanNutrition(&plan, items, client)
+
+	if n := requests.Load(); n != 3 {
+		t.Errorf("made %d nutrition requests, want one per distinct item (3)", n)
+	}
+	for _, day := range plan.MenuPlan {
+		got := day.Combos[0].NutritionCheck
+		want := planner.NutritionCheck{VerifiedCalories: 750, Discrepancy: true, Source: nutritionSourceExternal}
+		if got == nil || *got != want {
+			t.Errorf("%s: nutrition check is %+v, want %+v", day.Day, got, want)
+		}
+	}
+}
+
+func TestVerifyPlanNutritionServiceDown(t *testing.T) {
+	// More distinct items than lookup workers, so a service that is not
+	// abandoned after its first timeout takes several timeouts to verify.
+	plan, items := nutritionTestPlan(2 * nutritionLookupWorkers)
+
+	t.Run("error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		checkCatalogFallback(t, plan, items, NutritionConfig{URL: srv.URL, Timeout: Duration(time.Second), Tolerance: 0.1})
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer srv.Close()
+		defer close(release)
+
+		const timeout = 200 * time.Millisecond
+		start := time.Now()
+		checkCatalogFallback(t, plan, items, NutritionConfig{URL: srv.URL, Timeout: Duration(timeout), Tolerance: 0.1})
+		if elapsed := time.Since(start); elapsed >= 2*timeout {
+			t.Errorf("verification took %s with a dead service, want about one %s timeout", elapsed, timeout)
+		}
+		if n := int(requests.Load()); n > nutritionLookupWorkers {
+			t.Errorf("made %d nutrition requests, want at most one per worker (%d)", n, nutritionLookupWorkers)
+		}
+	})
+}
+
+// checkCatalogFallback verifies a copy of plan against the service in cfg and
+// checks every combo fell back to its catalog calories.
+func checkCatalogFallback(t *testing.T, plan planner.MenuPlan, items []menu.Item, cfg NutritionConfig) {
+	t.Helper()
+	plan.MenuPlan = append([]planner.DailyMenu(nil), plan.MenuPlan...)
+	for d := range plan.MenuPlan {
+		plan.MenuPlan[d].Combos = append([]planner.Combo(nil), plan.MenuPlan[d].Combos...)
+	}
+	verifyPlanNutrition(&plan, items, newNutritionClient(cfg))
+	for _, day := range plan.MenuPlan {
+		got := day.Combos[0].NutritionCheck
+		want := planner.NutritionCheck{VerifiedCalories: 650, Discrepancy: false, Source: nutritionSourceCatalog}
+		if got == nil || *got != want {
+			t.Errorf("%s: nutrition check is %+v, want %+v", day.Day, got, want)
+		}
+	}
+}
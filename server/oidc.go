@@ -0,0 +1,435 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // Register the hashes used by token algorithms.
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCConfig configures validation of JWT bearer tokens issued by an OpenID
+// Connect provider. It is disabled when both Issuer and JWKSURL are empty.
+type OIDCConfig struct {
+	// Issuer is the required iss claim. When JWKSURL is empty the signing
+	// keys are discovered from the issuer's /.well-known/openid-configuration.
+	Issuer  string `json:"issuer" yaml:"issuer"`
+	JWKSURL string `json:"jwks_url" yaml:"jwks_url"`
+	// Audience, when set, must appear in the aud claim.
+	Audience string `json:"audience" yaml:"audience"`
+	// TenantClaim names the claim listing the tenants the caller may use, as
+	// a string or an array; without it every tenant is allowed.
+	TenantClaim string `json:"tenant_claim" yaml:"tenant_claim"`
+	// RolesClaim names the claim listing the caller's roles, as a string or an array.
+	RolesClaim string `json:"roles_claim" yaml:"roles_claim"`
+	// RoleScopes maps roles to API scopes; a role named like a scope grants
+	// that scope unless mapped otherwise. The caller gets the widest scope
+	// of its roles.
+	RoleScopes map[string]string `json:"role_scopes,omitempty" yaml:"role_scopes"`
+	// KeyRefresh is how long fetched signing keys are used before they are fetched again.
+	KeyRefresh Duration `json:"key_refresh" yaml:"key_refresh"`
+}
+
+// enabled reports whether bearer tokens should be validated.
+func (cfg OIDCConfig) enabled() bool {
+	return cfg.Issuer != "" || cfg.JWKSURL != ""
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg OIDCConfig) validate() error {
+	if !cfg.enabled() {
+		return nil
+	}
+	if cfg.RolesClaim == "" {
+		return errors.New("roles_claim must not be empty")
+	}
+	if cfg.KeyRefresh <= 0 {
+		return errors.New("key_refresh must be positive")
+	}
+	for role, scope := range cfg.RoleScopes {
+		if _, ok := scopeLevels[scope]; !ok {
+			return fmt.Errorf("role_scopes: role %q maps to unknown scope %q", role, scope)
+		}
+	}
+	return nil
+}
+
+// Limits on fetching signing keys.
+const (
+	oidcFetchTimeout = 5 * time.Second
+	// oidcMinRefetch keeps tokens with unknown key IDs from making the
+	// service hammer the provider.
+	oidcMinRefetch = time.Minute
+	// oidcClockSkew is the leeway allowed on the exp and nbf claims.
+	oidcClockSkew = time.Minute
+)
+
+// oidcVerifier validates JWTs against the provider's signing keys, which it
+// fetches on demand and caches.
+type oidcVerifier struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu   sync.Mutex
+	keys map[string]crypto.PublicKey // by key ID
+	// fetchedAt is when the keys were last fetched, or a fetch last failed
+	// with fetchErr.
+	fetchedAt time.Time
+	fetchErr  error
+	// fetching is closed when the fetch in flight ends; it is nil when no
+	// fetch is running.
+	fetching chan struct{}
+}
+
+// newOIDCVerifier returns a verifier for cfg, or nil when OIDC is disabled.
+func newOIDCVerifier(cfg OIDCConfig) *oidcVerifier {
+	if !cfg.enabled() {
+		return nil
+	}
+	return &oidcVerifier{cfg: cfg, client: &http.Client{Timeout: oidcFetchTimeout}}
+}
+
+// jwtHeader is the decoded JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verify checks the token's signature and claims and returns the caller it identifies.
+func (v *oidcVerifier) verify(ctx context.Context, token string) (principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return principal{}, errors.New("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return principal{}, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return principal{}, fmt.Errorf("invalid token signature: %w", err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return principal{}, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return principal{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return principal{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return principal{}, err
+	}
+
+	caller := principal{name: fmt.Sprint(claims["sub"])}
+	for _, role := range claimStrings(claims[v.cfg.RolesClaim]) {
+		scope, ok := v.cfg.RoleScopes[role]
+		if !ok {
+			scope = role
+		}
+		if scopeLevels[scope] > scopeLevels[caller.scope] {
+			caller.scope = scope
+		}
+	}
+	if v.cfg.TenantClaim != "" {
+		if value, ok := claims[v.cfg.TenantClaim]; ok {
+			// A present but empty claim grants no tenant at all.
+			caller.tenants = append([]string{}, claimStrings(value)...)
+		}
+	}
+	return caller, nil
+}
+
+// checkClaims checks the expiry, not-before, issuer and audience claims.
+func (v *oidcVerifier) checkClaims(claims map[string]any, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if v.cfg.Issuer != "" && claims["iss"] != v.cfg.Issuer {
+		return fmt.Errorf("unexpected token issuer %v", claims["iss"])
+	}
+	if v.cfg.Audience != "" {
+		found := false
+		for _, aud := range claimStrings(claims["aud"]) {
+			found = found || aud == v.cfg.Audience
+		}
+		if !found {
+			return errors.New("token is not intended for this audience")
+		}
+	}
+	return nil
+}
+
+// claimStrings returns a claim holding a string or an array of strings as a slice.
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var values []string
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a token into v.
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature checks the signature of signed with key using alg. Only
+// the asymmetric algorithms used by OIDC providers are accepted.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			if rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+				return nil
+			}
+		case "PS":
+			if rsa.VerifyPSS(key, hash, digest, signature, nil) == nil {
+				return nil
+			}
+		default:
+			return fmt.Errorf("token algorithm %q does not match the RSA signing key", alg)
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" {
+			return fmt.Errorf("token algorithm %q does not match the EC signing key", alg)
+		}
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// key returns the signing key with the given ID, fetching the provider's
+// keys when they are stale or do not include it. Only one fetch runs at a
+// time, outside the lock, and none starts within oidcMinRefetch of the last
+// one, whether that succeeded or failed.
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	for {
+		v.mu.Lock()
+		key, ok := v.lookup(kid)
+		age := time.Since(v.fetchedAt)
+		if ok && v.fetchErr == nil && age < time.Duration(v.cfg.KeyRefresh) {
+			v.mu.Unlock()
+			return key, nil
+		}
+		if age < oidcMinRefetch || (ok && v.fetching != nil) {
+			fetchErr := v.fetchErr
+			v.mu.Unlock()
+			switch {
+			case ok:
+				// Keep using the cached key while the provider is
+				// unreachable or being asked again.
+				return key, nil
+			case fetchErr != nil:
+				return nil, fmt.Errorf("failed to fetch signing keys: %w", fetchErr)
+			}
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if fetching := v.fetching; fetching != nil {
+			v.mu.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		fetching := make(chan struct{})
+		v.fetching = fetching
+		v.mu.Unlock()
+
+		// Other requests wait for this fetch, so it is not cut short when
+		// this request ends.
+		keys, err := v.fetchKeys(context.WithoutCancel(ctx))
+		v.mu.Lock()
+		if err == nil {
+			v.keys = keys
+		}
+		v.fetchedAt, v.fetchErr, v.fetching = time.Now(), err, nil
+		close(fetching)
+		v.mu.Unlock()
+	}
+}
+
+// lookup returns the cached key with the given ID. A token without a key ID
+// may only be checked against a single cached key. The caller must hold the lock.
+func (v *oidcVerifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey is a public key of a JWKS document.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA modulus and exponent.
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC curve and point.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys downloads the provider's signing keys, discovering the JWKS
+// URL from the issuer when it is not configured. Keys of unsupported types
+// are skipped.
+func (v *oidcVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	jwksURL := v.cfg.JWKSURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.cfg.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("provider configuration has no jwks_uri")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// getJSON fetches url and decodes its JSON body into v.
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, oidcFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// publicKey converts the JWK into an RSA or ECDSA public key.
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
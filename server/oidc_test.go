@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOIDCKeyFetch(t *testing.T) {
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := map[string]any{"keys": []jsonWebKey{{
+		Kty: "EC",
+		Kid: "k1",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(signingKey.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(signingKey.Y.FillBytes(make([]byte, 32))),
+	}}}
+
+	t.Run("single fetch", func(t *testing.T) {
+		release := make(chan struct{})
+		var fetches atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			<-release
+			json.NewEncoder(w).Encode(jwks)
+		}))
+		defer srv.Close()
+		var releaseOnce sync.Once
+		releaseFetch := func() { releaseOnce.Do(func() { close(release) }) }
+		defer releaseFetch()
+		v := newOIDCVerifier(OIDCConfig{JWKSURL: srv.URL, RolesClaim: "roles", KeyRefresh: Duration(time.Hour)})
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := v.key(context.Background(), "k1")
+				errs <- err
+			}()
+		}
+		// The lock is not held while the fetch is in flight.
+		time.Sleep(50 * time.Millisecond)
+		done := make(chan struct{})
+		go func() {
+			v.mu.Lock()
+			v.mu.Unlock()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("verifier lock is held during the key fetch")
+		}
+		releaseFetch()
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Errorf("key lookup failed: %v", err)
+			}
+		}
+		if n := fetches.Load(); n != 1 {
+			t.Errorf("fetched the keys %d times for 10 concurrent lookups, want 1", n)
+		}
+	})
+
+	t.Run("failed fetch backs off", func(t *testing.T) {
+		var fetches atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		v := newOIDCVerifier(OIDCConfig{JWKSURL: srv.URL, RolesClaim: "roles", KeyRefresh: Duration(time.Hour)})
+
+		for range 3 {
+			if _, err := v.key(context.Background(), "k1"); err == nil {
+				t.Fatal("key lookup succeeded with the provider down")
+			}
+		}
+		if n := fetches.Load(); n != 1 {
+			t.Errorf("fetched the keys %d times after a failure, want 1 until oidcMinRefetch passes", n)
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"task/menu"
+)
+
+// openAPIDocument describes every endpoint, its parameters and the MenuPlan
+// schema as an OpenAPI 3.0 document, so clients can generate SDKs. It is
+// served at /openapi.json and requests are validated against it.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// openAPISpec is openAPIDocument decoded for validation.
+var openAPISpec = func() map[string]any {
+	var spec map[string]any
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		panic(fmt.Sprintf("openapi.json: %v", err))
+	}
+	return spec
+}()
+
+// openAPIHandler handles GET /openapi.json.
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// withOpenAPIValidation wraps next, rejecting requests whose query parameters
+// or JSON body do not match the operation the mux routes them to, with a
+// detail for each parameter or field at fault. Requests to routes the
+// document does not describe, CSV bodies and malformed JSON are passed on
+// for the handlers to deal with.
+func withOpenAPIValidation(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		op, params := openAPIOperation(pattern, r.Method)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var errs schemaErrors
+		validateQuery(params, r, &errs)
+		validateBody(op, r, &errs)
+		if len(errs) > 0 {
+			apiErr := newAPIError(http.StatusBadRequest, "Request does not match the API schema: "+errs[0].Message)
+			if len(errs) > 1 {
+				apiErr.Message += fmt.Sprintf(" (and %d more; see details)", len(errs)-1)
+			}
+			apiErr.Details = errs
+			writeAPIError(w, http.StatusBadRequest, apiErr)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// schemaErrors collects the places a request departs from the API schema.
+type schemaErrors []errorDetail
+
+// add records that the value at field, described as location in the
+// message, is invalid.
+func (errs *schemaErrors) add(field, location, format string, args ...any) {
+	*errs = append(*errs, errorDetail{Field: field, Message: location + " " + fmt.Sprintf(format, args...)})
+}
+
+// openAPIOperation returns the operation of the document describing method
+// on the mux route pattern, such as "GET /plans/{id}", together with its
+// path-level and operation-level parameters, or nil if none does.
+func openAPIOperation(pattern, method string) (map[string]any, []map[string]any) {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	paths, _ := openAPISpec["paths"].(map[string]any)
+	item, _ := paths[pattern].(map[string]any)
+	op, _ := item[strings.ToLower(method)].(map[string]any)
+	if op == nil {
+		return nil, nil
+	}
+	var params []map[string]any
+	for _, list := range []any{item["parameters"], op["parameters"]} {
+		entries, _ := list.([]any)
+		for _, entry := range entries {
+			if param, ok := resolveRef(entry).(map[string]any); ok {
+				params = append(params, param)
+			}
+		}
+	}
+	return op, params
+}
+
+// resolveRef follows a local "$ref" such as "#/components/schemas/MenuPlan".
+func resolveRef(node any) any {
+	for {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return node
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return node
+		}
+		var target any = openAPISpec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			obj, _ := target.(map[string]any)
+			target = obj[part]
+		}
+		node = target
+	}
+}
+
+// validateQuery checks the query parameters of r against params.
+func validateQuery(params []map[string]any, r *http.Request, errs *schemaErrors) {
+	query := r.URL.Query()
+	for _, param := range params {
+		if param["in"] != "query" {
+			continue
+		}
+		name, _ := param["name"].(string)
+		location := "query parameter " + name
+		values, present := query[name]
+		if !present {
+			if param["required"] == true {
+				errs.add(name, location, "is required")
+			}
+			continue
+		}
+		schema, _ := resolveRef(param["schema"]).(map[string]any)
+		for _, raw := range values {
+			if raw == "" {
+				continue // handlers treat an empty value as absent
+			}
+			value, err := parseQueryValue(schema, raw)
+			if err != nil {
+				errs.add(name, location, "%v", err)
+				continue
+			}
+			validateValue(schema, value, name, location, errs)
+		}
+	}
+}
+
+// parseQueryValue converts a query parameter to the JSON value its schema
+// type expects.
+func parseQueryValue(schema map[string]any, raw string) (any, error) {
+	switch schema["type"] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// validateBody checks a JSON request body against the operation's schema,
+// leaving r.Body readable by the handler.
+func validateBody(op map[string]any, r *http.Request, errs *schemaErrors) {
+	body, _ := resolveRef(op["requestBody"]).(map[string]any)
+	if body == nil || menu.IsCSVContentType(r.Header.Get("Content-Type")) {
+		return
+	}
+	content, _ := body["content"].(map[string]any)
+	media, _ := content["application/json"].(map[string]any)
+	if media == nil {
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body["required"] == true {
+			errs.add("body", "request body", "is required")
+		}
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return
+	}
+	validateValue(media["schema"], value, "", "body", errs)
+}
+
+// validateValue checks value against the subset of JSON Schema the document
+// uses. A null is accepted anywhere, as it decodes to the zero value. field
+// is the path of value in the request, such as menu_items[2].calories, and
+// location describes it in messages.
+func validateValue(schemaNode any, value any, field, location string, errs *schemaErrors) {
+	schema, _ := resolveRef(schemaNode).(map[string]any)
+	if schema == nil || value == nil {
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok && !enumContains(enum, value) {
+		options := make([]string, len(enum))
+		for i, option := range enum {
+			options[i] = fmt.Sprint(option)
+		}
+		errs.add(field, location, "must be one of %s", strings.Join(options, ", "))
+		return
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			errs.add(field, location, "must be an object")
+			return
+		}
+		validateObject(schema, obj, field, location, errs)
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			errs.add(field, location, "must be an array")
+			return
+		}
+		if min, ok := schema["minItems"].(float64); ok && float64(len(list)) < min {
+			errs.add(field, location, "must have at least %g items", min)
+		}
+		for i, elem := range list {
+			index := fmt.Sprintf("[%d]", i)
+			validateValue(schema["items"], elem, field+index, location+index, errs)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			errs.add(field, location, "must be a string")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs.add(field, location, "must be a boolean")
+		}
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			errs.add(field, location, "must be a number")
+			return
+		}
+		f, err := num.Float64()
+		if err != nil {
+			errs.add(field, location, "must be a number")
+			return
+		}
+		if schema["type"] == "integer" {
+			if _, err := num.Int64(); err != nil || f != math.Trunc(f) {
+				errs.add(field, location, "must be an integer")
+				return
+			}
+		}
+		if min, ok := schema["minimum"].(float64); ok && f < min {
+			errs.add(field, location, "must be at least %g", min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && f > max {
+			errs.add(field, location, "must be at most %g", max)
+		}
+	}
+}
+
+// validateObject checks the required and known properties of obj, and the
+// values of a map-like object's additionalProperties. Unknown properties are
+// otherwise allowed, as the JSON decoder ignores them.
+func validateObject(schema map[string]any, obj map[string]any, field, location string, errs *schemaErrors) {
+	join := func(name string) string {
+		if field == "" {
+			return name
+		}
+		return field + "." + name
+	}
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := obj[name.(string)]; !ok {
+			errs.add(join(name.(string)), location+"."+name.(string), "is required")
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		propSchema, ok := props[key]
+		if !ok {
+			propSchema, ok = schema["additionalProperties"].(map[string]any)
+		}
+		if !ok {
+			continue
+		}
+		validateValue(propSchema, obj[key], join(key), location+"."+key, errs)
+	}
+}
+
+// enumContains reports whether value is one of the enum's options.
+func enumContains(enum []any, value any) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"task/menu"
+	"task/planner"
+)
+
+// A4 landscape page size and margin, in PDF points.
+const (
+	pdfPageWidth  = 842.0
+	pdfPageHeight = 595.0
+	pdfMargin     = 28.0
+)
+
+// pdfPage accumulates the content stream of a single PDF page drawn with the
+// built-in Helvetica fonts, which viewers provide without embedding.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// pdfLatin1 maps text to the single-byte WinAnsi encoding of the built-in
+// fonts, replacing characters it cannot represent.
+func pdfLatin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '—' || r == '–':
+			out = append(out, '-')
+		case r < 0x20:
+			out = append(out, ' ')
+		case r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfString encodes text as a PDF literal string.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range pdfLatin1(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// text draws s with its baseline starting at x, y. Coordinates start at the
+// top left corner of the page.
+func (p *pdfPage) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, pdfPageHeight-y, pdfString(s))
+}
+
+// rect draws a rectangle with its top left corner at x, y, filled with the
+// given gray level (0 black, 1 white) and outlined in light gray.
+func (p *pdfPage) rect(x, y, w, h, fillGray float64) {
+	fmt.Fprintf(&p.content, "q %.2f g 0.75 G 0.5 w %.2f %.2f %.2f %.2f re B Q\n", fillGray, x, pdfPageHeight-y-h, w, h)
+}
+
+// pdfTextWidth estimates the width of s in Helvetica; the average glyph is
+// about half as wide as the font size.
+func pdfTextWidth(s string, size float64) float64 {
+	return float64(len(pdfLatin1(s))) * size * 0.5
+}
+
+// wrapPDFText breaks s into lines no wider than width.
+func wrapPDFText(s string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && pdfTextWidth(candidate, size) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeTo writes a complete one-page PDF document holding the page.
+func (p *pdfPage) writeTo(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+		"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pdfPageWidth, pdfPageHeight))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// renderMenuPlanPDF lays the plan out on one landscape page: a column per
+// day, up to a week per row, with a card per combo and the day's calorie
+// total under its name. Text that does not fit a card is cut off.
+func renderMenuPlanPDF(plan planner.MenuPlan) *pdfPage {
+	plan = planner.ReportedEnergy(plan)
+	unit := energyUnit(plan.EnergyUnit)
+	page := &pdfPage{}
+	title := "Weekly Menu"
+	if plan.PlanID != "" {
+		title += " - plan " + plan.PlanID
+	}
+	page.text(pdfMargin, pdfMargin+14, 18, true, title)
+	page.text(pdfMargin, pdfMargin+30, 9, false,
+		fmt.Sprintf("%d days, %d %s in total", len(plan.MenuPlan), plan.TotalCalories, unit))
+
+	days := len(plan.MenuPlan)
+	if days == 0 {
+		return page
+	}
+	columns := min(days, len(menu.DayNames))
+	rows := (days + columns - 1) / columns
+	const gap = 6.0
+	top := pdfMargin + 42
+	columnWidth := (pdfPageWidth - 2*pdfMargin - gap*float64(columns-1)) / float64(columns)
+	rowHeight := (pdfPageHeight - top - pdfMargin - gap*float64(rows-1)) / float64(rows)
+
+	for i, day := range plan.MenuPlan {
+		x := pdfMargin + float64(i%columns)*(columnWidth+gap)
+		y := top + float64(i/columns)*(rowHeight+gap)
+		page.rect(x, y, columnWidth, 30, 0.9)
+		page.text(x+5, y+13, 11, true, day.Day)
+		summary := fmt.Sprintf("%d %s", day.TotalCalories, unit)
+		if day.Date != "" {
+			summary = day.Date + "  " + summary
+		}
+		page.text(x+5, y+25, 8, false, summary)
+
+		if len(day.Combos) == 0 {
+			continue
+		}
+		cardTop := y + 34
+		cardHeight := (rowHeight-34)/float64(len(day.Combos)) - 4
+		const lineHeight, size = 9.0, 7.0
+		for c, combo := range day.Combos {
+			cy := cardTop + float64(c)*(cardHeight+4)
+			page.rect(x, cy, columnWidth, cardHeight, 1)
+			heading := combo.ComboID
+			if combo.Meal != "" {
+				heading = combo.Meal
+			}
+			var lines []string
+			for _, component := range combo.Components {
+				name := component.ItemName
+				if component.Portion != "" {
+					name += " (" + component.Portion + ")"
+				}
+				lines = append(lines, wrapPDFText(name, size, columnWidth-10)...)
+			}
+			footer := fmt.Sprintf("%d %s, grade %s", combo.CalorieCount, unit, combo.HealthGrade)
+
+			page.text(x+5, cy+11, 8, true, heading)
+			maxLines := int((cardHeight - 27) / lineHeight)
+			for l, line := range lines {
+				if l >= maxLines {
+					break
+				}
+				page.text(x+5, cy+22+float64(l)*lineHeight, size, false, line)
+			}
+			page.text(x+5, cy+cardHeight-5, size, true, footer)
+		}
+	}
+	return page
+}
+
+// pdfHandler handles GET /plans/{id}/pdf, rendering the plan as a printable
+// one-page weekly menu.
+func pdfHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="menu_plan_%s.pdf"`, plan.PlanID))
+	if err := renderMenuPlanPDF(plan).writeTo(w); err != nil {
+		requestLogger(r).Error("writing plan PDF failed", "error", err)
+	}
+}
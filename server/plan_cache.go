@@ -0,0 +1,139 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"task/planner"
+)
+
+// planCache keeps the most recently generated plans by the inputs that
+// determined them, so a repeated request for a seeded plan is answered
+// without generating it again. It is safe for concurrent use.
+type planCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// order holds the entries, most recently used first.
+	order *list.List
+}
+
+type planCacheEntry struct {
+	key  string
+	plan planner.MenuPlan
+}
+
+// newPlanCache returns a cache holding at most size plans, or nil, which
+// caches nothing, when size is 0.
+func newPlanCache(size int) *planCache {
+	if size <= 0 {
+		return nil
+	}
+	return &planCache{size: size, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get returns the plan cached under key.
+func (c *planCache) get(key string) (planner.MenuPlan, bool) {
+	if c == nil {
+		return planner.MenuPlan{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return planner.MenuPlan{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*planCacheEntry).plan, true
+}
+
+// put caches plan under key, evicting the least recently used plan when the
+// cache is full.
+func (c *planCache) put(key string, plan planner.MenuPlan) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*planCacheEntry).plan = plan
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&planCacheEntry{key: key, plan: plan})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*planCacheEntry).key)
+	}
+}
+
+// forget drops the plan with the given ID, which no longer matches what is
+// stored under it once a day or combo of it is regenerated.
+func (c *planCache) forget(planID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if e.Value.(*planCacheEntry).plan.PlanID == planID {
+			c.order.Remove(e)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheKey identifies the plan gen generates by a hash of the menu, the
+// generation settings and the seed. Only seeded generations are cached:
+// without a seed every request is meant to get a different plan. Requests
+// for debug statistics always generate, since the statistics are not kept.
+func (gen menuGeneration) cacheKey() (string, bool) {
+	if gen.opts.Seed == nil || gen.opts.Debug != nil {
+		return "", false
+	}
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	if err := enc.Encode(gen.items); err != nil {
+		return "", false
+	}
+	if err := enc.Encode(gen.opts); err != nil {
+		return "", false
+	}
+	fmt.Fprintf(h, "verify_nutrition=%t\n", gen.verifyNutrition)
+	// Without a start date the plan starts this week, and the items in
+	// season follow the date.
+	fmt.Fprintf(h, "start_date=%s\n", gen.opts.FirstDate().Format(time.DateOnly))
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// planETag returns the entity tag of plan written in format. It is weak
+// because zip archives carry the time they were written.
+func planETag(plan planner.MenuPlan, format, entryFormat string) string {
+	sum := sha256.Sum256([]byte(plan.PlanID + "\x00" + format + "\x00" + entryFormat))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header of r lists etag,
+// comparing tags weakly.
+func etagMatches(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
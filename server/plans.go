@@ -0,0 +1,227 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"task/planner"
+)
+
+// Page sizes of the plan listing.
+const (
+	defaultPlanPageSize = 50
+	maxPlanPageSize     = 500
+)
+
+// PlanPage is one page of the plan listing.
+type PlanPage struct {
+	Plans []PlanSummary `json:"plans"`
+	// Total counts every plan matching the filter, across all pages.
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// PlanFilter selects the plans listed by GET /plans. Zero fields do not filter.
+type PlanFilter struct {
+	// From and To bound the creation time; To is exclusive.
+	From, To time.Time
+	// MinCalories and MaxCalories bound the calorie window plans were generated with.
+	MinCalories, MaxCalories int
+	// Days is the exact number of days of the plan.
+	Days          int
+	Limit, Offset int
+}
+
+// parsePlanFilter reads a PlanFilter from the query parameters from, to
+// (RFC 3339 times or YYYY-MM-DD dates; a date as to includes that whole day),
+// min_calories, max_calories, days, limit and offset.
+func parsePlanFilter(query url.Values) (PlanFilter, error) {
+	filter := PlanFilter{Limit: defaultPlanPageSize}
+	parseTime := func(name string, endOfDay bool) (time.Time, error) {
+		raw := query.Get(name)
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return t, fmt.Errorf("invalid %s %q: expected an RFC 3339 time or YYYY-MM-DD date", name, raw)
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	var err error
+	if filter.From, err = parseTime("from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime("to", true); err != nil {
+		return filter, err
+	}
+
+	intParams := []struct {
+		name   string
+		target *int
+	}{
+		{"min_calories", &filter.MinCalories},
+		{"max_calories", &filter.MaxCalories},
+		{"days", &filter.Days},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	}
+	for _, p := range intParams {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return filter, fmt.Errorf("invalid %s %q: must be a non-negative integer", p.name, raw)
+		}
+		*p.target = value
+	}
+	if filter.Limit < 1 || filter.Limit > maxPlanPageSize {
+		return filter, fmt.Errorf("limit must be between 1 and %d", maxPlanPageSize)
+	}
+	if filter.MaxCalories > 0 && filter.MinCalories > filter.MaxCalories {
+		return filter, errors.New("min_calories must not exceed max_calories")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+	return filter, nil
+}
+
+// matches reports whether plan passes the filter. A calorie range matches
+// plans whose calorie window lies within it; plans without a creation time
+// never match a time range.
+func (f PlanFilter) matches(plan planner.MenuPlan) bool {
+	if !f.From.IsZero() || !f.To.IsZero() {
+		if plan.CreatedAt == nil {
+			return false
+		}
+		if !f.From.IsZero() && plan.CreatedAt.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && !plan.CreatedAt.Before(f.To) {
+			return false
+		}
+	}
+	if f.MinCalories > 0 && plan.CalorieWindow.MinCalories < f.MinCalories {
+		return false
+	}
+	if f.MaxCalories > 0 && plan.CalorieWindow.MaxCalories > f.MaxCalories {
+		return false
+	}
+	return f.Days == 0 || len(plan.MenuPlan) == f.Days
+}
+
+// PlanSummary describes a stored plan in the plan listing.
+type PlanSummary struct {
+	PlanID        string                `json:"plan_id"`
+	CreatedAt     *time.Time            `json:"created_at,omitempty"`
+	Seed          int64                 `json:"seed"`
+	CalorieWindow planner.CalorieWindow `json:"calorie_window"`
+	Days          int                   `json:"days"`
+	Combos        int                   `json:"combos"`
+	TotalCalories int                   `json:"total_calories"`
+	TotalPrice    float64               `json:"total_price"`
+}
+
+// summarizePlan returns the listing entry of plan.
+func summarizePlan(plan planner.MenuPlan) PlanSummary {
+	summary := PlanSummary{
+		PlanID:        plan.PlanID,
+		CreatedAt:     plan.CreatedAt,
+		Seed:          plan.Seed,
+		CalorieWindow: plan.CalorieWindow,
+		Days:          len(plan.MenuPlan),
+		TotalCalories: plan.TotalCalories,
+		TotalPrice:    plan.TotalPrice,
+	}
+	for _, day := range plan.MenuPlan {
+		summary.Combos += len(day.Combos)
+	}
+	return summary
+}
+
+// lookupPlan loads the plan named by the {id} path value, writing an error
+// response and reporting false when it cannot be loaded.
+func lookupPlan(w http.ResponseWriter, r *http.Request) (planner.MenuPlan, bool) {
+	plan, err := currentTenant(r).storage.GetPlan(r.PathValue("id"))
+	if errors.Is(err, errPlanNotFound) {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return plan, false
+	}
+	if err != nil {
+		requestLogger(r).Error("loading plan failed", "plan_id", r.PathValue("id"), "error", err)
+		writeError(w, "Unable to load the plan.", http.StatusInternalServerError)
+		return plan, false
+	}
+	return plan, true
+}
+
+// getPlanHandler handles GET /plans/{id}, writing the stored plan in the
+// format requested like /generate-menu does.
+func getPlanHandler(w http.ResponseWriter, r *http.Request) {
+	format, entryFormat, err := requestedOutputFormat(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	if err := writeMenuPlan(w, plan, format, entryFormat); err != nil {
+		requestLogger(r).Error("writing menu plan failed", "error", err)
+	}
+}
+
+// listPlansHandler handles GET /plans, listing summaries of the stored plans
+// that match the filter query parameters (see parsePlanFilter), newest
+// first, one page at a time.
+func listPlansHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePlanFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, fmt.Sprintf("Invalid plan filter: %v", err), http.StatusBadRequest)
+		return
+	}
+	plans, err := currentTenant(r).storage.ListPlans()
+	if err != nil {
+		requestLogger(r).Error("listing plans failed", "error", err)
+		writeError(w, "Unable to list plans.", http.StatusInternalServerError)
+		return
+	}
+	matching, total := filter.page(plans)
+	page := PlanPage{Plans: []PlanSummary{}, Total: total, Limit: filter.Limit, Offset: filter.Offset}
+	for _, plan := range matching {
+		page.Plans = append(page.Plans, summarizePlan(plan))
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// page returns the plans matching the filter within its page, and how many
+// match in total.
+func (f PlanFilter) page(plans []planner.MenuPlan) ([]planner.MenuPlan, int) {
+	var matching []planner.MenuPlan
+	total := 0
+	for _, plan := range plans {
+		if !f.matches(plan) {
+			continue
+		}
+		if total >= f.Offset && len(matching) < f.Limit {
+			matching = append(matching, plan)
+		}
+		total++
+	}
+	return matching, total
+}
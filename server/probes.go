@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout bounds how long a readiness check waits on a database.
+const readinessTimeout = 2 * time.Second
+
+// shuttingDown is set once the server starts draining, so readiness probes
+// fail and load balancers stop sending new requests.
+var shuttingDown atomic.Bool
+
+// Ping checks that the database can be reached.
+func (s *sqlStorage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// checkReady verifies that the tenant's database, if any, is reachable and
+// that its master menu can be loaded, parsed and is not empty.
+func (t *tenant) checkReady(ctx context.Context) error {
+	if pinger, ok := t.storage.(interface{ Ping(context.Context) error }); ok {
+		if err := pinger.Ping(ctx); err != nil {
+			return fmt.Errorf("database unreachable: %w", err)
+		}
+	}
+	items, _, err := t.storage.LoadMenu()
+	if err != nil {
+		return fmt.Errorf("loading menu: %w", err)
+	}
+	if len(items) == 0 {
+		return errors.New("master menu is empty")
+	}
+	return nil
+}
+
+// healthzHandler handles GET /healthz, reporting that the process is alive.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyzHandler handles GET /readyz, reporting whether every tenant can serve
+// requests. Failures are logged rather than returned, since probes are
+// answered without authentication.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if shuttingDown.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ready := true
+	for _, name := range names {
+		if err := tenants[name].checkReady(ctx); err != nil {
+			requestLogger(r).Warn("readiness check failed", "checked_tenant", name, "error", err)
+			ready = false
+		}
+	}
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
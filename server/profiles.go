@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"task/planner"
+)
+
+var errProfileExists = errors.New("profile already exists")
+
+// Profile is a named set of preferences for a recurring audience, applied to
+// /generate-menu with the profile query parameter.
+type Profile struct {
+	Name string `json:"name"`
+	// DietaryTags must be carried by every item; ExcludeAllergens may not be
+	// contained in any item.
+	DietaryTags      []string `json:"dietary_tags,omitempty"`
+	ExcludeAllergens []string `json:"exclude_allergens,omitempty"`
+	// DislikedItems names items left out of the audience's plans.
+	DislikedItems []string `json:"disliked_items,omitempty"`
+	// MinCalories and MaxCalories replace the default calorie window of each
+	// combo. Zero keeps the default.
+	MinCalories int `json:"min_calories,omitempty"`
+	MaxCalories int `json:"max_calories,omitempty"`
+}
+
+// validate reports the first problem with the profile.
+func (p Profile) validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(p.Name, "/?#") {
+		return fmt.Errorf("name %q must not contain '/', '?' or '#'", p.Name)
+	}
+	if p.MinCalories < 0 || p.MaxCalories < 0 {
+		return errors.New("min_calories and max_calories must not be negative")
+	}
+	if p.MaxCalories > 0 && p.MaxCalories < p.MinCalories {
+		return fmt.Errorf("max_calories (%d) must not be less than min_calories (%d)", p.MaxCalories, p.MinCalories)
+	}
+	for _, name := range p.DislikedItems {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("disliked_items must not contain empty names")
+		}
+	}
+	return nil
+}
+
+// apply adds the profile's preferences to opts. Tags, allergens and disliked
+// items are added to those of the request; the calorie window is only taken
+// from the profile when query does not set it.
+func (p Profile) apply(opts *planner.GenerationOptions, query url.Values) {
+	opts.Profile = p.Name
+	opts.DietaryTags = append(opts.DietaryTags, p.DietaryTags...)
+	opts.ExcludeAllergens = append(opts.ExcludeAllergens, p.ExcludeAllergens...)
+	opts.ExcludeItems = append(opts.ExcludeItems, p.DislikedItems...)
+	if p.MinCalories > 0 && query.Get("min_calories") == "" {
+		opts.MinCalories = p.MinCalories
+	}
+	if p.MaxCalories > 0 && query.Get("max_calories") == "" {
+		opts.MaxCalories = p.MaxCalories
+	}
+}
+
+// decodeProfile reads a profile from the request body and validates it.
+func decodeProfile(r *http.Request) (Profile, error) {
+	var profile Profile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		return profile, fmt.Errorf("invalid profile: %w", err)
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	return profile, nil
+}
+
+// profileStoreError writes the response for a failed profile storage call.
+func profileStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errProfileNotFound):
+		writeError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errProfileExists):
+		writeError(w, err.Error(), http.StatusConflict)
+	default:
+		requestLogger(r).Error("accessing profiles failed", "error", err)
+		writeError(w, "Unable to access the stored profiles.", http.StatusInternalServerError)
+	}
+}
+
+// listProfilesHandler handles GET /profiles.
+func listProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	profiles, err := currentTenant(r).storage.ListProfiles()
+	if err != nil {
+		profileStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+// getProfileHandler handles GET /profiles/{name}.
+func getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	profile, err := currentTenant(r).storage.GetProfile(r.PathValue("name"))
+	if err != nil {
+		profileStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// createProfileHandler handles POST /profiles.
+func createProfileHandler(w http.ResponseWriter, r *http.Request) {
+	profile, err := decodeProfile(r)
+	if err == nil {
+		err = profile.validate()
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	store := currentTenant(r).storage
+	if _, err := store.GetProfile(profile.Name); err == nil {
+		profileStoreError(w, r, fmt.Errorf("%w: %q", errProfileExists, profile.Name))
+		return
+	} else if !errors.Is(err, errProfileNotFound) {
+		profileStoreError(w, r, err)
+		return
+	}
+	if err := store.SaveProfile(profile); err != nil {
+		profileStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, profile)
+}
+
+// putProfileHandler handles PUT /profiles/{name}, creating or replacing the
+// named profile. The name in the body may be omitted but must otherwise
+// match the path.
+func putProfileHandler(w http.ResponseWriter, r *http.Request) {
+	profile, err := decodeProfile(r)
+	if err == nil {
+		name := r.PathValue("name")
+		if profile.Name != "" && profile.Name != name {
+			err = fmt.Errorf("profile name %q does not match the path", profile.Name)
+		}
+		profile.Name = name
+	}
+	if err == nil {
+		err = profile.validate()
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := currentTenant(r).storage.SaveProfile(profile); err != nil {
+		profileStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// deleteProfileHandler handles DELETE /profiles/{name}.
+func deleteProfileHandler(w http.ResponseWriter, r *http.Request) {
+	if err := currentTenant(r).storage.DeleteProfile(r.PathValue("name")); err != nil {
+		profileStoreError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
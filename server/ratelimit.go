@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig limits how often each client may call the generation
+// endpoints. It is disabled when RequestsPerMinute is zero.
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained rate each client is allowed.
+	RequestsPerMinute float64 `json:"requests_per_minute" yaml:"requests_per_minute"`
+	// Burst is how many requests a client may make at once after being idle.
+	Burst int `json:"burst" yaml:"burst"`
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg RateLimitConfig) validate() error {
+	if cfg.RequestsPerMinute < 0 {
+		return fmt.Errorf("requests_per_minute must not be negative, got %g", cfg.RequestsPerMinute)
+	}
+	if cfg.RequestsPerMinute > 0 && cfg.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1, got %d", cfg.Burst)
+	}
+	return nil
+}
+
+// tokenBucket holds the requests a client may still make; it refills
+// continuously up to the burst size.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	perSecond float64
+	burst     float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// generationLimiter limits the generation endpoints; nil when rate limiting is disabled.
+var generationLimiter *rateLimiter
+
+// newRateLimiter returns a limiter for cfg, or nil when it is disabled.
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		perSecond: cfg.RequestsPerMinute / 60,
+		burst:     float64(cfg.Burst),
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the client's bucket. When the bucket is empty it
+// reports false and how long until the next token arrives.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops, at most once a minute, the buckets that have refilled
+// completely, since a new bucket would be identical. The caller must hold the lock.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.perSecond * float64(time.Second))
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+type principalContextKey struct{}
+
+// clientID identifies the client of a request for rate limiting: the
+// authenticated caller when there is one, and the remote IP address otherwise.
+func clientID(r *http.Request) string {
+	if caller, ok := r.Context().Value(principalContextKey{}).(principal); ok && caller.name != "" {
+		return "caller:" + caller.name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// withPrincipal returns r carrying the authenticated caller for clientID.
+func withPrincipal(r *http.Request, caller principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey{}, caller))
+}
+
+// rateLimited wraps a generation handler so each client is held to the
+// configured rate, answering 429 Too Many Requests with a Retry-After header
+// once its bucket is empty.
+func rateLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if generationLimiter == nil {
+			next(w, r)
+			return
+		}
+		if ok, wait := generationLimiter.allow(clientID(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, "Too many generation requests; try again later.", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+// decodePlanLocks reads the optional PlanLocks body of a regeneration request.
+func decodePlanLocks(r *http.Request) (planner.PlanLocks, error) {
+	var locks planner.PlanLocks
+	if err := json.NewDecoder(r.Body).Decode(&locks); err != nil && err != io.EOF {
+		return locks, fmt.Errorf("invalid request body: %w", err)
+	}
+	return locks, nil
+}
+
+// planMutexes serializes the changes to each stored plan of a tenant, so two
+// requests regenerating parts of one plan at once both keep their update
+// instead of the later save dropping the earlier. Only requests served by
+// the same instance are serialized. The zero value is ready for use.
+type planMutexes struct {
+	mu    sync.Mutex
+	plans map[string]*planMutex
+}
+
+// planMutex is the mutex of one plan and the number of requests holding or
+// waiting for it, so it is dropped once none does.
+type planMutex struct {
+	sync.Mutex
+	users int
+}
+
+// lock waits until no other request is changing the plan with the given ID
+// and returns the function that lets the next one go on.
+func (m *planMutexes) lock(id string) (unlock func()) {
+	m.mu.Lock()
+	if m.plans == nil {
+		m.plans = make(map[string]*planMutex)
+	}
+	pm := m.plans[id]
+	if pm == nil {
+		pm = &planMutex{}
+		m.plans[id] = pm
+	}
+	pm.users++
+	m.mu.Unlock()
+
+	pm.Lock()
+	return func() {
+		pm.Unlock()
+		m.mu.Lock()
+		if pm.users--; pm.users == 0 {
+			delete(m.plans, id)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// planUpdate holds the settings shared by the requests that change a stored plan.
+type planUpdate struct {
+	format, entryFormat string
+	seed                int64
+}
+
+// parsePlanUpdate reads the output format and the optional seed query
+// parameter of a request that changes a stored plan, writing an error
+// response and reporting false when they are invalid.
+func parsePlanUpdate(w http.ResponseWriter, r *http.Request) (planUpdate, bool) {
+	var update planUpdate
+	var err error
+	update.format, update.entryFormat, err = requestedOutputFormat(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return update, false
+	}
+	update.seed = time.Now().UnixNano()
+	if raw := r.URL.Query().Get("seed"); raw != "" {
+		if update.seed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			writeError(w, fmt.Sprintf("Invalid seed value %q", raw), http.StatusBadRequest)
+			return update, false
+		}
+	}
+	return update, true
+}
+
+// saveUpdatedPlan stores a changed plan under its ID and writes it in the
+// requested format.
+func saveUpdatedPlan(w http.ResponseWriter, r *http.Request, t *tenant, plan planner.MenuPlan, update planUpdate) {
+	if err := t.storage.SavePlan(plan); err != nil {
+		requestLogger(r).Error("saving menu plan failed", "error", err)
+		writeError(w, "Unable to save the updated plan.", http.StatusInternalServerError)
+		return
+	}
+	t.plans.forget(plan.PlanID)
+	if err := writeMenuPlan(w, plan, update.format, update.entryFormat); err != nil {
+		requestLogger(r).Error("writing menu plan failed", "error", err)
+	}
+}
+
+// menuForUpdate returns the current master menu of t for regenerating part
+// of plan and records its version as the one plan was last generated from.
+// It writes an error response and reports false when the menu is empty.
+func menuForUpdate(w http.ResponseWriter, r *http.Request, t *tenant, plan *planner.MenuPlan) ([]menu.Item, *planner.ComboIndex, bool) {
+	items, index, version := t.menuSnapshot(r.Context())
+	if len(items) == 0 {
+		writeError(w, "Master menu is empty.", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	plan.MenuVersion = version
+	return items, index, true
+}
+
+// regenerateDayHandler handles POST /plans/{id}/days/{day}/regenerate. The
+// day is a 1-based day number or a day name; an optional PlanLocks body keeps
+// some of the day's combos, and the optional seed query parameter makes the
+// new combos reproducible. The updated plan is stored under the same ID and
+// written like GET /plans/{id}.
+func regenerateDayHandler(w http.ResponseWriter, r *http.Request) {
+	update, ok := parsePlanUpdate(w, r)
+	if !ok {
+		return
+	}
+	locks, err := decodePlanLocks(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer currentTenant(r).planEdits.lock(r.PathValue("id"))()
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	dayIndex, err := planner.FindDay(plan, r.PathValue("day"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	t := currentTenant(r)
+	items, index, ok := menuForUpdate(w, r, t, &plan)
+	if !ok {
+		return
+	}
+	opts := planner.PlanOptions(plan, t.defaults)
+	attachRequest(&opts, r)
+	ctx, cancel := generationContext(r.Context())
+	defer cancel()
+	if err := planner.RegenerateDay(ctx, &plan, opts, dayIndex, locks, items, index, update.seed); err != nil {
+		generationFailed(w, r, err)
+		return
+	}
+	saveUpdatedPlan(w, r, t, plan, update)
+}
+
+// regeneratePlanHandler handles POST /plans/{id}/regenerate, regenerating
+// every combo of the plan except those locked by an optional PlanLocks
+// body, e.g. {"locked_combos": ["combo_5"]}.
+func regeneratePlanHandler(w http.ResponseWriter, r *http.Request) {
+	update, ok := parsePlanUpdate(w, r)
+	if !ok {
+		return
+	}
+	locks, err := decodePlanLocks(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer currentTenant(r).planEdits.lock(r.PathValue("id"))()
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	for _, id := range locks.Combos {
+		if _, _, err := planner.FindCombo(plan, id); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	t := currentTenant(r)
+	items, index, ok := menuForUpdate(w, r, t, &plan)
+	if !ok {
+		return
+	}
+	opts := planner.PlanOptions(plan, t.defaults)
+	attachRequest(&opts, r)
+	ctx, cancel := generationContext(r.Context())
+	defer cancel()
+	if err := planner.RegeneratePlan(ctx, &plan, opts, locks, items, index, update.seed); err != nil {
+		generationFailed(w, r, err)
+		return
+	}
+	saveUpdatedPlan(w, r, t, plan, update)
+}
+
+// swapComboHandler handles POST /plans/{id}/combos/{combo_id}/swap. The
+// optional seed query parameter makes the choice reproducible. The updated
+// plan is stored under the same ID and written like GET /plans/{id}.
+func swapComboHandler(w http.ResponseWriter, r *http.Request) {
+	update, ok := parsePlanUpdate(w, r)
+	if !ok {
+		return
+	}
+	defer currentTenant(r).planEdits.lock(r.PathValue("id"))()
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	dayIndex, comboIndex, err := planner.FindCombo(plan, r.PathValue("combo_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	t := currentTenant(r)
+	items, index, ok := menuForUpdate(w, r, t, &plan)
+	if !ok {
+		return
+	}
+	opts := planner.PlanOptions(plan, t.defaults)
+	attachRequest(&opts, r)
+	ctx, cancel := generationContext(r.Context())
+	defer cancel()
+	if err := planner.SwapCombo(ctx, &plan, opts, dayIndex, comboIndex, items, index, update.seed); err != nil {
+		generationFailed(w, r, err)
+		return
+	}
+	saveUpdatedPlan(w, r, t, plan, update)
+}
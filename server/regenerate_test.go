@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"task/planner"
+)
+
+func TestConcurrentSwapsKeepEveryUpdate(t *testing.T) {
+	// SQLite hands out a copy of the plan on every load, as Postgres
+	// does, so a lost update shows.
+	top := useTestTenant(t, StorageConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "planner.db")})
+	items, index, _ := top.menu.Snapshot()
+	seed := int64(5)
+	opts := top.defaults
+	opts.Seed = &seed
+	plan, err := planner.GenerateWithIndex(context.Background(), items, opts, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.PlanID = "plan_1"
+	// The stored plan shares its days with plan, so keep the combos apart.
+	var before []planner.Combo
+	for _, day := range plan.MenuPlan {
+		before = append(before, day.Combos[0])
+	}
+	if err := top.storage.SavePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the first combo of every day at once; each request must build
+	// on the swaps saved before it.
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /plans/{id}/combos/{combo_id}/swap", swapComboHandler)
+	var wg sync.WaitGroup
+	for d, combo := range before {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := fmt.Sprintf("/plans/%s/combos/%s/swap?seed=%d", plan.PlanID, combo.ComboID, d)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("swapping %s answered %d: %s", combo.ComboID, rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := top.storage.GetPlan(plan.PlanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for d, day := range stored.MenuPlan {
+		if reflect.DeepEqual(day.Combos[0].Components, before[d].Components) {
+			t.Errorf("%s: swap of %s was lost", day.Day, before[d].ComboID)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"task/planner"
+)
+
+// ScheduleConfig lists plans the server generates on its own, so a weekly
+// menu needs no external cron.
+type ScheduleConfig struct {
+	// Timezone is the IANA time zone the cron expressions of the jobs and of
+	// notify.chat are read in; empty is the server's local time.
+	Timezone string         `json:"timezone" yaml:"timezone"`
+	Jobs     []ScheduledJob `json:"jobs" yaml:"jobs"`
+}
+
+// ScheduledJob generates and stores a plan whenever its cron expression
+// matches. The plan starts on the Monday after the run, so a job running on
+// Sunday night plans the coming week, and is delivered to the tenant's
+// webhooks like any other.
+type ScheduledJob struct {
+	Name string `json:"name" yaml:"name"`
+	// Cron has the five fields minute, hour, day of month, month and day of
+	// week (0 or 7 is Sunday), e.g. "0 22 * * 0" for Sunday at 22:00.
+	Cron string `json:"cron" yaml:"cron"`
+	// Tenant names the tenant the plan is generated for; empty is the
+	// top-level one.
+	Tenant string `json:"tenant" yaml:"tenant"`
+	// Profile names a stored preference profile applied to the plan.
+	Profile string `json:"profile" yaml:"profile"`
+	// Days overrides the tenant's default plan length when positive.
+	Days int `json:"days" yaml:"days"`
+}
+
+// validate reports the first setting that cannot be used. tenantNames holds
+// the configured tenants.
+func (cfg ScheduleConfig) validate(tenantNames map[string]TenantConfig) error {
+	if _, err := cfg.location(); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for i, job := range cfg.Jobs {
+		if job.Name == "" {
+			return fmt.Errorf("jobs[%d]: name is required", i)
+		}
+		if seen[job.Name] {
+			return fmt.Errorf("job %s is configured twice", job.Name)
+		}
+		seen[job.Name] = true
+		cron, err := parseCron(job.Cron)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		if cron.next(time.Now()).IsZero() {
+			return fmt.Errorf("job %s: cron %q never matches", job.Name, job.Cron)
+		}
+		if _, ok := tenantNames[job.Tenant]; job.Tenant != "" && !ok {
+			return fmt.Errorf("job %s: unknown tenant %q", job.Name, job.Tenant)
+		}
+		if job.Days < 0 {
+			return fmt.Errorf("job %s: days must not be negative", job.Name)
+		}
+	}
+	return nil
+}
+
+// location returns the time zone of the cron expressions.
+func (cfg ScheduleConfig) location() (*time.Location, error) {
+	if cfg.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+	}
+	return loc, nil
+}
+
+// scheduledTask is something the scheduler does whenever cron matches.
+type scheduledTask struct {
+	cron   cronSchedule
+	logger *slog.Logger
+	run    func(now time.Time)
+}
+
+// startScheduler runs every configured job, and posts each tenant's daily
+// chat summary, until ctx is done. The returned function waits for tasks
+// still running.
+func startScheduler(ctx context.Context, cfg ScheduleConfig) (func(), error) {
+	loc, err := cfg.location()
+	if err != nil {
+		return nil, err
+	}
+	var tasks []scheduledTask
+	for _, job := range cfg.Jobs {
+		cron, err := parseCron(job.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		logger := slog.With("job", job.Name, "tenant", job.Tenant)
+		tasks = append(tasks, scheduledTask{cron: cron, logger: logger, run: func(now time.Time) {
+			plan, err := job.run(ctx, logger, now)
+			if err != nil {
+				logger.Error("scheduled plan generation failed", "error", err)
+				return
+			}
+			logger.Info("scheduled plan generated", "plan_id", plan.PlanID)
+		}})
+	}
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := tenants[name]
+		if t.notify.Chat.Cron == "" {
+			continue
+		}
+		cron, err := parseCron(t.notify.Chat.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q chat: %w", name, err)
+		}
+		logger := slog.With("job", "chat", "tenant", name)
+		tasks = append(tasks, scheduledTask{cron: cron, logger: logger, run: func(now time.Time) {
+			if err := postTodayToChat(t, now); err != nil {
+				logger.Error("posting today's menu to chat failed", "error", err)
+			}
+		}})
+	}
+
+	var running sync.WaitGroup
+	for _, task := range tasks {
+		running.Add(1)
+		go func(task scheduledTask) {
+			defer running.Done()
+			for {
+				next := task.cron.next(time.Now().In(loc))
+				if next.IsZero() {
+					task.logger.Warn("cron never matches; task stopped")
+					return
+				}
+				task.logger.Info("task scheduled", "at", next.Format(time.RFC3339))
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				task.run(next)
+			}
+		}(task)
+	}
+	return running.Wait, nil
+}
+
+// run generates and stores the job's plan for the week after now, giving up
+// when ctx is done.
+func (job ScheduledJob) run(ctx context.Context, logger *slog.Logger, now time.Time) (planner.MenuPlan, error) {
+	t, ok := tenants[job.Tenant]
+	if !ok {
+		return planner.MenuPlan{}, fmt.Errorf("unknown tenant %q", job.Tenant)
+	}
+	opts := t.defaults
+	if job.Days > 0 {
+		opts.Days = job.Days
+	}
+	if job.Profile != "" {
+		profile, err := t.storage.GetProfile(job.Profile)
+		if err != nil {
+			return planner.MenuPlan{}, fmt.Errorf("%w: %q", err, job.Profile)
+		}
+		profile.apply(&opts, nil)
+	}
+	opts.StartDate = nextWeekStart(now)
+	if err := opts.Validate(); err != nil {
+		return planner.MenuPlan{}, fmt.Errorf("invalid generation settings: %w", err)
+	}
+	items, index, version := t.menuSnapshot(ctx)
+	if len(items) == 0 {
+		return planner.MenuPlan{}, errors.New("master menu is empty")
+	}
+	opts.Logger = logger
+	return menuGeneration{tenant: t, opts: opts, items: items, index: index, menuVersion: version}.run(ctx)
+}
+
+// nextWeekStart returns the date of the Monday after now, reading now in its
+// own location rather than UTC so that a late Sunday run west of Greenwich
+// still plans the coming week.
+func nextWeekStart(now time.Time) string {
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return planner.StartOfWeek(date).AddDate(0, 0, 7).Format(time.DateOnly)
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// Like cron, a day matches either day field when both are restricted.
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// cronFields gives the range of each cron field, in order.
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseCron parses a five-field cron expression. Each field is "*" or a
+// comma-separated list of values and ranges ("1-5"), optionally stepped
+// ("*/15", "8-18/2").
+func parseCron(expr string) (cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return cronSchedule{}, fmt.Errorf("cron %q must have 5 fields: minute, hour, day of month, month and day of week", expr)
+	}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("cron %q: %s: %w", expr, cronFields[i].name, err)
+		}
+		sets[i] = set
+	}
+	// 7 is another name for Sunday.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return cronSchedule{
+		minute: sets[0], hour: sets[1], dayOfMonth: sets[2], month: sets[3], dayOfWeek: sets[4],
+		anyDayOfMonth: fields[2] == "*", anyDayOfWeek: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the bit set of the values a field matches.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", after)
+			}
+			rangePart, step = before, n
+		}
+		lo, hi := min, max
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value %q", first)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid value %q", last)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("%q is outside %d-%d", rangePart, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t.
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dayOfMonth&(1<<t.Day()) != 0
+	dow := c.dayOfWeek&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDayOfMonth && c.anyDayOfWeek:
+		return true
+	case c.anyDayOfMonth:
+		return dow
+	case c.anyDayOfWeek:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next returns the first time after t the schedule matches, in t's location.
+// It returns the zero time when the expression never matches, such as on
+// February 30.
+func (c cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// useTestTenant makes a tenant with the given storage, seeded from the
+// sample menu, the top-level tenant until the test ends.
+func useTestTenant(t *testing.T, storage StorageConfig) *tenant {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.MenuPath = "../data/master_menu.json"
+	cfg.Storage = storage
+	top, err := openTenant("", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := tenants
+	tenants = map[string]*tenant{"": top}
+	t.Cleanup(func() {
+		tenants = saved
+		top.storage.Close()
+	})
+	return top
+}
+
+func TestScheduledJobPlansComingWeek(t *testing.T) {
+	useTestTenant(t, StorageConfig{Driver: "memory"})
+	// Sunday 22:00 seven hours west of UTC is already Monday in UTC, so
+	// reading the run time in UTC would skip the coming week.
+	sunday := time.Date(2026, time.October, 18, 22, 0, 0, 0, time.FixedZone("UTC-7", -7*60*60))
+	job := ScheduledJob{Name: "weekly", Cron: "0 22 * * 0", Days: 5}
+	plan, err := job.run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := plan.Options.StartDate, "2026-10-19"; got != want {
+		t.Errorf("plan generated on Sunday %s starts on %s, want the Monday after, %s", sunday.Format(time.DateOnly), got, want)
+	}
+}
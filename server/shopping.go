@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"task/planner"
+)
+
+// ShoppingList totals how often each item is served in a plan, so the
+// ingredients for the whole plan can be ordered at once.
+type ShoppingList struct {
+	PlanID string `json:"plan_id"`
+	// Servings multiplies every count, e.g. the number of guests served each combo.
+	Servings int            `json:"servings"`
+	Items    []ShoppingItem `json:"items"`
+	// TotalQuantity is the sum of the quantities of all items.
+	TotalQuantity int `json:"total_quantity"`
+}
+
+// ShoppingItem is one line of a ShoppingList. Each portion size of an item
+// is listed separately.
+type ShoppingItem struct {
+	ItemName string `json:"item_name"`
+	Category string `json:"category"`
+	Portion  string `json:"portion,omitempty"`
+	// Count is how many combos of the plan include the item.
+	Count int `json:"count"`
+	// Quantity is Count times the list's servings.
+	Quantity int `json:"quantity"`
+	// Days lists the days the item is served on, in plan order.
+	Days []string `json:"days"`
+}
+
+// buildShoppingList aggregates the items of every combo in plan, ordered by
+// category and then by item name.
+func buildShoppingList(plan planner.MenuPlan, servings int) ShoppingList {
+	type key struct{ name, portion string }
+	lines := make(map[key]*ShoppingItem)
+	for _, day := range plan.MenuPlan {
+		for _, combo := range day.Combos {
+			for _, component := range combo.Components {
+				k := key{component.ItemName, component.Portion}
+				line, ok := lines[k]
+				if !ok {
+					line = &ShoppingItem{ItemName: component.ItemName, Category: component.Category, Portion: component.Portion}
+					lines[k] = line
+				}
+				line.Count++
+				line.Quantity += servings
+				if n := len(line.Days); n == 0 || line.Days[n-1] != day.Day {
+					line.Days = append(line.Days, day.Day)
+				}
+			}
+		}
+	}
+
+	list := ShoppingList{PlanID: plan.PlanID, Servings: servings, Items: []ShoppingItem{}}
+	for _, line := range lines {
+		list.Items = append(list.Items, *line)
+		list.TotalQuantity += line.Quantity
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.ItemName != b.ItemName {
+			return a.ItemName < b.ItemName
+		}
+		return a.Portion < b.Portion
+	})
+	return list
+}
+
+// shoppingListHandler handles GET /plans/{id}/shopping-list. The optional
+// servings query parameter multiplies every count and defaults to 1.
+func shoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	servings := 1
+	if raw := r.URL.Query().Get("servings"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, fmt.Sprintf("Invalid servings value %q: must be a positive integer", raw), http.StatusBadRequest)
+			return
+		}
+		servings = n
+	}
+	plan, ok := lookupPlan(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, buildShoppingList(plan, servings))
+}
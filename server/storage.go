@@ -0,0 +1,273 @@
+package server
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"task/menu"
+	"task/planner"
+)
+
+var (
+	errPlanNotFound        = errors.New("plan not found")
+	errProfileNotFound     = errors.New("profile not found")
+	errMenuVersionNotFound = errors.New("menu version not found")
+)
+
+// Storage persists the master menu and its versions, generated plans,
+// preference profiles and webhooks.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// LoadMenu returns the stored master menu and its version. An empty
+	// result means nothing has been stored yet; version 0 means the menu was
+	// stored before versions were kept.
+	LoadMenu() ([]menu.Item, int, error)
+	// SaveMenu replaces the stored master menu and keeps it as the next
+	// version, which it returns.
+	SaveMenu(items []menu.Item) (int, error)
+	// GetMenuVersion returns a version of the master menu, or errMenuVersionNotFound.
+	GetMenuVersion(version int) (MenuVersion, error)
+	// ListMenuVersions returns every version of the master menu without its
+	// items, newest first.
+	ListMenuVersions() ([]MenuVersion, error)
+	// SavePlan stores a generated plan under its PlanID, replacing any plan
+	// stored under the same ID.
+	SavePlan(plan planner.MenuPlan) error
+	// GetPlan returns the plan with the given ID, or errPlanNotFound.
+	GetPlan(id string) (planner.MenuPlan, error)
+	// ListPlans returns every stored plan, newest first.
+	ListPlans() ([]planner.MenuPlan, error)
+	// SaveProfile stores a preference profile under its name, replacing any
+	// profile stored under the same name.
+	SaveProfile(profile Profile) error
+	// GetProfile returns the profile with the given name, or errProfileNotFound.
+	GetProfile(name string) (Profile, error)
+	// ListProfiles returns every stored profile, ordered by name.
+	ListProfiles() ([]Profile, error)
+	// DeleteProfile removes the named profile, or returns errProfileNotFound.
+	DeleteProfile(name string) error
+	// SaveWebhook stores a webhook under its ID, replacing any webhook
+	// stored under the same ID.
+	SaveWebhook(hook Webhook) error
+	// GetWebhook returns the webhook with the given ID, or errWebhookNotFound.
+	GetWebhook(id string) (Webhook, error)
+	// ListWebhooks returns every stored webhook, oldest first.
+	ListWebhooks() ([]Webhook, error)
+	// DeleteWebhook removes the webhook with the given ID, or returns errWebhookNotFound.
+	DeleteWebhook(id string) error
+	// Close releases any resources held by the storage.
+	Close() error
+}
+
+// memoryStorage keeps everything in process memory. It is the default when no
+// database is configured, so nothing survives a restart.
+type memoryStorage struct {
+	mu    sync.RWMutex
+	items []menu.Item
+	// versions holds every version of the menu; version n is at index n-1.
+	versions []MenuVersion
+	plans    map[string]planner.MenuPlan
+	// order lists plan IDs in the order they were saved.
+	order    []string
+	profiles map[string]Profile
+	webhooks map[string]Webhook
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{plans: make(map[string]planner.MenuPlan), profiles: make(map[string]Profile), webhooks: make(map[string]Webhook)}
+}
+
+func (s *memoryStorage) LoadMenu() ([]menu.Item, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]menu.Item(nil), s.items...), len(s.versions), nil
+}
+
+func (s *memoryStorage) SaveMenu(items []menu.Item) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append([]menu.Item(nil), items...)
+	version := MenuVersion{Version: len(s.versions) + 1, CreatedAt: time.Now().UTC(), ItemCount: len(items), Items: s.items}
+	s.versions = append(s.versions, version)
+	return version.Version, nil
+}
+
+func (s *memoryStorage) GetMenuVersion(version int) (MenuVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if version < 1 || version > len(s.versions) {
+		return MenuVersion{}, errMenuVersionNotFound
+	}
+	v := s.versions[version-1]
+	v.Items = append([]menu.Item(nil), v.Items...)
+	return v, nil
+}
+
+func (s *memoryStorage) ListMenuVersions() ([]MenuVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := make([]MenuVersion, 0, len(s.versions))
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		v := s.versions[i]
+		v.Items = nil
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (s *memoryStorage) SavePlan(plan planner.MenuPlan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.plans[plan.PlanID]; !ok {
+		s.order = append(s.order, plan.PlanID)
+	}
+	s.plans[plan.PlanID] = plan
+	return nil
+}
+
+func (s *memoryStorage) GetPlan(id string) (planner.MenuPlan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	plan, ok := s.plans[id]
+	if !ok {
+		return planner.MenuPlan{}, errPlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *memoryStorage) ListPlans() ([]planner.MenuPlan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	plans := make([]planner.MenuPlan, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		plans = append(plans, s.plans[s.order[i]])
+	}
+	return plans, nil
+}
+
+func (s *memoryStorage) SaveProfile(profile Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[profile.Name] = profile
+	return nil
+}
+
+func (s *memoryStorage) GetProfile(name string) (Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profile, ok := s.profiles[name]
+	if !ok {
+		return Profile{}, errProfileNotFound
+	}
+	return profile, nil
+}
+
+func (s *memoryStorage) ListProfiles() ([]Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+	return profiles, nil
+}
+
+func (s *memoryStorage) DeleteProfile(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[name]; !ok {
+		return errProfileNotFound
+	}
+	delete(s.profiles, name)
+	return nil
+}
+
+func (s *memoryStorage) SaveWebhook(hook Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[hook.ID] = hook
+	return nil
+}
+
+func (s *memoryStorage) GetWebhook(id string) (Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hook, ok := s.webhooks[id]
+	if !ok {
+		return Webhook{}, errWebhookNotFound
+	}
+	return hook, nil
+}
+
+func (s *memoryStorage) ListWebhooks() ([]Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hooks := make([]Webhook, 0, len(s.webhooks))
+	for _, hook := range s.webhooks {
+		hooks = append(hooks, hook)
+	}
+	slices.SortFunc(hooks, func(a, b Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return hooks, nil
+}
+
+func (s *memoryStorage) DeleteWebhook(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[id]; !ok {
+		return errWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	return nil
+}
+
+func (s *memoryStorage) Close() error { return nil }
+
+// isSharedStorage reports whether the storage may be modified by other
+// service instances, in which case cached copies must be refreshed before use.
+func isSharedStorage(s Storage) bool {
+	sqlStore, ok := s.(*sqlStorage)
+	return ok && sqlStore.shared
+}
+
+// defaultSQLitePath is the database file of the sqlite driver without a DSN.
+const defaultSQLitePath = "./data/planner.db"
+
+// openStorage opens the storage selected by cfg.Driver ("memory", "sqlite" or
+// "postgres"). For SQLite the DSN defaults to ./data/planner.db.
+func openStorage(cfg StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return newMemoryStorage(), nil
+	case "sqlite":
+		return openSQLiteStorage(cmp.Or(cfg.DSN, defaultSQLitePath))
+	case "postgres":
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("a DSN is required for the postgres storage driver")
+		}
+		return openPostgresStorage(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (expected memory, sqlite or postgres)", cfg.Driver)
+	}
+}
+
+// newPlanID returns a random identifier for a generated plan.
+func newPlanID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate plan ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+
+	"task/planner"
+)
+
+// Types of the progress events streamed while a plan is generated.
+const (
+	// eventDay carries one day of the plan as soon as it is generated.
+	eventDay = "day"
+	// eventPlan carries the whole plan once it is generated and stored.
+	eventPlan = "plan"
+	// eventError reports that the plan could not be completed.
+	eventError = "error"
+)
+
+// generationEvent is a progress event of a streamed generation.
+type generationEvent struct {
+	Type string `json:"type"`
+	// Day is the 1-based number of the day in Menu, out of Days.
+	Day  int                `json:"day,omitempty"`
+	Days int                `json:"days,omitempty"`
+	Menu *planner.DailyMenu `json:"menu,omitempty"`
+	Plan *planner.MenuPlan  `json:"plan,omitempty"`
+	// Error describes why generation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// reportedPlan returns the plan event's plan, with its calories in the unit
+// its options ask for.
+func reportedPlan(plan planner.MenuPlan) *planner.MenuPlan {
+	plan = planner.ReportedEnergy(plan)
+	return &plan
+}
+
+// dayEvent returns the event reporting that day dayIndex of gen is done.
+func (gen menuGeneration) dayEvent(dayIndex int, day planner.DailyMenu) generationEvent {
+	day = planner.ReportedDayEnergy(day, gen.opts.Units)
+	return generationEvent{Type: eventDay, Day: dayIndex + 1, Days: gen.opts.Days, Menu: &day}
+}
+
+// Values of the stream query parameter of /generate-menu. streamSSE
+// delivers the plan as Server-Sent Events; streamJSON writes the usual JSON
+// document day by day as it is generated.
+const (
+	streamSSE  = "sse"
+	streamJSON = "json"
+)
+
+// streamGenerationSSE runs gen, writing its progress events as Server-Sent
+// Events: the event name is the event's type and its data the event as JSON.
+// Each event is flushed as soon as it is written.
+func streamGenerationSSE(w http.ResponseWriter, r *http.Request, gen menuGeneration) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher := http.NewResponseController(w)
+	send := func(event generationEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			requestLogger(r).Error("encoding progress event failed", "error", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		flusher.Flush()
+	}
+	gen.opts.OnDay = func(dayIndex int, day planner.DailyMenu) {
+		send(gen.dayEvent(dayIndex, day))
+	}
+	plan, err := gen.run(r.Context())
+	if err != nil {
+		if status, apiErr := generationError(r, err); status != 0 {
+			send(generationEvent{Type: eventError, Error: apiErr.Message})
+		}
+		return
+	}
+	send(generationEvent{Type: eventPlan, Plan: reportedPlan(plan)})
+}
+
+// streamGenerationJSON runs gen and writes the plan as JSON, each day as
+// soon as it is generated, so a long plan is never held in memory whole. The
+// plan is therefore neither cached nor stored and has no plan_id. Days are
+// held back until one has combos, so a plan that gets no combo at all is
+// still answered with 422. A failure once the response has started breaks
+// it off, leaving the document incomplete.
+func streamGenerationJSON(w http.ResponseWriter, r *http.Request, gen menuGeneration) {
+	pw := newPlanStreamWriter(w)
+	var pending []planner.DailyMenu
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		for _, day := range pending {
+			pw.writeDay(day)
+		}
+		pending = nil
+	}
+	gen.opts.DiscardDays = true
+	gen.opts.OnDay = func(dayIndex int, day planner.DailyMenu) {
+		day = planner.ReportedDayEnergy(day, gen.opts.Units)
+		if !started && len(day.Combos) == 0 {
+			pending = append(pending, day)
+			return
+		}
+		if !started {
+			start()
+		}
+		if err := pw.writeDay(day); err != nil {
+			requestLogger(r).Error("writing menu plan failed", "error", err)
+		}
+	}
+
+	ctx, cancel := generationContext(r.Context())
+	defer cancel()
+	plan, err := planner.GenerateWithIndex(ctx, gen.items, gen.opts, gen.index)
+	if err == nil && !started && len(plan.Warnings) > 0 {
+		err = infeasibleError{warnings: plan.Warnings}
+	}
+	if err != nil {
+		if !started {
+			generationFailed(w, r, err)
+			return
+		}
+		generationError(r, err)
+		panic(http.ErrAbortHandler)
+	}
+	if !started {
+		start()
+	}
+	plan.MenuVersion = gen.menuVersion
+	if err := pw.finish(planner.ReportedEnergy(plan)); err != nil {
+		requestLogger(r).Error("writing menu plan failed", "error", err)
+	}
+}
+
+// generateMenuWebSocketHandler handles GET /generate-menu/ws, which takes the
+// query parameters of GET /generate-menu. Invalid settings are answered like
+// /generate-menu; otherwise the connection is upgraded to a WebSocket that
+// receives a "day" event with each DailyMenu as soon as it is generated,
+// then a "plan" event with the stored plan, as JSON text messages. Browsers
+// may connect from the API's own origin or one allowed by cors.
+func generateMenuWebSocketHandler(cors CORSConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Checked here rather than in the handshake, which only runs once the
+		// connection has been taken over from the HTTP server.
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			w.Header().Set("Upgrade", "websocket")
+			writeError(w, "This endpoint requires a WebSocket connection.", http.StatusUpgradeRequired)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !cors.allowsWebSocketOrigin(origin, r.Host) {
+			writeError(w, "WebSocket connections from this origin are not allowed.", http.StatusForbidden)
+			return
+		}
+		gen, ok := prepareGeneration(w, r)
+		if !ok {
+			return
+		}
+		server := websocket.Server{
+			// The origin was checked above, and clients other than browsers send none.
+			Handshake: func(*websocket.Config, *http.Request) error { return nil },
+			Handler: func(conn *websocket.Conn) {
+				defer conn.Close()
+				// A client that goes away does not stop generation; the plan
+				// is still stored and can be fetched from /plans.
+				gen.opts.OnDay = func(dayIndex int, day planner.DailyMenu) {
+					websocket.JSON.Send(conn, gen.dayEvent(dayIndex, day))
+				}
+				plan, err := gen.run(context.WithoutCancel(r.Context()))
+				if err != nil {
+					if status, apiErr := generationError(r, err); status != 0 {
+						websocket.JSON.Send(conn, generationEvent{Type: eventError, Error: apiErr.Message})
+					}
+					return
+				}
+				websocket.JSON.Send(conn, generationEvent{Type: eventPlan, Plan: reportedPlan(plan)})
+			},
+		}
+		server.ServeHTTP(w, r)
+	}
+}
+
+// allowsWebSocketOrigin reports whether a browser on origin may open a
+// WebSocket to host: the API's own origin always may, other origins when
+// CORS allows them.
+func (cfg CORSConfig) allowsWebSocketOrigin(origin, host string) bool {
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, host) {
+		return true
+	}
+	return cfg.allowsOrigin(origin)
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"task/menu"
+	"task/planner"
+)
+
+// tenantHeader selects the tenant of a request when the tenant query
+// parameter is not given.
+const tenantHeader = "X-Tenant-ID"
+
+// tenant is one cafeteria served by the deployment, with its own master
+// menu, plans, profiles and generation settings.
+type tenant struct {
+	name string
+	// storage persists the tenant's master menu, plans and profiles.
+	storage Storage
+	// menu is the master menu used for generation and edited through /menu-items.
+	menu *menuStore
+	// defaults holds the generation settings used when a request does not override them.
+	defaults planner.GenerationOptions
+	// learningRate is the fraction of the way each piece of feedback moves
+	// an item's popularity score towards the score it implies.
+	learningRate float64
+	// notify selects who besides the webhooks hears about generated plans.
+	notify NotifyConfig
+	// plans caches seeded plans by the inputs that determined them; nil
+	// when server.plan_cache_size is 0.
+	plans *planCache
+	// planEdits serializes the requests changing a stored plan.
+	planEdits planMutexes
+}
+
+// tenants holds every configured tenant by name. The tenant named "" uses
+// the top-level settings and serves requests that name no tenant.
+var tenants map[string]*tenant
+
+// openTenant opens the storage of a tenant configured by cfg and loads its
+// master menu, seeding the storage from cfg.MenuPath when it holds no menu yet.
+func openTenant(name string, cfg Config) (*tenant, error) {
+	store, err := openStorage(cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error opening storage: %w", err)
+	}
+	items, version, err := store.LoadMenu()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("error loading menu from storage: %w", err)
+	}
+	seeded := false
+	if len(items) == 0 {
+		// Nothing stored yet: seed the storage from the menu file.
+		items, err = menu.LoadFile(cfg.MenuPath)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error loading menu file: %w", err)
+		}
+		if problems := menu.Validate(items); len(problems) > 0 {
+			store.Close()
+			return nil, fmt.Errorf("refusing to seed the menu from %s: %s", cfg.MenuPath, strings.Join(problems, "; "))
+		}
+		seeded = true
+	} else {
+		// A stored menu is served as it is, so it can still be fixed
+		// through the API, but its problems are flagged.
+		for _, problem := range menu.Validate(items) {
+			slog.Warn("stored menu has a problem", "tenant", name, "problem", problem)
+		}
+	}
+	if seeded || version == 0 {
+		// A menu stored before versions were kept becomes version 1.
+		if version, err = store.SaveMenu(items); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error saving menu to storage: %w", err)
+		}
+	}
+	t := &tenant{
+		name:         name,
+		storage:      store,
+		menu:         newMenuStore(items, version, store),
+		defaults:     cfg.generationOptions(),
+		learningRate: cfg.Feedback.LearningRate,
+		notify:       cfg.Notify,
+		plans:        newPlanCache(cfg.Server.PlanCacheSize),
+	}
+	t.menu.Snapshot() // Build the combo index before serving requests.
+	return t, nil
+}
+
+// menuSnapshot returns the tenant's current master menu, its combo index
+// and version, tracing the load under the span in ctx.
+func (t *tenant) menuSnapshot(ctx context.Context) ([]menu.Item, *planner.ComboIndex, int) {
+	_, s := startSpan(ctx, "load_menu")
+	defer s.end()
+	items, index, version := t.menu.Snapshot()
+	s.set("menu.items", len(items))
+	s.set("menu.version", version)
+	return items, index, version
+}
+
+// openTenants opens the default tenant and every tenant configured in cfg.
+func openTenants(cfg Config) (map[string]*tenant, error) {
+	opened := make(map[string]*tenant, len(cfg.Tenants)+1)
+	open := func(name string, tc Config) error {
+		t, err := openTenant(name, tc)
+		if err != nil {
+			closeTenants(opened)
+			if name != "" {
+				return fmt.Errorf("tenant %s: %w", name, err)
+			}
+			return err
+		}
+		opened[name] = t
+		return nil
+	}
+	if err := open("", cfg); err != nil {
+		return nil, err
+	}
+	for name := range cfg.Tenants {
+		tc, err := cfg.tenantConfig(name)
+		if err == nil {
+			err = open(name, tc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opened, nil
+}
+
+// closeTenants releases the storage of every tenant.
+func closeTenants(opened map[string]*tenant) {
+	for _, t := range opened {
+		t.storage.Close()
+	}
+}
+
+type tenantContextKey struct{}
+
+// withTenant resolves the tenant named by the tenant query parameter or the
+// X-Tenant-ID header and makes it available to next through currentTenant.
+// Requests for an unknown tenant are rejected.
+func withTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := requestTenantName(r)
+		t, ok := tenants[name]
+		if !ok {
+			writeError(w, fmt.Sprintf("unknown tenant %q", name), http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, t)))
+	})
+}
+
+// requestTenantName returns the tenant named by the request, "" for the
+// top-level tenant.
+func requestTenantName(r *http.Request) string {
+	if name := r.URL.Query().Get("tenant"); name != "" {
+		return name
+	}
+	return r.Header.Get(tenantHeader)
+}
+
+// currentTenant returns the tenant of a request passed through withTenant.
+func currentTenant(r *http.Request) *tenant {
+	return contextTenant(r.Context())
+}
+
+// contextTenant returns the tenant carried by ctx, or the top-level tenant.
+func contextTenant(ctx context.Context) *tenant {
+	if t, ok := ctx.Value(tenantContextKey{}).(*tenant); ok {
+		return t
+	}
+	return tenants[""]
+}
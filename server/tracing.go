@@ -0,0 +1,429 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"task/planner"
+)
+
+// TracingConfig exports traces of requests and plan generation to an
+// OpenTelemetry collector over OTLP/HTTP. It is disabled when Endpoint is empty.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g.
+	// "http://localhost:4318"; spans are posted to its /v1/traces path.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// Headers are added to every export request, e.g. for collector authentication.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+	// ServiceName identifies the service in the tracing backend.
+	ServiceName string `json:"service_name" yaml:"service_name"`
+	// SampleRatio is the share of new traces recorded, between 0 and 1.
+	// Requests continuing a trace follow the caller's sampling decision.
+	SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// validate reports the first setting that cannot be used.
+func (cfg TracingConfig) validate() error {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("endpoint must be an http or https URL, got %q", cfg.Endpoint)
+	}
+	if cfg.ServiceName == "" {
+		return errors.New("service_name is required")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio must be between 0 and 1, got %g", cfg.SampleRatio)
+	}
+	return nil
+}
+
+// OTLP span kinds and status codes.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanStatusError  = 2
+)
+
+// span is one timed operation of a trace. A nil *span is valid and records
+// nothing, so code can be instrumented without checking whether tracing is on.
+type span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu     sync.Mutex
+	attrs  map[string]any
+	errMsg string
+	failed bool
+}
+
+// tracer records and exports spans; nil when tracing is disabled.
+var tracer *spanExporter
+
+type spanContextKey struct{}
+
+// startSpan starts a span named name as a child of the span in ctx and
+// returns a context carrying it. It returns a nil span when ctx carries no
+// span, because tracing is disabled or the trace is not sampled.
+func startSpan(ctx context.Context, name string) (context.Context, *span) {
+	parent := spanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	s := parent.child(name)
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+// spanFromContext returns the span carried by ctx, or nil.
+func spanFromContext(ctx context.Context) *span {
+	s, _ := ctx.Value(spanContextKey{}).(*span)
+	return s
+}
+
+// child starts a span of the same trace with s as its parent.
+func (s *span) child(name string) *span {
+	if s == nil {
+		return nil
+	}
+	c := &span{traceID: s.traceID, parentID: s.spanID, name: name, kind: spanKindInternal, start: time.Now()}
+	rand.Read(c.spanID[:])
+	return c
+}
+
+// set records an attribute of the span.
+func (s *span) set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// add adds n to an integer attribute of the span, such as a count of attempts.
+func (s *span) add(key string, n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	total, _ := s.attrs[key].(int)
+	s.attrs[key] = total + n
+}
+
+// fail marks the span as failed with message.
+func (s *span) fail(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed, s.errMsg = true, message
+}
+
+// end finishes the span and queues it for export.
+func (s *span) end() {
+	if s == nil || tracer == nil {
+		return
+	}
+	tracer.export(s, time.Now())
+}
+
+// traceparentHeader carries the W3C trace context of a request.
+const traceparentHeader = "traceparent"
+
+// parseTraceparent parses a W3C traceparent header into the calling span's
+// trace and span IDs and its sampled flag.
+func parseTraceparent(header string) (traceID [16]byte, spanID [8]byte, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceID, spanID, false, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, spanID, false, false
+	}
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || spanID == [8]byte{} {
+		return traceID, spanID, false, false
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return traceID, spanID, false, false
+	}
+	return traceID, spanID, flags&1 == 1, true
+}
+
+// withTracing wraps next so every request runs in a server span named after
+// the mux route serving it, continuing the caller's trace when the request
+// carries a traceparent header.
+func withTracing(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tracer == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s := startServerSpan(r.Header.Get(traceparentHeader))
+		if s == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		s.name = r.Method + " " + strings.TrimPrefix(route, r.Method+" ")
+		s.set("http.request.method", r.Method)
+		s.set("http.route", route)
+		s.set("url.path", r.URL.Path)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), spanContextKey{}, s)))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		s.set("http.response.status_code", rec.status)
+		if rec.status >= http.StatusInternalServerError {
+			s.fail(http.StatusText(rec.status))
+		}
+		s.end()
+	})
+}
+
+// startServerSpan starts the span of a server request, continuing the
+// caller's trace when traceparent is a valid W3C traceparent header. It
+// returns nil when tracing is disabled or the trace is not sampled.
+func startServerSpan(traceparent string) *span {
+	if tracer == nil {
+		return nil
+	}
+	s := &span{kind: spanKindServer, start: time.Now()}
+	traceID, parentID, sampled, ok := parseTraceparent(traceparent)
+	if ok {
+		s.traceID, s.parentID = traceID, parentID
+	} else {
+		rand.Read(s.traceID[:])
+		sampled = tracer.sampled(s.traceID)
+	}
+	if !sampled {
+		return nil
+	}
+	rand.Read(s.spanID[:])
+	return s
+}
+
+// Limits of the span exporter.
+const (
+	spanQueueSize     = 2048
+	spanBatchSize     = 512
+	spanExportEvery   = 5 * time.Second
+	spanExportTimeout = 10 * time.Second
+)
+
+// finishedSpan is a span queued for export with its end time.
+type finishedSpan struct {
+	span *span
+	end  time.Time
+}
+
+// spanExporter batches finished spans and posts them to an OTLP/HTTP
+// collector in the JSON encoding. Spans are dropped when the queue is full
+// rather than slowing down requests.
+type spanExporter struct {
+	cfg    TracingConfig
+	client *http.Client
+	queue  chan finishedSpan
+	done   chan struct{}
+
+	closeOnce sync.Once
+}
+
+// newSpanExporter starts exporting spans as configured by cfg, or returns
+// nil when tracing is disabled.
+func newSpanExporter(cfg TracingConfig) *spanExporter {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	e := &spanExporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: spanExportTimeout},
+		queue:  make(chan finishedSpan, spanQueueSize),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// sampled decides whether a new trace is recorded, deterministically from
+// its random trace ID.
+func (e *spanExporter) sampled(traceID [16]byte) bool {
+	if e.cfg.SampleRatio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])>>11)/(1<<53) < e.cfg.SampleRatio
+}
+
+// export queues a finished span.
+func (e *spanExporter) export(s *span, end time.Time) {
+	select {
+	case e.queue <- finishedSpan{span: s, end: end}:
+	default:
+		slog.Debug("span dropped, export queue is full", "span", s.name)
+	}
+}
+
+// run sends the queued spans in batches until the queue is closed.
+func (e *spanExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(spanExportEvery)
+	defer ticker.Stop()
+	var batch []finishedSpan
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.post(batch); err != nil {
+			slog.Warn("exporting spans failed", "spans", len(batch), "error", err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if batch = append(batch, s); len(batch) >= spanBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// shutdown exports the spans still queued, waiting until ctx is done at most.
+func (e *spanExporter) shutdown(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	e.closeOnce.Do(func() { close(e.queue) })
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+	}
+}
+
+// post sends batch to the collector as an OTLP ExportTraceServiceRequest.
+func (e *spanExporter) post(batch []finishedSpan) error {
+	spans := make([]map[string]any, 0, len(batch))
+	for _, f := range batch {
+		spans = append(spans, f.span.otlp(f.end))
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": otlpAttributes(map[string]any{"service.name": e.cfg.ServiceName})},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "planner"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(e.cfg.Endpoint, "/")+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// otlp encodes the span in the OTLP JSON encoding.
+func (s *span) otlp(end time.Time) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoded := map[string]any{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attrs),
+	}
+	if s.parentID != [8]byte{} {
+		encoded["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if s.failed {
+		encoded["status"] = map[string]any{"code": spanStatusError, "message": s.errMsg}
+	}
+	return encoded
+}
+
+// otlpAttributes encodes attributes as OTLP key-value pairs.
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	encoded := make([]map[string]any, 0, len(attrs))
+	for key, value := range attrs {
+		var v map[string]any
+		switch value := value.(type) {
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(value)}
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(value, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": value}
+		case bool:
+			v = map[string]any{"boolValue": value}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(value)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": v})
+	}
+	return encoded
+}
+
+// generationSpan records plan generation in the trace of s.
+type generationSpan struct {
+	s *span
+}
+
+func (g generationSpan) Child(name string) planner.Span { return generationSpan{g.s.child(name)} }
+func (g generationSpan) Set(key string, value any)      { g.s.set(key, value) }
+func (g generationSpan) Add(key string, n int)          { g.s.add(key, n) }
+func (g generationSpan) Fail(message string)            { g.s.fail(message) }
+func (g generationSpan) End()                           { g.s.end() }
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"task/planner"
+)
+
+var (
+	errWebhookNotFound = errors.New("webhook not found")
+	// errWebhookRejected marks a delivery the receiver answered with a 4xx
+	// status, which is not retried.
+	errWebhookRejected = errors.New("webhook rejected the event")
+)
+
+// eventPlanGenerated is the webhook event sent when a plan is generated.
+const eventPlanGenerated = "plan.generated"
+
+// Webhook is a URL notified with a signed HTTP POST whenever one of the
+// tenant's plans is generated.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret keys the HMAC-SHA256 signature sent in X-Webhook-Signature. It
+	// is generated when not given, and only returned when the webhook is
+	// created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// validate reports the first problem with the webhook.
+func (h Webhook) validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("url %q must be an absolute http or https URL", h.URL)
+	}
+	return nil
+}
+
+// redacted returns the webhook without its secret.
+func (h Webhook) redacted() Webhook {
+	h.Secret = ""
+	return h
+}
+
+// webhookEvent is the JSON body posted to a webhook.
+type webhookEvent struct {
+	Event  string           `json:"event"`
+	Tenant string           `json:"tenant,omitempty"`
+	Plan   planner.MenuPlan `json:"plan"`
+}
+
+// Webhook deliveries are retried with a doubling delay when the receiver
+// cannot be reached or answers with a 5xx status.
+const (
+	webhookAttempts     = 3
+	webhookRetryDelay   = time.Second
+	webhookPostDeadline = 10 * time.Second
+)
+
+var webhookClient = &http.Client{Timeout: webhookPostDeadline}
+
+// signWebhookBody returns the X-Webhook-Signature of body: "sha256=" and the
+// hex HMAC-SHA256 of the body keyed by the webhook's secret.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postPlanToWebhooks posts the plan to every webhook of t in the background.
+func postPlanToWebhooks(t *tenant, plan planner.MenuPlan, logger *slog.Logger) {
+	hooks, err := t.storage.ListWebhooks()
+	if err != nil {
+		logger.Error("listing webhooks failed", "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(webhookEvent{Event: eventPlanGenerated, Tenant: t.name, Plan: plan})
+	if err != nil {
+		logger.Error("encoding webhook event failed", "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		pendingNotifications.Add(1)
+		go func(hook Webhook) {
+			defer pendingNotifications.Done()
+			deliverWebhook(hook, eventPlanGenerated, body, logger.With("webhook_id", hook.ID, "plan_id", plan.PlanID))
+		}(hook)
+	}
+}
+
+// deliverWebhook posts body to hook, retrying failed attempts.
+func deliverWebhook(hook Webhook, event string, body []byte, logger *slog.Logger) {
+	deliveryID := newPlanID()
+	delay := webhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := postWebhook(hook, event, deliveryID, body)
+		if err == nil {
+			logger.Info("webhook delivered", "attempt", attempt)
+			return
+		}
+		if attempt == webhookAttempts || errors.Is(err, errWebhookRejected) {
+			logger.Error("webhook delivery failed", "attempts", attempt, "error", err)
+			return
+		}
+		logger.Warn("webhook delivery failed, retrying", "attempt", attempt, "error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// postWebhook makes one delivery attempt.
+func postWebhook(hook Webhook, event, deliveryID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "menu-planner-webhooks")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Signature", signWebhookBody(hook.Secret, body))
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("receiver answered %s", resp.Status)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("%w: %s", errWebhookRejected, resp.Status)
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret.
+func newWebhookSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate webhook secret: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// webhookStoreError writes the response for a failed webhook storage call.
+func webhookStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errWebhookNotFound) {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	requestLogger(r).Error("accessing webhooks failed", "error", err)
+	writeError(w, "Unable to access the stored webhooks.", http.StatusInternalServerError)
+}
+
+// listWebhooksHandler handles GET /webhooks. Secrets are left out.
+func listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := currentTenant(r).storage.ListWebhooks()
+	if err != nil {
+		webhookStoreError(w, r, err)
+		return
+	}
+	for i := range hooks {
+		hooks[i] = hooks[i].redacted()
+	}
+	writeJSON(w, http.StatusOK, hooks)
+}
+
+// getWebhookHandler handles GET /webhooks/{id}. The secret is left out.
+func getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	hook, err := currentTenant(r).storage.GetWebhook(r.PathValue("id"))
+	if err != nil {
+		webhookStoreError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hook.redacted())
+}
+
+// createWebhookHandler handles POST /webhooks, registering a URL with the
+// tenant of the request. The response holds the signing secret.
+func createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var hook Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		writeError(w, fmt.Sprintf("invalid webhook: %v", err), http.StatusBadRequest)
+		return
+	}
+	hook.URL = strings.TrimSpace(hook.URL)
+	if err := hook.validate(); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hook.ID = newPlanID()
+	if hook.Secret == "" {
+		hook.Secret = newWebhookSecret()
+	}
+	hook.CreatedAt = time.Now().UTC()
+	if err := currentTenant(r).storage.SaveWebhook(hook); err != nil {
+		webhookStoreError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/webhooks/"+hook.ID)
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+// deleteWebhookHandler handles DELETE /webhooks/{id}.
+func deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if err := currentTenant(r).storage.DeleteWebhook(r.PathValue("id")); err != nil {
+		webhookStoreError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}